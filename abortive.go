@@ -0,0 +1,105 @@
+package main
+
+// Abortive draws: the round ends without a winner, the dealer keeps the seat and a honba is added
+
+type AbortiveDraw int
+
+const (
+	Abortive_None           AbortiveDraw = iota
+	Abortive_KyuushuKyuuhai              // A seat showed nine different terminals and honors on its first draw
+	Abortive_SuufonRenda                 // Every seat discarded the same wind on the first go-around
+	Abortive_SuuchaRiichi                // Every seat declared riichi
+	Abortive_Suukaikan                   // Four kans were declared by more than one seat
+	Abortive_Sanchahou                   // Three seats ronned the same discard
+)
+
+func (a AbortiveDraw) String() string {
+	return [...]string{"None", "Kyuushu Kyuuhai", "Suufon Renda", "Suucha Riichi", "Suukaikan", "Sanchahou"}[a]
+}
+
+// Counts the different terminals and honors in a hand
+func terminalHonorKinds(hand Hand) int {
+	kinds := 0
+	for id, count := range hand.counts {
+		if count > 0 && ParseTile(id, false).IsTerminalOrHonor() {
+			kinds++
+		}
+	}
+	return kinds
+}
+
+// Offers kyuushu kyuuhai on a seat's first draw when no call has been made and the hand
+// holds nine or more different terminals and honors
+func (r *Round) kyuushuOptions(seat int, drawn *Tile) []Action {
+	s := r.seats[seat]
+	if !r.Rules.KyuushuKyuuhai || drawn == nil || len(s.discards) > 0 || r.interrupted {
+		return nil
+	}
+	if terminalHonorKinds(s.hand()) < 9 {
+		return nil
+	}
+	return []Action{{Type: Action_KyuushuKyuuhai, Tile: *drawn}}
+}
+
+// Checks the abortive draws that take effect once a discard has passed without a ron
+func (r *Round) abortAfterDiscard() AbortiveDraw {
+	n := r.players()
+	if r.Rules.SuufonRenda && n == 4 && r.discards == n && !r.interrupted {
+		first := r.seats[0].discards[0].Tile.ID
+		same := first >= 27 && first <= 30
+		for _, s := range r.seats {
+			if s.discards[0].Tile.ID != first {
+				same = false
+			}
+		}
+		if same {
+			return Abortive_SuufonRenda
+		}
+	}
+	if r.Rules.SuuchaRiichi && n == 4 {
+		all := true
+		for _, s := range r.seats {
+			if !s.riichi {
+				all = false
+			}
+		}
+		if all {
+			return Abortive_SuuchaRiichi
+		}
+	}
+	if r.Rules.Suukaikan && r.wall.Kans() == 4 {
+		seats := 0
+		for _, s := range r.seats {
+			for _, m := range s.melds {
+				if m.Type == Kantsu {
+					seats++
+					break
+				}
+			}
+		}
+		if seats > 1 {
+			return Abortive_Suukaikan
+		}
+	}
+	return Abortive_None
+}
+
+// Ends the round with an abortive draw; riichi sticks stay on the table
+func (r *Round) abort(kind AbortiveDraw) RoundResult {
+	r.emit(Event{Type: Event_AbortiveDraw, Abortive: kind})
+	return RoundResult{
+		Abortive:     kind,
+		DealerRepeat: true,
+		Deltas:       make([]int, r.players()),
+		RiichiSticks: r.sticks,
+	}
+}
+
+// Returns the honba count for the next round: it grows when the dealer repeats or the
+// round is drawn and resets when a non-dealer wins
+func (res RoundResult) NextHonba(honba int) int {
+	if res.DealerRepeat || len(res.Wins) == 0 {
+		return honba + 1
+	}
+	return 0
+}
@@ -0,0 +1,119 @@
+package main
+
+import "testing"
+
+func TestRound_KyuushuOptions(t *testing.T) {
+	nine := []int{0, 8, 9, 17, 18, 27, 28, 29, 31, 4, 5, 6, 13, 14} // nine kinds with the draw
+	eight := []int{0, 8, 9, 17, 18, 27, 28, 29, 3, 4, 5, 6, 13, 14}
+	pass := []ActionType{Action_Pass, Action_Pass, Action_Pass, Action_Pass}
+	drawn := ParseTile(14, false)
+
+	tests := []struct {
+		name  string
+		tiles []int
+		setup func(r *Round)
+		want  bool
+	}{
+		{"Nine kinds on the first draw", nine, func(r *Round) {}, true},
+		{"Eight kinds", eight, func(r *Round) {}, false},
+		{"After a call", nine, func(r *Round) { r.interrupt() }, false},
+		{"After discarding", nine, func(r *Round) { r.seats[0].discards = []Discard{{Tile: ParseTile(1, false)}} }, false},
+		{"Disabled by the rules", nine, func(r *Round) { r.Rules.KyuushuKyuuhai = false }, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := scriptedRound(DefaultRules(), pass, tt.tiles)
+			tt.setup(r)
+			if got := len(r.kyuushuOptions(0, &drawn)) > 0; got != tt.want {
+				t.Errorf("kyuushuOptions() offered = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRound_AbortAfterDiscard(t *testing.T) {
+	pass := []ActionType{Action_Pass, Action_Pass, Action_Pass, Action_Pass}
+	discardAll := func(r *Round, ids ...int) {
+		for seat, id := range ids {
+			r.seats[seat].tiles = append(r.seats[seat].tiles, ParseTile(id, false))
+			r.discard(seat, ParseTile(id, false), true, false)
+		}
+	}
+
+	tests := []struct {
+		name  string
+		setup func(r *Round)
+		want  AbortiveDraw
+	}{
+		{"Four of the same wind", func(r *Round) { discardAll(r, 28, 28, 28, 28) }, Abortive_SuufonRenda},
+		{"Four of the same dragon", func(r *Round) { discardAll(r, 31, 31, 31, 31) }, Abortive_None},
+		{"Different winds", func(r *Round) { discardAll(r, 28, 28, 28, 27) }, Abortive_None},
+		{"Every seat in riichi", func(r *Round) {
+			for _, s := range r.seats {
+				s.riichi = true
+			}
+		}, Abortive_SuuchaRiichi},
+		{"Four kans by two seats", func(r *Round) {
+			r.seats[0].melds = []Set{{Type: Kantsu, Tiles: tilesOf(0, 0, 0, 0)}}
+			r.seats[1].melds = []Set{{Type: Kantsu, Tiles: tilesOf(1, 1, 1, 1)}}
+			for i := 0; i < 4; i++ {
+				r.wall.DrawRinshan()
+			}
+		}, Abortive_Suukaikan},
+		{"Four kans by one seat", func(r *Round) {
+			r.seats[0].melds = []Set{{Type: Kantsu, Tiles: tilesOf(0, 0, 0, 0)}}
+			for i := 0; i < 4; i++ {
+				r.wall.DrawRinshan()
+			}
+		}, Abortive_None},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := scriptedRound(DefaultRules(), pass, nil, nil, nil, nil)
+			tt.setup(r)
+			if got := r.abortAfterDiscard(); got != tt.want {
+				t.Errorf("abortAfterDiscard() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRound_Abort(t *testing.T) {
+	setup := testSetup(1)
+	setup.RiichiSticks = 1
+	r := NewRound(DefaultRules(), setup, greedyAgents())
+	r.payRiichiDeposit(2)
+	result := r.finish(r.abort(Abortive_SuuchaRiichi))
+	if !result.DealerRepeat || result.RiichiSticks != 2 || result.Abortive != Abortive_SuuchaRiichi {
+		t.Errorf("abort() = %+v, want the dealer to repeat with 2 sticks on the table", result)
+	}
+	if result.Deltas[2] != -1000 || result.Scores[2] != 24000 {
+		t.Errorf("abort() deltas = %v, want the riichi deposit kept on the table", result.Deltas)
+	}
+	if got := result.NextHonba(setup.Honba); got != 1 {
+		t.Errorf("NextHonba() = %v, want 1", got)
+	}
+	if ev := r.Events[len(r.Events)-1]; ev.Type != Event_AbortiveDraw || ev.Abortive != Abortive_SuuchaRiichi {
+		t.Errorf("last event = %+v, want the abortive draw", ev)
+	}
+}
+
+func TestRoundResult_NextHonba(t *testing.T) {
+	tests := []struct {
+		name   string
+		result RoundResult
+		want   int
+	}{
+		{"Dealer win", RoundResult{Wins: []Win{{Seat: 0}}, DealerRepeat: true}, 3},
+		{"Non-dealer win", RoundResult{Wins: []Win{{Seat: 1}}}, 0},
+		{"Exhaustive draw, dealer noten", RoundResult{Exhaustive: true}, 3},
+		{"Abortive draw", RoundResult{Abortive: Abortive_SuufonRenda, DealerRepeat: true}, 3},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.result.NextHonba(2); got != tt.want {
+				t.Errorf("NextHonba() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
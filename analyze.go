@@ -0,0 +1,282 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"slices"
+)
+
+// Review of recorded games: every discard is graded against the evaluator's recommendation,
+// as seen by the discarding seat at that point of the round
+
+// EV loss in points above which a discard is flagged as a mistake by default
+const DefaultMistakeThreshold = 500.0
+
+// Grade of one discard
+type DiscardAnalysis struct {
+	Round       int          // index of the round in the game
+	Seat        int          // seat that discarded
+	Turn        int          // the seat's discards so far in the round, counting this one
+	Hand        []Tile       // concealed tiles before the discard
+	Discard     Tile         // the tile discarded
+	Riichi      bool         // the discard declared riichi
+	Recommended Tile         // the evaluator's discard
+	Decision    DecisionType // the evaluator's decision behind it
+
+	Shanten            Deficiency // deficiency after the discard
+	RecommendedShanten Deficiency
+	Ukeire             int // unseen tiles improving the hand after the discard
+	RecommendedUkeire  int
+	EV                 float64 // expected points of the discard
+	RecommendedEV      float64
+	Loss               float64 // EV given up against the recommendation
+	Mistake            bool    // the loss is above the threshold, or the hand went back while pushing
+}
+
+func (a DiscardAnalysis) String() string {
+	s := fmt.Sprintf("round %d seat %d turn %d: %v (%d shanten, %d ukeire, %.0f EV)",
+		a.Round+1, a.Seat, a.Turn, a.Discard, a.Shanten, a.Ukeire, a.EV)
+	if a.Riichi {
+		s += " riichi"
+	}
+	if a.Discard.ID != a.Recommended.ID {
+		s += fmt.Sprintf(", recommended %v (%d shanten, %d ukeire, %.0f EV), loss %.0f",
+			a.Recommended, a.RecommendedShanten, a.RecommendedUkeire, a.RecommendedEV, a.Loss)
+	}
+	if a.Mistake {
+		s += " MISTAKE"
+	}
+	return s
+}
+
+// Totals of the graded discards of one seat
+type PlayerSummary struct {
+	Seat     int
+	Discards int
+	Agreed   int // discards matching the recommendation
+	Mistakes int
+	Loss     float64 // total EV lost, counting only discards worse than the recommendation
+}
+
+// Expected points of a discard, estimated as Decide estimates its push and fold: pushing on
+// from the hand left (with riichi or dama on a closed tenpai), or folding after this tile,
+// whichever is better
+func discardEV(state DecisionState, td TileDanger, risk float64) (Deficiency, int, float64) {
+	hand := state.Hand
+	hand.counts[td.Tile.ID]--
+	shanten := CalculateDeficiency(hand, len(state.Melds))
+	_, ukeire := Ukeire(hand, len(state.Melds), state.KB)
+	draws := state.WallRemaining / (len(state.Opponents) + 1)
+	winRate := EstimateWinRate(shanten, ukeire, state.KB.Total(), draws)
+	closed := !slices.ContainsFunc(state.Melds, func(m Set) bool { return m.Open })
+	value := EstimateHandValue(hand, state.Melds, state.KB, state.WinCtx)
+	threat := threatValue(state, td)
+	dealIn := td.Danger / 100
+	push := winRate*ownValue(state, value, shanten, closed) - risk*threat*(1-math.Pow(1-dealIn, float64(shanten)+1))
+	if shanten == 0 && closed && !state.Riichi && value.Remaining > 0 && canRiichi(state) {
+		riichi := winRate*riichiWinShare*value.Expected(true, defaultTsumoRate) -
+			risk*threat*(1-math.Pow(1-dealIn, 2)) - 1000*(1-winRate*riichiWinShare)
+		push = max(push, riichi)
+	}
+	fold := -risk * threat * dealIn
+	return shanten, ukeire, max(push, fold)
+}
+
+// Grades a discard against the evaluator from the view of the discarding seat before it
+func analyzeDiscard(view *PlayerView, tile Tile, riichi bool, threshold float64) (DiscardAnalysis, error) {
+	state := view.DecisionState()
+	decision, err := Decide(state)
+	if err != nil {
+		return DiscardAnalysis{}, err
+	}
+	a := DiscardAnalysis{
+		Seat:        view.Seat,
+		Turn:        len(view.Discards[view.Seat]) + 1,
+		Hand:        view.Tiles,
+		Discard:     tile,
+		Riichi:      riichi,
+		Recommended: decision.Discard,
+		Decision:    decision.Type,
+	}
+	_, risk := placementRisk(state)
+	for _, td := range EstimateDanger(state.Hand, state.Opponents, state.KB) {
+		if td.Tile.ID == tile.ID {
+			a.Shanten, a.Ukeire, a.EV = discardEV(state, td, risk)
+		}
+		if td.Tile.ID == decision.Discard.ID {
+			a.RecommendedShanten, a.RecommendedUkeire, a.RecommendedEV = discardEV(state, td, risk)
+		}
+	}
+	a.Loss = a.RecommendedEV - a.EV
+	// The EV estimate is optimistic for hands far from tenpai, so going back in shanten
+	// while the evaluator pushes is a mistake whatever the estimate says
+	backwards := decision.Type != Decision_Fold && a.Shanten > a.RecommendedShanten
+	a.Mistake = tile.ID != decision.Discard.ID && (a.Loss > threshold || backwards)
+	return a, nil
+}
+
+// Grades every discard of a recorded round that was a choice: discards after a riichi are
+// forced and skipped. The round is rebuilt from the dealt hands and the recorded events,
+// so imported records that cannot be re-simulated can be analyzed too.
+func AnalyzeRound(rules Rules, record RoundRecord, threshold float64) ([]DiscardAnalysis, error) {
+	n := rules.Players
+	if len(record.Hands) != n || len(record.Setup.Scores) != n {
+		return nil, fmt.Errorf("analyze: %d hands and %d scores, want %d", len(record.Hands), len(record.Setup.Scores), n)
+	}
+	r := newShadowRound(rules, record.Setup)
+	for seat, hand := range record.Hands {
+		r.seats[seat].tiles = append([]Tile{}, hand...)
+	}
+
+	var analysis []DiscardAnalysis
+	var drawn *Tile
+	next := drawLive
+	declared := make([]*PlayerView, n) // views of seats declaring riichi, taken before the declaration
+	for i, ev := range record.Events {
+		if ev.Seat < 0 || ev.Seat >= n {
+			return nil, fmt.Errorf("analyze: event %d: invalid seat %d", i, ev.Seat)
+		}
+		s := r.seats[ev.Seat]
+		switch ev.Type {
+		case Event_NewDora:
+			r.wall.showIndicator(ev.Tile)
+		case Event_Draw:
+			if ev.Tile.ID < 0 {
+				return nil, fmt.Errorf("analyze: event %d: hidden draw", i)
+			}
+			switch next {
+			case drawRinshan:
+				r.wall.DrawRinshan()
+			case drawKita:
+				r.wall.DrawKita()
+			default:
+				r.wall.Draw()
+			}
+			next = drawLive
+			s.tiles = append(s.tiles, ev.Tile)
+			t := ev.Tile
+			drawn = &t
+		case Event_Riichi:
+			declared[ev.Seat] = r.view(ev.Seat, drawn)
+			r.declareRiichi(ev.Seat)
+		case Event_Discard:
+			if !slices.Contains(s.tiles, ev.Tile) {
+				return nil, fmt.Errorf("analyze: event %d: seat %d discards %v it does not hold", i, ev.Seat, ev.Tile)
+			}
+			riichi := declared[ev.Seat] != nil
+			if riichi || !s.riichi {
+				view := declared[ev.Seat]
+				if !riichi {
+					view = r.view(ev.Seat, drawn)
+				}
+				a, err := analyzeDiscard(view, ev.Tile, riichi, threshold)
+				if err != nil {
+					return nil, fmt.Errorf("analyze: event %d: %w", i, err)
+				}
+				analysis = append(analysis, a)
+			}
+			r.discard(ev.Seat, ev.Tile, ev.Tsumogiri, riichi)
+			if riichi && (i+1 == len(record.Events) || record.Events[i+1].Type != Event_Win) {
+				r.payRiichiDeposit(ev.Seat)
+			}
+			declared[ev.Seat], drawn = nil, nil
+		case Event_Call:
+			if err := r.replayCall(ev); err != nil {
+				return nil, fmt.Errorf("analyze: event %d: %w", i, err)
+			}
+			if ev.Call != Action_Chi && ev.Call != Action_Pon {
+				next = drawRinshan
+			}
+			drawn = nil
+		case Event_Kita:
+			r.declareKita(ev.Seat, Action{Type: Action_Kita, Tile: ev.Tile})
+			next, drawn = drawKita, nil
+		}
+	}
+	return analysis, nil
+}
+
+// Applies a recorded call or kan to a shadow round
+func (r *Round) replayCall(ev Event) error {
+	s := r.seats[ev.Seat]
+	switch ev.Call {
+	case Action_Chi, Action_Pon, Action_Daiminkan:
+		if ev.Set.Target < 0 || ev.Set.Target >= r.players() || len(r.seats[ev.Set.Target].discards) == 0 {
+			return fmt.Errorf("%v from seat %d without a discard", ev.Call, ev.Set.Target)
+		}
+		r.applyCall(ev.Seat, ev.Set.Target, Action{Type: ev.Call, Tile: ev.Tile, Tiles: withoutTile(ev.Set.Tiles, ev.Tile)})
+	case Action_Ankan:
+		for _, t := range ev.Set.Tiles {
+			s.remove(t)
+		}
+		s.melds = append(s.melds, ev.Set)
+		r.interrupt()
+	case Action_Shouminkan:
+		i := slices.IndexFunc(s.melds, func(m Set) bool { return m.Type == Koutsu && m.Tiles[0].ID == ev.Tile.ID })
+		if i < 0 {
+			return fmt.Errorf("added kan of %v without a called triplet", ev.Tile)
+		}
+		s.remove(ev.Tile)
+		s.melds[i] = ev.Set
+		r.interrupt()
+	default:
+		return fmt.Errorf("invalid call %v", ev.Call)
+	}
+	return nil
+}
+
+// Grades every discard of a recorded game, imported or native
+func AnalyzeGame(record *GameRecord, threshold float64) ([]DiscardAnalysis, error) {
+	var analysis []DiscardAnalysis
+	for i, round := range record.Rounds {
+		graded, err := AnalyzeRound(record.Rules, round, threshold)
+		if err != nil {
+			return nil, fmt.Errorf("round %d: %w", i+1, err)
+		}
+		for j := range graded {
+			graded[j].Round = i
+		}
+		analysis = append(analysis, graded...)
+	}
+	return analysis, nil
+}
+
+// Totals the graded discards of each of the given number of seats
+func SummarizeAnalysis(analysis []DiscardAnalysis, players int) []PlayerSummary {
+	summaries := make([]PlayerSummary, players)
+	for seat := range summaries {
+		summaries[seat].Seat = seat
+	}
+	for _, a := range analysis {
+		p := &summaries[a.Seat]
+		p.Discards++
+		if a.Discard.ID == a.Recommended.ID {
+			p.Agreed++
+		}
+		if a.Mistake {
+			p.Mistakes++
+		}
+		p.Loss += max(a.Loss, 0)
+	}
+	return summaries
+}
+
+// Writes a review of a graded game: every discard of each player by turn, then their totals
+func WriteAnalysis(w io.Writer, players []string, analysis []DiscardAnalysis) error {
+	for _, p := range SummarizeAnalysis(analysis, len(players)) {
+		if _, err := fmt.Fprintf(w, "%s: %d discards, %d as recommended, %d mistakes, %.0f EV lost\n",
+			players[p.Seat], p.Discards, p.Agreed, p.Mistakes, p.Loss); err != nil {
+			return err
+		}
+		for _, a := range analysis {
+			if a.Seat != p.Seat {
+				continue
+			}
+			if _, err := fmt.Fprintf(w, "  %v\n", a); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
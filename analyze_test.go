@@ -0,0 +1,198 @@
+package main
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+// A round where the dealer is tenpai on 2-5p with tanyao after drawing a white dragon
+func analysisRound(events ...Event) RoundRecord {
+	return RoundRecord{
+		Setup: RoundSetup{Scores: []int{25000, 25000, 25000, 25000}},
+		Hands: [][]Tile{
+			tilesOf(1, 2, 3, 4, 5, 6, 11, 12, 22, 22, 23, 24, 25),
+			tilesOf(3, 4, 5, 6, 7, 8, 15, 16, 17, 18, 19, 20, 28),
+			tilesOf(9, 9, 10, 11, 21, 22, 23, 29, 29, 30, 30, 32, 32),
+			tilesOf(0, 3, 6, 9, 12, 15, 18, 21, 24, 31, 32, 33, 33),
+		},
+		Events: append([]Event{
+			{Type: Event_StartRound},
+			{Type: Event_NewDora, Tile: ParseTile(33, false)},
+			{Type: Event_Draw, Tile: ParseTile(31, false)},
+		}, events...),
+	}
+}
+
+func TestAnalyzeRound(t *testing.T) {
+	tests := []struct {
+		name    string
+		discard int
+		mistake bool
+	}{
+		{"keeps tenpai", 31, false},
+		{"breaks tenpai", 11, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			record := analysisRound(Event{Type: Event_Discard, Tile: ParseTile(tt.discard, false)})
+			got, err := AnalyzeRound(DefaultRules(), record, DefaultMistakeThreshold)
+			if err != nil {
+				t.Fatalf("AnalyzeRound() error = %v", err)
+			}
+			if len(got) != 1 {
+				t.Fatalf("AnalyzeRound() = %d discards, want 1", len(got))
+			}
+			a := got[0]
+			if a.Seat != 0 || a.Turn != 1 || len(a.Hand) != 14 || a.Discard.ID != tt.discard {
+				t.Errorf("AnalyzeRound() seat %d turn %d hand %v discard %v", a.Seat, a.Turn, a.Hand, a.Discard)
+			}
+			if a.Recommended.ID != 31 || a.RecommendedShanten != 0 {
+				t.Errorf("AnalyzeRound() recommended %v at %d shanten, want 5z at 0", a.Recommended, a.RecommendedShanten)
+			}
+			if a.Mistake != tt.mistake || a.Loss != a.RecommendedEV-a.EV {
+				t.Errorf("AnalyzeRound() mistake = %v with loss %v, want %v", a.Mistake, a.Loss, tt.mistake)
+			}
+			if tt.mistake && (a.Shanten != 1 || a.Ukeire <= a.RecommendedUkeire || a.Decision == Decision_Fold) {
+				t.Errorf("AnalyzeRound() %d shanten, %d ukeire, %v", a.Shanten, a.Ukeire, a.Decision)
+			}
+		})
+	}
+}
+
+func TestAnalyzeRound_Riichi(t *testing.T) {
+	record := analysisRound(
+		Event{Type: Event_Riichi},
+		Event{Type: Event_Discard, Tile: ParseTile(31, false), Tsumogiri: true},
+		Event{Type: Event_Draw, Seat: 1, Tile: ParseTile(33, false)},
+		Event{Type: Event_Discard, Seat: 1, Tile: ParseTile(33, false), Tsumogiri: true},
+		Event{Type: Event_Draw, Seat: 2, Tile: ParseTile(8, false)},
+		Event{Type: Event_Discard, Seat: 2, Tile: ParseTile(8, false), Tsumogiri: true},
+		Event{Type: Event_Draw, Seat: 3, Tile: ParseTile(17, false)},
+		Event{Type: Event_Discard, Seat: 3, Tile: ParseTile(17, false), Tsumogiri: true},
+		Event{Type: Event_Draw, Tile: ParseTile(16, false)},
+		Event{Type: Event_Discard, Tile: ParseTile(16, false), Tsumogiri: true},
+	)
+	got, err := AnalyzeRound(DefaultRules(), record, DefaultMistakeThreshold)
+	if err != nil {
+		t.Fatalf("AnalyzeRound() error = %v", err)
+	}
+	// The discard after the riichi is forced and not graded
+	if len(got) != 4 {
+		t.Fatalf("AnalyzeRound() = %d discards, want 4", len(got))
+	}
+	if !got[0].Riichi || got[0].Decision != Decision_Riichi || got[0].Mistake {
+		t.Errorf("AnalyzeRound() riichi discard = %+v", got[0])
+	}
+	for i, a := range got {
+		if a.Seat != i {
+			t.Errorf("AnalyzeRound() discard %d by seat %d, want %d", i, a.Seat, i)
+		}
+	}
+}
+
+func TestAnalyzeRound_Invalid(t *testing.T) {
+	tests := []struct {
+		name   string
+		record RoundRecord
+	}{
+		{"tile not held", analysisRound(Event{Type: Event_Discard, Tile: ParseTile(8, false)})},
+		{"hidden draw", analysisRound(Event{Type: Event_Draw, Seat: 1, Tile: Tile{ID: -1}})},
+		{"call without discard", analysisRound(Event{Type: Event_Call, Seat: 1, Call: Action_Pon, Set: Set{Target: 2}})},
+		{"missing hands", RoundRecord{Setup: RoundSetup{Scores: []int{25000, 25000, 25000, 25000}}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := AnalyzeRound(DefaultRules(), tt.record, DefaultMistakeThreshold); err == nil {
+				t.Errorf("AnalyzeRound() error = nil, want an error")
+			}
+		})
+	}
+}
+
+func TestAnalyzeGame(t *testing.T) {
+	record := testRecord(t)
+	got, err := AnalyzeGame(record, DefaultMistakeThreshold)
+	if err != nil {
+		t.Fatalf("AnalyzeGame() error = %v", err)
+	}
+	// Every discard is graded except those made after a riichi was declared
+	want := 0
+	for _, round := range record.Rounds {
+		riichi := make([]bool, len(round.Setup.Scores))
+		declaring := make([]bool, len(round.Setup.Scores))
+		for _, ev := range round.Events {
+			switch ev.Type {
+			case Event_Riichi:
+				declaring[ev.Seat] = true
+			case Event_Discard:
+				if !riichi[ev.Seat] {
+					want++
+				}
+				riichi[ev.Seat] = riichi[ev.Seat] || declaring[ev.Seat]
+			}
+		}
+	}
+	if len(got) != want {
+		t.Fatalf("AnalyzeGame() = %d discards, want %d", len(got), want)
+	}
+	agreed := 0
+	for _, a := range got {
+		if a.Discard.ID == a.Recommended.ID {
+			agreed++
+			if a.Loss != 0 || a.Mistake {
+				t.Errorf("AnalyzeGame() agreeing discard %v has loss %v", a, a.Loss)
+			}
+		}
+	}
+	// The greedy bots discard for efficiency, as the evaluator does when pushing
+	if agreed < len(got)/2 {
+		t.Errorf("AnalyzeGame() %d of %d discards agree with the evaluator", agreed, len(got))
+	}
+	if last := got[len(got)-1]; last.Round != len(record.Rounds)-1 {
+		t.Errorf("AnalyzeGame() last discard in round %d, want %d", last.Round, len(record.Rounds)-1)
+	}
+
+	// An imported record grades the same discards
+	var b bytes.Buffer
+	if err := WriteTenhouJSON(&b, record); err != nil {
+		t.Fatalf("WriteTenhouJSON() error = %v", err)
+	}
+	imported, err := ParseTenhouJSON(&b)
+	if err != nil {
+		t.Fatalf("ParseTenhouJSON() error = %v", err)
+	}
+	if got, err := AnalyzeGame(imported, DefaultMistakeThreshold); err != nil || len(got) != want {
+		t.Errorf("AnalyzeGame() imported = %d discards, want %d, error %v", len(got), want, err)
+	}
+}
+
+func TestWriteAnalysis(t *testing.T) {
+	record := analysisRound(
+		Event{Type: Event_Discard, Tile: ParseTile(11, false)},
+		Event{Type: Event_Draw, Seat: 1, Tile: ParseTile(33, false)},
+		Event{Type: Event_Discard, Seat: 1, Tile: ParseTile(33, false), Tsumogiri: true},
+	)
+	analysis, err := AnalyzeRound(DefaultRules(), record, DefaultMistakeThreshold)
+	if err != nil {
+		t.Fatalf("AnalyzeRound() error = %v", err)
+	}
+	summaries := SummarizeAnalysis(analysis, 4)
+	if s := summaries[0]; s.Discards != 1 || s.Agreed != 0 || s.Mistakes != 1 || s.Loss != max(analysis[0].Loss, 0) {
+		t.Errorf("SummarizeAnalysis() seat 0 = %+v", s)
+	}
+	if s := summaries[2]; s.Discards != 0 {
+		t.Errorf("SummarizeAnalysis() seat 2 = %+v", s)
+	}
+
+	var b bytes.Buffer
+	if err := WriteAnalysis(&b, []string{"A", "B", "C", "D"}, analysis); err != nil {
+		t.Fatalf("WriteAnalysis() error = %v", err)
+	}
+	out := b.String()
+	for _, want := range []string{"A: 1 discards, 0 as recommended, 1 mistakes", "round 1 seat 0 turn 1: 3p", "recommended 5z", "MISTAKE", "D: 0 discards"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("WriteAnalysis() = %q, want %q", out, want)
+		}
+	}
+}
@@ -0,0 +1,322 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+)
+
+// HTTP JSON API for validating, scoring and analysing hands, and the serve subcommand running it
+
+// Largest request body accepted by the API
+const maxRequestBytes = 1 << 16
+
+// Tiles in a request, given as an MPSZ string such as "123m55z" or as an array of tiles such as ["1m", "0p"]
+type tileList []Tile
+
+func (l *tileList) UnmarshalJSON(data []byte) error {
+	var s string
+	if err := json.Unmarshal(data, &s); err == nil {
+		tiles, err := ParseTiles(s)
+		*l = tiles
+		return err
+	}
+	var tiles []Tile
+	if err := json.Unmarshal(data, &tiles); err != nil {
+		return fmt.Errorf("tiles must be an MPSZ string or an array of tiles: %w", err)
+	}
+	*l = tiles
+	return nil
+}
+
+// A called set or closed kan in a request, given as "kind:tiles" like the meld flag of the
+// command line or as an object such as {"kind": "pon", "tiles": "555z"}
+type apiMeld struct {
+	Set
+}
+
+func (m *apiMeld) UnmarshalJSON(data []byte) error {
+	var s string
+	if err := json.Unmarshal(data, &s); err != nil {
+		var obj struct {
+			Kind  string   `json:"kind"`
+			Tiles tileList `json:"tiles"`
+		}
+		if err := json.Unmarshal(data, &obj); err != nil {
+			return err
+		}
+		s = obj.Kind + ":" + FormatTiles(obj.Tiles)
+	}
+	var melds meldFlags
+	if err := melds.Set(s); err != nil {
+		return err
+	}
+	m.Set = melds[0]
+	return nil
+}
+
+// Body of every API request; each endpoint reads the fields it needs
+type apiRequest struct {
+	Hand    tileList  `json:"hand"`    // concealed tiles
+	Melds   []apiMeld `json:"melds"`   // called sets and closed kans
+	Visible tileList  `json:"visible"` // other visible tiles, removed from the unseen counts
+
+	// Winning conditions for score
+	Win    *Tile    `json:"win"` // winning tile, the last tile of the hand if omitted
+	Tsumo  bool     `json:"tsumo"`
+	Seat   string   `json:"seat"`  // seat wind, East if omitted
+	Round  string   `json:"round"` // round wind, East if omitted
+	Riichi bool     `json:"riichi"`
+	Dora   tileList `json:"dora"` // dora indicators
+	Ura    tileList `json:"ura"`  // ura-dora indicators
+}
+
+func (req apiRequest) melds() []Set {
+	var melds []Set
+	for _, m := range req.Melds {
+		melds = append(melds, m.Set)
+	}
+	return melds
+}
+
+// Builds the concealed hand of the request and the tiles unseen by its owner, requiring a
+// hand awaiting a draw (13 tiles counting melds) or a discard (14) as allowed
+func (req apiRequest) hand(draw, discard bool) (Hand, KB, error) {
+	melds := req.melds()
+	size := len(req.Hand) + 3*len(melds)
+	switch {
+	case size == 13 && !draw, size == 14 && !discard:
+		want := 14
+		if draw {
+			want = 13
+		}
+		return Hand{}, KB{}, fmt.Errorf("hand has %d tiles, want %d with %d melds", len(req.Hand), want-3*len(melds), len(melds))
+	case size != 13 && size != 14:
+		return Hand{}, KB{}, fmt.Errorf("hand has %d tiles, want %d or %d with %d melds", len(req.Hand), 13-3*len(melds), 14-3*len(melds), len(melds))
+	}
+	return handKB(req.Hand, melds, req.Visible)
+}
+
+// Error as written by the API: a stable code for programs and a message for people
+type apiError struct {
+	Status  int    `json:"-"`
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
+
+func (e *apiError) Error() string { return e.Message }
+
+// Writes a JSON response
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
+
+// Writes an error response as {"error": {"code": ..., "message": ...}}
+func writeError(w http.ResponseWriter, e *apiError) {
+	writeJSON(w, e.Status, struct {
+		Error *apiError `json:"error"`
+	}{e})
+}
+
+// Wraps an endpoint: accepts only POST with a JSON request body, and writes the result or
+// the error. Errors that are not an apiError are problems with the hand.
+func apiEndpoint(fn func(req apiRequest) (any, error)) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			w.Header().Set("Allow", http.MethodPost)
+			writeError(w, &apiError{http.StatusMethodNotAllowed, "method_not_allowed", "use POST with a JSON body"})
+			return
+		}
+		body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxRequestBytes))
+		if err != nil {
+			writeError(w, &apiError{http.StatusRequestEntityTooLarge, "too_large", err.Error()})
+			return
+		}
+		var req apiRequest
+		dec := json.NewDecoder(bytes.NewReader(body))
+		dec.DisallowUnknownFields()
+		if err := dec.Decode(&req); err != nil {
+			writeError(w, &apiError{http.StatusBadRequest, "invalid_request", err.Error()})
+			return
+		}
+		res, err := fn(req)
+		if err != nil {
+			var e *apiError
+			if !errors.As(err, &e) {
+				e = &apiError{http.StatusUnprocessableEntity, "invalid_hand", err.Error()}
+			}
+			writeError(w, e)
+			return
+		}
+		writeJSON(w, http.StatusOK, res)
+	}
+}
+
+// Response of /v1/validate
+type validateJSON struct {
+	Valid    bool        `json:"valid"`
+	Error    string      `json:"error,omitempty"`   // why the hand is not valid
+	Shanten  *Deficiency `json:"shanten,omitempty"` // -1 when complete
+	Complete bool        `json:"complete"`
+}
+
+// Response of /v1/shanten
+type shantenJSON struct {
+	Shanten    Deficiency  `json:"shanten"`
+	Regular    Deficiency  `json:"regular"`              // as four sets and a pair
+	Chiitoitsu *Deficiency `json:"chiitoitsu,omitempty"` // as seven pairs, closed hands only
+	Kokushi    *Deficiency `json:"kokushi,omitempty"`    // as thirteen orphans, closed hands only
+}
+
+// Response of /v1/ukeire and /v1/waits
+type tilesJSON struct {
+	Shanten Deficiency  `json:"shanten"`
+	Tiles   []tileCount `json:"tiles"` // with their unseen copies
+	Count   int         `json:"count"`
+}
+
+// Response of /v1/discard
+type recommendationJSON struct {
+	Recommended Tile          `json:"recommended"`
+	Discards    []discardJSON `json:"discards"` // every discard, best first
+}
+
+// Checks that a hand awaiting a draw or a discard can exist
+func apiValidate(req apiRequest) (any, error) {
+	hand, _, err := req.hand(true, true)
+	if err != nil {
+		return validateJSON{Error: err.Error()}, nil
+	}
+	d := CalculateDeficiency(hand, len(req.Melds))
+	return validateJSON{Valid: true, Shanten: &d, Complete: d == -1}, nil
+}
+
+// Scores a winning hand
+func apiScore(req apiRequest) (any, error) {
+	ctx := WinContext{Tsumo: req.Tsumo, Riichi: req.Riichi, DoraIndicators: req.Dora, UraDoraIndicators: req.Ura}
+	var err error
+	for _, w := range []struct {
+		field, value string
+		wind         *int
+	}{{"seat", req.Seat, &ctx.Seat}, {"round", req.Round, &ctx.Round}} {
+		if w.value == "" {
+			continue
+		}
+		if *w.wind, err = parseWind(w.value); err != nil {
+			return nil, &apiError{http.StatusBadRequest, "invalid_request", w.field + ": " + err.Error()}
+		}
+	}
+	if len(req.Hand) > 0 {
+		ctx.WinningTile = req.Hand[len(req.Hand)-1]
+	}
+	if req.Win != nil {
+		ctx.WinningTile = *req.Win
+	}
+	score, err := scoreTiles(req.Hand, req.melds(), ctx)
+	switch {
+	case errors.Is(err, errNotComplete):
+		return nil, &apiError{http.StatusUnprocessableEntity, "not_complete", err.Error()}
+	case errors.Is(err, errNoYaku):
+		return nil, &apiError{http.StatusUnprocessableEntity, "no_yaku", err.Error()}
+	case err != nil:
+		return nil, err
+	}
+	return newScoreJSON(score), nil
+}
+
+// Reports the deficiency of a hand by each winning shape
+func apiShanten(req apiRequest) (any, error) {
+	hand, _, err := req.hand(true, true)
+	if err != nil {
+		return nil, err
+	}
+	open := len(req.Melds)
+	res := shantenJSON{Shanten: CalculateDeficiency(hand, open), Regular: regularDeficiency(hand, open)}
+	if open == 0 {
+		chiitoitsu, kokushi := chiitoitsuDeficiency(hand), kokushiDeficiency(hand)
+		res.Chiitoitsu, res.Kokushi = &chiitoitsu, &kokushi
+	}
+	return res, nil
+}
+
+// Lists the tiles reducing the deficiency of a hand awaiting a draw
+func apiUkeire(req apiRequest) (any, error) {
+	hand, kb, err := req.hand(true, false)
+	if err != nil {
+		return nil, err
+	}
+	ids, count := Ukeire(hand, len(req.Melds), kb)
+	return tilesJSON{Shanten: CalculateDeficiency(hand, len(req.Melds)), Tiles: tileCounts(ids, kb), Count: count}, nil
+}
+
+// Lists the winning tiles of a hand awaiting a draw, none unless it is tenpai
+func apiWaits(req apiRequest) (any, error) {
+	hand, kb, err := req.hand(true, false)
+	if err != nil {
+		return nil, err
+	}
+	res := tilesJSON{Shanten: CalculateDeficiency(hand, len(req.Melds)), Tiles: []tileCount{}}
+	if res.Shanten == 0 {
+		res.Tiles = tileCounts(Waits(hand, len(req.Melds)), kb)
+		for _, c := range res.Tiles {
+			res.Count += c.Count
+		}
+	}
+	return res, nil
+}
+
+// Ranks the discards of a hand awaiting a discard by efficiency
+func apiDiscard(req apiRequest) (any, error) {
+	hand, kb, err := req.hand(false, true)
+	if err != nil {
+		return nil, err
+	}
+	discards := AnalyzeDiscards(hand, len(req.Melds), kb)
+	markRedDiscards(discards, req.Hand)
+	return recommendationJSON{Recommended: discards[0].Tile, Discards: discardJSONs(discards, kb)}, nil
+}
+
+// Creates the handler serving the API; every endpoint takes a POST with a JSON body
+func NewAPIHandler() http.Handler {
+	mux := http.NewServeMux()
+	mux.Handle("/v1/validate", apiEndpoint(apiValidate))
+	mux.Handle("/v1/score", apiEndpoint(apiScore))
+	mux.Handle("/v1/shanten", apiEndpoint(apiShanten))
+	mux.Handle("/v1/ukeire", apiEndpoint(apiUkeire))
+	mux.Handle("/v1/waits", apiEndpoint(apiWaits))
+	mux.Handle("/v1/discard", apiEndpoint(apiDiscard))
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		writeError(w, &apiError{http.StatusNotFound, "not_found", fmt.Sprintf("no endpoint %s", r.URL.Path)})
+	})
+	return mux
+}
+
+// Runs the serve subcommand until the server fails
+func runServe(args []string, stdout, stderr io.Writer) error {
+	fs := flag.NewFlagSet("serve", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	fs.Usage = func() {
+		fmt.Fprintln(stderr, "usage: riichi-mahjong serve [flags]")
+		fmt.Fprintln(stderr, "endpoints: POST /v1/validate, /v1/score, /v1/shanten, /v1/ukeire, /v1/waits and /v1/discard")
+		fs.PrintDefaults()
+	}
+	addr := fs.String("addr", "localhost:8080", "`address` to listen on")
+	positional, err := parseFlags(fs, args)
+	if err != nil {
+		return err
+	}
+	if len(positional) != 0 {
+		fs.Usage()
+		return errUsage
+	}
+	server := &http.Server{Addr: *addr, Handler: NewAPIHandler(), ReadHeaderTimeout: 10 * time.Second}
+	fmt.Fprintf(stdout, "Serving on %s\n", *addr)
+	return server.ListenAndServe()
+}
@@ -0,0 +1,125 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// Posts a JSON body to the API and decodes the response into out
+func postAPI(t *testing.T, server *httptest.Server, path, body string, out any) int {
+	t.Helper()
+	resp, err := http.Post(server.URL+path, "application/json", strings.NewReader(body))
+	if err != nil {
+		t.Fatalf("POST %s error = %v", path, err)
+	}
+	defer resp.Body.Close()
+	if ct := resp.Header.Get("Content-Type"); ct != "application/json" {
+		t.Errorf("POST %s Content-Type = %q, want application/json", path, ct)
+	}
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		t.Fatalf("POST %s decode error = %v", path, err)
+	}
+	return resp.StatusCode
+}
+
+func TestAPI_Score(t *testing.T) {
+	server := httptest.NewServer(NewAPIHandler())
+	defer server.Close()
+
+	var got scoreJSON
+	body := `{"hand": "234m567m345p678s55p", "riichi": true, "tsumo": true, "seat": "S", "dora": ["4m"]}`
+	if status := postAPI(t, server, "/v1/score", body, &got); status != http.StatusOK {
+		t.Fatalf("POST /v1/score status = %d, want 200", status)
+	}
+	// Riichi, tsumo, tanyao, pinfu and a dora: a non-dealer mangan
+	if got.Han != 5 || got.Limit != "Mangan" || got.TsumoDealer != 4000 || got.TsumoOther != 2000 || got.Total != 8000 || len(got.Yaku) != 5 {
+		t.Errorf("POST /v1/score = %+v, want a non-dealer mangan tsumo", got)
+	}
+
+	// Melds as kind:tiles strings or objects, tiles as arrays
+	body = `{"hand": ["1m","2m","3m","4p","5p","6p","7s","8s","9s","1z","1z"], "melds": [{"kind": "pon", "tiles": "555z"}], "win": "3m"}`
+	got = scoreJSON{}
+	if status := postAPI(t, server, "/v1/score", body, &got); status != http.StatusOK || got.Ron != 1500 || got.Han != 1 || !got.Dealer {
+		t.Errorf("POST /v1/score = %d %+v, want a 1500 dealer ron with white", status, got)
+	}
+}
+
+func TestAPI_Analysis(t *testing.T) {
+	server := httptest.NewServer(NewAPIHandler())
+	defer server.Close()
+
+	var valid validateJSON
+	if postAPI(t, server, "/v1/validate", `{"hand": "123m456p789s1122z"}`, &valid); !valid.Valid || valid.Shanten == nil || *valid.Shanten != 0 || valid.Complete {
+		t.Errorf("POST /v1/validate = %+v, want a valid tenpai hand", valid)
+	}
+	valid = validateJSON{}
+	if postAPI(t, server, "/v1/validate", `{"hand": "11111m"}`, &valid); valid.Valid || valid.Error == "" {
+		t.Errorf("POST /v1/validate = %+v, want an invalid hand", valid)
+	}
+
+	var shanten shantenJSON
+	if postAPI(t, server, "/v1/shanten", `{"hand": "19m19p19s1234567z"}`, &shanten); shanten.Shanten != 0 || shanten.Kokushi == nil || *shanten.Kokushi != 0 || shanten.Regular <= 0 {
+		t.Errorf("POST /v1/shanten = %+v, want kokushi tenpai", shanten)
+	}
+
+	var waits tilesJSON
+	if postAPI(t, server, "/v1/waits", `{"hand": "123m456p789s1122z", "visible": "1z"}`, &waits); len(waits.Tiles) != 2 || waits.Count != 3 {
+		t.Errorf("POST /v1/waits = %+v, want 1z and 2z with 3 unseen", waits)
+	}
+
+	var ukeire tilesJSON
+	if postAPI(t, server, "/v1/ukeire", `{"hand": "13m456p789s123s11z"}`, &ukeire); ukeire.Shanten != 0 || ukeire.Count != 4 {
+		t.Errorf("POST /v1/ukeire = %+v, want the 2m kanchan", ukeire)
+	}
+
+	var rec recommendationJSON
+	if postAPI(t, server, "/v1/discard", `{"hand": "13m456p789s123s11z9p"}`, &rec); rec.Recommended != ParseTile(17, false) || len(rec.Discards) != 13 {
+		t.Errorf("POST /v1/discard = %+v, want 9p of 13 discards", rec)
+	}
+}
+
+func TestAPI_Errors(t *testing.T) {
+	server := httptest.NewServer(NewAPIHandler())
+	defer server.Close()
+
+	tests := []struct {
+		name       string
+		path, body string
+		wantStatus int
+		wantCode   string
+	}{
+		{"malformed json", "/v1/score", `{"hand":`, http.StatusBadRequest, "invalid_request"},
+		{"unknown field", "/v1/score", `{"hands": "123m"}`, http.StatusBadRequest, "invalid_request"},
+		{"bad notation", "/v1/shanten", `{"hand": "12x"}`, http.StatusBadRequest, "invalid_request"},
+		{"hidden winning tile", "/v1/score", `{"hand": "234m567m345p678s55p", "win": "?"}`, http.StatusBadRequest, "invalid_request"},
+		{"hidden tile", "/v1/waits", `{"hand": ["?","1m","2m","3m","4p","5p","6p","7s","8s","9s","1z","1z","2z"]}`, http.StatusBadRequest, "invalid_request"},
+		{"hidden dora", "/v1/score", `{"hand": "234m567m345p678s55p", "dora": ["?"]}`, http.StatusBadRequest, "invalid_request"},
+		{"bad meld", "/v1/score", `{"hand": "123m", "melds": ["chi:135m"]}`, http.StatusBadRequest, "invalid_request"},
+		{"bad wind", "/v1/score", `{"hand": "234m567m345p678s55p", "seat": "X"}`, http.StatusBadRequest, "invalid_request"},
+		{"hand size", "/v1/discard", `{"hand": "123m456p789s1122z"}`, http.StatusUnprocessableEntity, "invalid_hand"},
+		{"five copies", "/v1/ukeire", `{"hand": "123m456p789s1111z", "visible": "1z"}`, http.StatusUnprocessableEntity, "invalid_hand"},
+		{"not complete", "/v1/score", `{"hand": "123m456p789s11234z"}`, http.StatusUnprocessableEntity, "not_complete"},
+		{"no yaku", "/v1/score", `{"hand": "123m456p789s11z", "melds": ["pon:999m"]}`, http.StatusUnprocessableEntity, "no_yaku"},
+		{"unknown endpoint", "/v1/tenpai", `{}`, http.StatusNotFound, "not_found"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got struct{ Error apiError }
+			if status := postAPI(t, server, tt.path, tt.body, &got); status != tt.wantStatus || got.Error.Code != tt.wantCode || got.Error.Message == "" {
+				t.Errorf("POST %s = %d %+v, want %d %s", tt.path, status, got.Error, tt.wantStatus, tt.wantCode)
+			}
+		})
+	}
+
+	resp, err := http.Get(server.URL + "/v1/score")
+	if err != nil {
+		t.Fatalf("GET /v1/score error = %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusMethodNotAllowed || resp.Header.Get("Allow") != http.MethodPost {
+		t.Errorf("GET /v1/score = %d, Allow %q, want 405 allowing POST", resp.StatusCode, resp.Header.Get("Allow"))
+	}
+}
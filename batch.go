@@ -0,0 +1,202 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"math/rand/v2"
+	"runtime"
+	"sync"
+)
+
+// Batch simulation of bot-versus-bot matches with per-agent statistics
+
+type BatchConfig struct {
+	Rules   Rules
+	Games   int    // number of matches to play
+	Seed    uint64 // seeds every match of the batch
+	Workers int    // concurrent workers, defaults to the number of CPUs
+}
+
+// An agent taking part in a batch; New creates a fresh agent for each match
+type BatchEntry struct {
+	Name string
+	New  func(seed uint64) Agent
+}
+
+// A mean with the half-width of its 95% confidence interval
+type Estimate struct {
+	Mean   float64
+	Margin float64
+}
+
+func (e Estimate) String() string {
+	return fmt.Sprintf("%.3f ± %.3f", e.Mean, e.Margin)
+}
+
+// Running count, sum and sum of squares of a sample
+type sample struct {
+	n          int
+	sum, sumSq float64
+}
+
+func (s *sample) add(x float64) {
+	s.n++
+	s.sum += x
+	s.sumSq += x * x
+}
+
+// Mean of the sample with a normal approximation of its 95% confidence interval
+func (s sample) estimate() Estimate {
+	if s.n == 0 {
+		return Estimate{}
+	}
+	mean := s.sum / float64(s.n)
+	if s.n == 1 {
+		return Estimate{Mean: mean}
+	}
+	variance := max(0, (s.sumSq-s.sum*mean)/float64(s.n-1))
+	return Estimate{Mean: mean, Margin: 1.96 * math.Sqrt(variance/float64(s.n))}
+}
+
+// Adds a yes-or-no outcome to a sample of rates
+func (s *sample) addBool(b bool) {
+	if b {
+		s.add(1)
+	} else {
+		s.add(0)
+	}
+}
+
+// Results of one batch entry over every match it played
+type AgentStats struct {
+	Name   string
+	Games  int
+	Rounds int
+	Places []int // number of finishes in each place, first place first
+
+	place, points                sample // per match
+	wins, dealIns, riichi, calls sample // per round
+	winValue                     sample // per won hand
+}
+
+// Average final place
+func (s AgentStats) AvgPlace() Estimate { return s.place.estimate() }
+
+// Average placement points, with uma and oka
+func (s AgentStats) AvgPoints() Estimate { return s.points.estimate() }
+
+// Share of rounds won
+func (s AgentStats) WinRate() Estimate { return s.wins.estimate() }
+
+// Share of rounds dealing into another player's ron
+func (s AgentStats) DealInRate() Estimate { return s.dealIns.estimate() }
+
+// Share of rounds with a riichi declaration
+func (s AgentStats) RiichiRate() Estimate { return s.riichi.estimate() }
+
+// Share of rounds with a chi, pon or open kan
+func (s AgentStats) CallRate() Estimate { return s.calls.estimate() }
+
+// Average value of a won hand
+func (s AgentStats) AvgWinValue() Estimate { return s.winValue.estimate() }
+
+// What one seat did in one round
+type roundTally struct {
+	win, dealIn, riichi, call bool
+	winValue                  int
+}
+
+// What one seat did over one match
+type seatTally struct {
+	place  int
+	points float64
+	rounds []roundTally
+}
+
+// Tallies every seat of a played match
+func tallyMatch(result MatchResult) []seatTally {
+	seats := make([]seatTally, len(result.Scores))
+	for _, p := range result.Placement {
+		seats[p.Seat].place = p.Place
+		seats[p.Seat].points = p.Points
+	}
+	for _, round := range result.Rounds {
+		tallies := make([]roundTally, len(seats))
+		for _, ev := range round.Events {
+			switch ev.Type {
+			case Event_Win:
+				tallies[ev.Seat].win = true
+				tallies[ev.Seat].winValue = ev.Score.Total()
+				if ev.From != ev.Seat {
+					tallies[ev.From].dealIn = true
+				}
+			case Event_Riichi:
+				tallies[ev.Seat].riichi = true
+			case Event_Call:
+				if ev.Call == Action_Chi || ev.Call == Action_Pon || ev.Call == Action_Daiminkan {
+					tallies[ev.Seat].call = true
+				}
+			}
+		}
+		for seat, t := range tallies {
+			seats[seat].rounds = append(seats[seat].rounds, t)
+		}
+	}
+	return seats
+}
+
+// Plays a batch of matches between the entries, one per seat, concurrently across workers.
+// Seats rotate from match to match so every entry plays every seat equally often. Match i
+// always uses the same seed regardless of worker count, so results are reproducible.
+func RunBatch(cfg BatchConfig, entries []BatchEntry) ([]AgentStats, error) {
+	n := len(entries)
+	if n != cfg.Rules.Players {
+		return nil, fmt.Errorf("batch: %d agents for %d players", n, cfg.Rules.Players)
+	}
+	workers := cfg.Workers
+	if workers <= 0 {
+		workers = runtime.NumCPU()
+	}
+	games := make([][]seatTally, cfg.Games)
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func(w int) {
+			defer wg.Done()
+			for i := w; i < cfg.Games; i += workers {
+				seed := rand.New(rand.NewPCG(cfg.Seed, uint64(i))).Uint64()
+				agents := make([]Agent, n)
+				for seat := range agents {
+					agents[seat] = entries[(seat+i)%n].New(seed + uint64(seat))
+				}
+				games[i] = tallyMatch(NewMatch(cfg.Rules, seed, agents).Play())
+			}
+		}(w)
+	}
+	wg.Wait()
+
+	stats := make([]AgentStats, n)
+	for e := range stats {
+		stats[e] = AgentStats{Name: entries[e].Name, Places: make([]int, n)}
+	}
+	for i, seats := range games {
+		for seat, t := range seats {
+			s := &stats[(seat+i)%n]
+			s.Games++
+			s.Places[t.place-1]++
+			s.place.add(float64(t.place))
+			s.points.add(t.points)
+			for _, r := range t.rounds {
+				s.Rounds++
+				s.wins.addBool(r.win)
+				s.dealIns.addBool(r.dealIn)
+				s.riichi.addBool(r.riichi)
+				s.calls.addBool(r.call)
+				if r.win {
+					s.winValue.add(float64(r.winValue))
+				}
+			}
+		}
+	}
+	return stats, nil
+}
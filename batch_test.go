@@ -0,0 +1,74 @@
+package main
+
+import (
+	"math"
+	"reflect"
+	"testing"
+)
+
+func TestSampleEstimate(t *testing.T) {
+	var s sample
+	for _, x := range []float64{1, 2, 3, 4} {
+		s.add(x)
+	}
+	got := s.estimate()
+	// Sample variance 5/3 over 4 observations
+	want := Estimate{Mean: 2.5, Margin: 1.96 * math.Sqrt(5.0/3/4)}
+	if math.Abs(got.Mean-want.Mean) > 1e-9 || math.Abs(got.Margin-want.Margin) > 1e-9 {
+		t.Errorf("estimate() = %v, want %v", got, want)
+	}
+	if got := (sample{}).estimate(); got != (Estimate{}) {
+		t.Errorf("estimate() of no sample = %v, want zero", got)
+	}
+}
+
+func batchEntries() []BatchEntry {
+	greedy := func(uint64) Agent { return NewGreedyAgent() }
+	defensive := func(uint64) Agent { return NewDefensiveAgent() }
+	return []BatchEntry{{"greedy", greedy}, {"defensive", defensive}, {"greedy", greedy}, {"defensive", defensive}}
+}
+
+func TestRunBatch(t *testing.T) {
+	rules := DefaultRules()
+	rules.Length = Length_Tonpuusen
+	cfg := BatchConfig{Rules: rules, Games: 4, Seed: 3, Workers: 1}
+	stats, err := RunBatch(cfg, batchEntries())
+	if err != nil {
+		t.Fatalf("RunBatch() error = %v", err)
+	}
+	places := make([]int, 4)
+	for _, s := range stats {
+		if s.Games != 4 || s.Rounds == 0 {
+			t.Errorf("RunBatch() %s played %d games and %d rounds", s.Name, s.Games, s.Rounds)
+		}
+		for place, count := range s.Places {
+			places[place] += count
+		}
+		if p := s.AvgPlace().Mean; p < 1 || p > 4 {
+			t.Errorf("RunBatch() %s average place = %v", s.Name, p)
+		}
+		if s.WinRate().Mean > 0 && s.AvgWinValue().Mean < 1000 {
+			t.Errorf("RunBatch() %s average win value = %v", s.Name, s.AvgWinValue().Mean)
+		}
+	}
+	// Seats rotate, so every place is taken once per match
+	if !reflect.DeepEqual(places, []int{4, 4, 4, 4}) {
+		t.Errorf("RunBatch() places = %v, want 4 of each", places)
+	}
+
+	// Results do not depend on the number of workers
+	cfg.Workers = 3
+	again, err := RunBatch(cfg, batchEntries())
+	if err != nil {
+		t.Fatalf("RunBatch() error = %v", err)
+	}
+	if !reflect.DeepEqual(stats, again) {
+		t.Errorf("RunBatch() with 3 workers = %+v, want %+v", again, stats)
+	}
+}
+
+func TestRunBatch_Players(t *testing.T) {
+	if _, err := RunBatch(BatchConfig{Rules: SanmaRules(), Games: 1}, batchEntries()); err == nil {
+		t.Errorf("RunBatch() with 4 agents for sanma succeeded")
+	}
+}
@@ -0,0 +1,183 @@
+package main
+
+import "math/rand/v2"
+
+// Bundled CPU agents
+
+// Picks uniformly among the offered actions
+type RandomAgent struct {
+	rng *rand.Rand
+}
+
+func NewRandomAgent(seed uint64) *RandomAgent {
+	return &RandomAgent{rng: rand.New(rand.NewPCG(seed, 0))}
+}
+
+func (a *RandomAgent) Name() string                       { return "Random" }
+func (a *RandomAgent) OnEvent(view *PlayerView, ev Event) {}
+func (a *RandomAgent) ChooseAction(view *PlayerView, options []Action) Action {
+	return options[a.rng.IntN(len(options))]
+}
+func (a *RandomAgent) ChooseCall(view *PlayerView, options []Action) Action {
+	return options[a.rng.IntN(len(options))]
+}
+
+// Minimises deficiency, then maximises ukeire; always wins when it can, riichis when
+// tenpai, sets aside every kita and only calls when it brings the hand closer with a yaku in reach
+type GreedyAgent struct{}
+
+func NewGreedyAgent() *GreedyAgent { return &GreedyAgent{} }
+
+func (a *GreedyAgent) Name() string                       { return "Greedy" }
+func (a *GreedyAgent) OnEvent(view *PlayerView, ev Event) {}
+
+func (a *GreedyAgent) ChooseAction(view *PlayerView, options []Action) Action {
+	if win, ok := findAction(options, Action_Tsumo, -1); ok {
+		return win
+	}
+	if kita, ok := findAction(options, Action_Kita, -1); ok {
+		return kita
+	}
+	id := efficientDiscardID(view.Hand(), len(view.Melds[view.Seat]), view.KB(), options)
+	if id < 0 {
+		return options[0] // no discard offered
+	}
+	hand := view.Hand()
+	hand.counts[id]--
+	if CalculateDeficiency(hand, len(view.Melds[view.Seat])) == 0 {
+		if riichi, ok := findAction(options, Action_Riichi, id); ok {
+			return riichi
+		}
+	}
+	return discardOption(options, id)
+}
+
+func (a *GreedyAgent) ChooseCall(view *PlayerView, options []Action) Action {
+	if win, ok := findAction(options, Action_Ron, -1); ok {
+		return win
+	}
+	pass, _ := findAction(options, Action_Pass, -1)
+	hand := view.Hand()
+	melds := view.Melds[view.Seat]
+	current := CalculateDeficiency(hand, len(melds))
+	for _, option := range options {
+		if option.Type != Action_Pon && option.Type != Action_Chi {
+			continue
+		}
+		if !callKeepsYaku(view, option) {
+			continue
+		}
+		after := hand
+		for _, t := range option.Tiles {
+			after.counts[t.ID]--
+		}
+		if CalculateDeficiency(after, len(melds)+1) < current {
+			return option
+		}
+	}
+	return pass
+}
+
+// Reports whether an open hand after the call still has an obvious yaku:
+// a value triplet, or all simples (open tanyao)
+func callKeepsYaku(view *PlayerView, call Action) bool {
+	seatWind := 27 + view.SeatWind(view.Seat)
+	roundWind := 27 + view.RoundWind
+	isValue := func(id int) bool {
+		return id >= 31 || id == seatWind || id == roundWind
+	}
+	if call.Type == Action_Pon && isValue(call.Tile.ID) {
+		return true
+	}
+	for _, m := range view.Melds[view.Seat] {
+		if m.Type != Shuntsu && isValue(m.Tiles[0].ID) {
+			return true
+		}
+	}
+	simples := !call.Tile.IsTerminalOrHonor()
+	for _, t := range view.Tiles {
+		simples = simples && !t.IsTerminalOrHonor()
+	}
+	for _, m := range view.Melds[view.Seat] {
+		for _, t := range m.Tiles {
+			simples = simples && !t.IsTerminalOrHonor()
+		}
+	}
+	return simples
+}
+
+// Wins when possible and otherwise follows the push/fold decision model,
+// folding to the safest tile when the danger outweighs its own hand; never calls
+type DefensiveAgent struct{}
+
+func NewDefensiveAgent() *DefensiveAgent { return &DefensiveAgent{} }
+
+func (a *DefensiveAgent) Name() string                       { return "Defensive" }
+func (a *DefensiveAgent) OnEvent(view *PlayerView, ev Event) {}
+
+func (a *DefensiveAgent) ChooseAction(view *PlayerView, options []Action) Action {
+	if win, ok := findAction(options, Action_Tsumo, -1); ok {
+		return win
+	}
+	if kita, ok := findAction(options, Action_Kita, -1); ok {
+		return kita
+	}
+	d, err := Decide(view.DecisionState())
+	if err != nil {
+		return options[0]
+	}
+	if d.Type == Decision_Riichi {
+		if riichi, ok := findAction(options, Action_Riichi, d.Discard.ID); ok {
+			return riichi
+		}
+	}
+	return discardOption(options, d.Discard.ID)
+}
+
+func (a *DefensiveAgent) ChooseCall(view *PlayerView, options []Action) Action {
+	if win, ok := findAction(options, Action_Ron, -1); ok {
+		return win
+	}
+	pass, _ := findAction(options, Action_Pass, -1)
+	return pass
+}
+
+// Selects the offered discard that minimises deficiency, then maximises ukeire,
+// then prefers isolated and outer tiles; -1 when no discard is offered
+func efficientDiscardID(hand Hand, openSets int, kb KB, options []Action) int {
+	best, bestD, bestU, bestP := -1, Deficiency(0), 0, 0
+	for _, option := range options {
+		if option.Type != Action_Discard {
+			continue
+		}
+		id := option.Tile.ID
+		hand.counts[id]--
+		d := CalculateDeficiency(hand, openSets)
+		_, u := Ukeire(hand, openSets, kb)
+		hand.counts[id]++
+		p := discardPriority(hand, id)
+		switch {
+		case best == -1, d < bestD, d == bestD && u > bestU, d == bestD && u == bestU && p < bestP:
+			best, bestD, bestU, bestP = id, d, u, p
+		}
+	}
+	return best
+}
+
+// Returns the offered discard of a tile ID, keeping red fives when a plain copy can go;
+// falls back to the first offered action when the tile cannot be discarded
+func discardOption(options []Action, id int) Action {
+	var found *Action
+	for i, a := range options {
+		if a.Type != Action_Discard || a.Tile.ID != id {
+			continue
+		}
+		if found == nil || (found.Tile.Red && !a.Tile.Red) {
+			found = &options[i]
+		}
+	}
+	if found != nil {
+		return *found
+	}
+	return options[0]
+}
@@ -0,0 +1,134 @@
+package main
+
+import (
+	"testing"
+)
+
+// Offers a discard of every concealed tile in the view
+func discardOptions(v *PlayerView) []Action {
+	var options []Action
+	for _, t := range v.Tiles {
+		options = append(options, Action{Type: Action_Discard, Tile: t})
+	}
+	return options
+}
+
+func TestRandomAgent_Deterministic(t *testing.T) {
+	v := testView(0, 1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12, 13)
+	options := discardOptions(v)
+	a, b := NewRandomAgent(3), NewRandomAgent(3)
+	for i := 0; i < 20; i++ {
+		x, y := a.ChooseAction(v, options), b.ChooseAction(v, options)
+		if x.Tile.ID != y.Tile.ID {
+			t.Fatalf("RandomAgent with the same seed diverged")
+		}
+	}
+}
+
+func TestGreedyAgent_ChooseAction(t *testing.T) {
+	t.Run("Discards the isolated tile", func(t *testing.T) {
+		v := testView(1, 2, 3, 4, 5, 11, 12, 13, 23, 24, 25, 0, 0, 30)
+		got := NewGreedyAgent().ChooseAction(v, discardOptions(v))
+		if got.Type != Action_Discard || got.Tile.ID != 30 {
+			t.Errorf("GreedyAgent.ChooseAction() = %v %v, want discard 30", got.Type, got.Tile.ID)
+		}
+	})
+
+	t.Run("Declares riichi when tenpai", func(t *testing.T) {
+		v := testView(1, 2, 3, 4, 5, 11, 12, 13, 23, 24, 25, 0, 0, 30)
+		options := append(discardOptions(v), Action{Type: Action_Riichi, Tile: ParseTile(30, false)})
+		got := NewGreedyAgent().ChooseAction(v, options)
+		if got.Type != Action_Riichi || got.Tile.ID != 30 {
+			t.Errorf("GreedyAgent.ChooseAction() = %v %v, want riichi 30", got.Type, got.Tile.ID)
+		}
+	})
+
+	t.Run("Takes tsumo", func(t *testing.T) {
+		v := testView(1, 2, 3, 4, 5, 6, 11, 12, 13, 23, 24, 25, 0, 0)
+		options := append(discardOptions(v), Action{Type: Action_Tsumo, Tile: ParseTile(6, false)})
+		if got := NewGreedyAgent().ChooseAction(v, options); got.Type != Action_Tsumo {
+			t.Errorf("GreedyAgent.ChooseAction() = %v, want Tsumo", got.Type)
+		}
+	})
+
+	t.Run("Keeps red fives", func(t *testing.T) {
+		v := testView(4, 4, 4, 4, 11, 12, 13, 23, 24, 25, 0, 0, 0, 30)
+		v.Tiles[0].Red = true
+		options := discardOptions(v)
+		got := discardOption(options, 4)
+		if got.Tile.Red {
+			t.Errorf("discardOption() chose the red five")
+		}
+	})
+
+	t.Run("Falls back without a discard to choose", func(t *testing.T) {
+		v := testView(1, 1, 1, 1, 5, 11, 12, 13, 23, 24, 25, 0, 0, 30)
+		options := []Action{{Type: Action_Ankan, Tile: ParseTile(1, false)}}
+		if got := NewGreedyAgent().ChooseAction(v, options); got.Type != Action_Ankan {
+			t.Errorf("GreedyAgent.ChooseAction() = %v, want Ankan", got.Type)
+		}
+	})
+}
+
+func TestGreedyAgent_ChooseCall(t *testing.T) {
+	pass := Action{Type: Action_Pass}
+
+	tests := []struct {
+		name     string
+		view     *PlayerView
+		option   Action
+		wantType ActionType
+	}{
+		{
+			name:     "Pons a dragon",
+			view:     testView(31, 31, 1, 2, 3, 11, 12, 13, 23, 24, 17, 26, 30),
+			option:   Action{Type: Action_Pon, Tile: ParseTile(31, false), Tiles: tilesOf(31, 31)},
+			wantType: Action_Pon,
+		},
+		{
+			name:     "Skips a pon without a yaku",
+			view:     testView(0, 0, 1, 2, 3, 11, 12, 13, 23, 24, 17, 26, 30),
+			option:   Action{Type: Action_Pon, Tile: ParseTile(0, false), Tiles: tilesOf(0, 0)},
+			wantType: Action_Pass,
+		},
+		{
+			name:     "Chis towards open tanyao",
+			view:     testView(1, 2, 4, 4, 11, 12, 13, 22, 23, 15, 16, 6, 7),
+			option:   Action{Type: Action_Chi, Tile: ParseTile(3, false), Tiles: tilesOf(1, 2)},
+			wantType: Action_Chi,
+		},
+		{
+			name:     "Always rons",
+			view:     testView(1, 2, 3, 4, 5, 11, 12, 13, 23, 24, 25, 0, 0),
+			option:   Action{Type: Action_Ron, Tile: ParseTile(6, false)},
+			wantType: Action_Ron,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := NewGreedyAgent().ChooseCall(tt.view, []Action{tt.option, pass})
+			if got.Type != tt.wantType {
+				t.Errorf("GreedyAgent.ChooseCall() = %v, want %v", got.Type, tt.wantType)
+			}
+		})
+	}
+}
+
+func TestDefensiveAgent(t *testing.T) {
+	v := testView(0, 4, 8, 10, 13, 16, 19, 23, 27, 28, 29, 30, 31, 33)
+	v.Seat = 1
+	v.Tiles = tilesOf(0, 4, 8, 10, 13, 16, 19, 23, 27, 28, 29, 30, 31, 33)
+	v.Riichi[0] = true
+	v.Discards[0] = []Discard{{Tile: ParseTile(32, false)}, {Tile: ParseTile(31, false), Riichi: true}}
+
+	a := NewDefensiveAgent()
+	got := a.ChooseAction(v, discardOptions(v))
+	if got.Tile.ID != 31 {
+		t.Errorf("DefensiveAgent.ChooseAction() = %v, want genbutsu 31", got.Tile.ID)
+	}
+	call := a.ChooseCall(v, []Action{{Type: Action_Pon, Tile: ParseTile(33, false), Tiles: tilesOf(33, 33)}, {Type: Action_Pass}})
+	if call.Type != Action_Pass {
+		t.Errorf("DefensiveAgent.ChooseCall() = %v, want Pass", call.Type)
+	}
+}
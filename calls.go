@@ -0,0 +1,261 @@
+package main
+
+// Offering and resolving calls on a discard: ron > pon/daiminkan > chi
+
+// Returns every distinct way to pick tiles with the given IDs from the concealed tiles,
+// distinguishing red fives
+func pickTiles(tiles []Tile, ids ...int) [][]Tile {
+	if len(ids) == 0 {
+		return [][]Tile{nil}
+	}
+	var result [][]Tile
+	seen := map[Tile]bool{}
+	for i, t := range tiles {
+		if t.ID != ids[0] || seen[t] {
+			continue
+		}
+		seen[t] = true
+		rest := append(append([]Tile{}, tiles[:i]...), tiles[i+1:]...)
+		for _, others := range pickTiles(rest, ids[1:]...) {
+			picked := append([]Tile{t}, others...)
+			// Skip orderings already produced for identical IDs
+			if len(others) > 0 && others[0].ID == t.ID && t.Red && !others[0].Red {
+				continue
+			}
+			result = append(result, picked)
+		}
+	}
+	return result
+}
+
+// Tiles that may not be discarded straight after a call (kuikae): the called tile,
+// and for a chi on the end of a sequence the tile on the other side
+func kuikae(call Action) map[int]bool {
+	forbidden := map[int]bool{call.Tile.ID: true}
+	if call.Type != Action_Chi {
+		return forbidden
+	}
+	low, high := call.Tiles[0].ID, call.Tiles[1].ID
+	id := call.Tile.ID
+	switch {
+	case id < low && high%9 < 8:
+		forbidden[high+1] = true
+	case id > high && low%9 > 0:
+		forbidden[low-1] = true
+	}
+	return forbidden
+}
+
+// Reports whether the seat would still have a legal discard after making a call
+func (s *seatState) canDiscardAfter(call Action) bool {
+	forbidden := kuikae(call)
+	used := map[int]int{}
+	for _, t := range call.Tiles {
+		used[t.ID]++
+	}
+	for _, t := range s.tiles {
+		if used[t.ID] > 0 {
+			used[t.ID]--
+			continue
+		}
+		if !forbidden[t.ID] {
+			return true
+		}
+	}
+	return false
+}
+
+// Marks a seat that let a winning tile pass as furiten until its next discard, or for the
+// rest of the round once it has declared riichi
+func (s *seatState) missWin() {
+	s.tempFuriten = true
+	if s.riichi {
+		s.riichiFuriten = true
+	}
+}
+
+// Reports whether a seat is furiten: a wait is among its own discards, or it let a winning
+// tile pass since its last discard or after declaring riichi
+func (r *Round) furiten(seat int) bool {
+	s := r.seats[seat]
+	if s.tempFuriten || s.riichiFuriten {
+		return true
+	}
+	for _, id := range Waits(s.hand(), len(s.melds)) {
+		for _, d := range s.discards {
+			if d.Tile.ID == id {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// Lists the calls a seat may make on a discarded tile, ending with a pass
+func (r *Round) callOptions(seat, from int, tile Tile) []Action {
+	s := r.seats[seat]
+	var options []Action
+	if !r.furiten(seat) {
+		if _, ok := r.scoreWin(seat, tile, false); ok {
+			options = append(options, Action{Type: Action_Ron, Tile: tile})
+		}
+	}
+	// No calls other than ron on the last discard or in riichi
+	if r.wall.Remaining() > 0 && !s.riichi {
+		for _, tiles := range pickTiles(s.tiles, tile.ID, tile.ID) {
+			pon := Action{Type: Action_Pon, Tile: tile, Tiles: tiles}
+			if s.canDiscardAfter(pon) {
+				options = append(options, pon)
+			}
+		}
+		if r.wall.Kans() < 4 {
+			for _, tiles := range pickTiles(s.tiles, tile.ID, tile.ID, tile.ID) {
+				options = append(options, Action{Type: Action_Daiminkan, Tile: tile, Tiles: tiles})
+			}
+		}
+		// No chi in sanma
+		if seat == (from+1)%r.players() && tile.Suit != Honor && !r.sanma() {
+			for _, pair := range [][2]int{{-2, -1}, {-1, 1}, {1, 2}} {
+				lo, hi := tile.Rank+pair[0], tile.Rank+pair[1]
+				if lo < 0 || hi > 8 {
+					continue
+				}
+				for _, tiles := range pickTiles(s.tiles, tile.ID+pair[0], tile.ID+pair[1]) {
+					chi := Action{Type: Action_Chi, Tile: tile, Tiles: tiles}
+					if s.canDiscardAfter(chi) {
+						options = append(options, chi)
+					}
+				}
+			}
+		}
+	}
+	if len(options) == 0 {
+		return nil
+	}
+	return append(options, Action{Type: Action_Pass})
+}
+
+// Builds the set formed by a call
+func callSet(call Action, from int) Set {
+	tiles := append(append([]Tile{}, call.Tiles...), call.Tile)
+	sortTiles(tiles)
+	set := Set{Tiles: tiles, Open: true, Target: from}
+	switch call.Type {
+	case Action_Chi:
+		set.Type = Shuntsu
+	case Action_Pon:
+		set.Type = Koutsu
+	default:
+		set.Type = Kantsu
+	}
+	return set
+}
+
+// Priority of a call when several seats respond to the same discard
+func callPriority(t ActionType) int {
+	switch t {
+	case Action_Ron:
+		return 3
+	case Action_Pon, Action_Daiminkan:
+		return 2
+	case Action_Chi:
+		return 1
+	}
+	return 0
+}
+
+// Offers the discarded tile to every other seat, in turn order from the discarder, and
+// resolves their responses by priority. Returns the winning rons (several with multiple ron,
+// otherwise only the first seat in turn order, unless three seats ron under Sanchahou) or
+// the seat and call that claims the tile; caller is -1 when everyone passes.
+func (r *Round) offerCalls(from int, tile Tile) (rons []int, caller int, call Action) {
+	n := r.players()
+	caller = -1
+	for i := 1; i < n; i++ {
+		seat := (from + i) % n
+		options := r.callOptions(seat, from, tile)
+		if options == nil {
+			continue
+		}
+		choice := matchOption(options, r.agents[seat].ChooseCall(r.view(seat, nil), options))
+		if _, offered := findAction(options, Action_Ron, -1); offered && choice.Type != Action_Ron {
+			r.seats[seat].missWin()
+		}
+		switch {
+		case choice.Type == Action_Ron:
+			rons = append(rons, seat)
+		case callPriority(choice.Type) > callPriority(call.Type):
+			caller, call = seat, choice
+		}
+	}
+	// Any seat waiting on the tile that did not ron is furiten until its next discard, or for
+	// the rest of the round in riichi
+	for i := 1; i < n; i++ {
+		seat := (from + i) % n
+		for _, id := range Waits(r.seats[seat].hand(), len(r.seats[seat].melds)) {
+			if id == tile.ID && !containsInt(rons, seat) {
+				r.seats[seat].missWin()
+			}
+		}
+	}
+	return r.trimRons(rons), caller, call
+}
+
+// Keeps only the first ron in turn order unless multiple ron is allowed (atamahane). A triple
+// ron aborts under Sanchahou with or without multiple ron, so all three are kept.
+func (r *Round) trimRons(rons []int) []int {
+	if len(rons) > 1 && !r.Rules.MultipleRon && !(len(rons) == 3 && r.Rules.Sanchahou) {
+		return rons[:1]
+	}
+	return rons
+}
+
+// Reports whether a slice contains a value
+func containsInt(values []int, v int) bool {
+	for _, x := range values {
+		if x == v {
+			return true
+		}
+	}
+	return false
+}
+
+// Applies a call: the tiles leave the caller's hand and form an open set,
+// and the discard is marked as claimed
+func (r *Round) applyCall(seat, from int, call Action) {
+	s := r.seats[seat]
+	for _, t := range call.Tiles {
+		s.remove(t)
+	}
+	set := callSet(call, from)
+	s.melds = append(s.melds, set)
+	discards := r.seats[from].discards
+	discards[len(discards)-1].Called = true
+	r.checkPao(seat, set)
+	r.interrupt()
+	r.emit(Event{Type: Event_Call, Seat: seat, Tile: call.Tile, Set: set, Call: call.Type})
+}
+
+// Settles one or more rons on a discard. The discarder pays each winner; honba and
+// riichi sticks go to the first winner in turn order from the discarder.
+func (r *Round) settleRon(from int, tile Tile, winners []int) RoundResult {
+	result := RoundResult{Deltas: make([]int, r.players())}
+	for i, seat := range winners {
+		score, _ := r.scoreWin(seat, tile, false)
+		pay := score.Ron
+		if i == 0 {
+			pay += 300 * r.Setup.Honba
+			result.Deltas[seat] += riichiDeposit * r.sticks
+		}
+		// Under pao the liable seat pays half of the liable yakuman
+		liable, share := r.paoRonShare(seat, from, score)
+		if share > 0 {
+			result.Deltas[liable] -= share
+			pay -= share
+		}
+		result.Deltas[from] -= pay
+		result.Deltas[seat] += pay + share
+		result.Wins = append(result.Wins, Win{Seat: seat, From: from, Tile: tile, Score: score, Liable: liable})
+	}
+	return result
+}
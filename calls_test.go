@@ -0,0 +1,219 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+// Picks the first offered action of a preferred type, otherwise the first option
+type scriptAgent struct {
+	prefer ActionType
+}
+
+func (a *scriptAgent) Name() string                       { return "Script" }
+func (a *scriptAgent) OnEvent(view *PlayerView, ev Event) {}
+func (a *scriptAgent) ChooseAction(view *PlayerView, options []Action) Action {
+	if action, ok := findAction(options, a.prefer, -1); ok {
+		return action
+	}
+	return options[0]
+}
+func (a *scriptAgent) ChooseCall(view *PlayerView, options []Action) Action {
+	if action, ok := findAction(options, a.prefer, -1); ok {
+		return action
+	}
+	return options[len(options)-1]
+}
+
+// Creates a round where each seat holds the given tiles
+func scriptedRound(rules Rules, prefer []ActionType, hands ...[]int) *Round {
+	var agents []Agent
+	for _, p := range prefer {
+		agents = append(agents, &scriptAgent{prefer: p})
+	}
+	r := NewRound(rules, testSetup(1), agents)
+	for seat, ids := range hands {
+		r.seats[seat].tiles = tilesOf(ids...)
+	}
+	return r
+}
+
+func TestPickTiles(t *testing.T) {
+	tiles := []Tile{ParseTile(4, false), ParseTile(4, true), ParseTile(4, false), ParseTile(5, false)}
+	tests := []struct {
+		name string
+		ids  []int
+		want int
+	}{
+		{"Pair with and without red", []int{4, 4}, 2},
+		{"Three fives", []int{4, 4, 4}, 1},
+		{"Sequence pieces", []int{4, 5}, 2},
+		{"Missing tile", []int{4, 6}, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := pickTiles(tiles, tt.ids...); len(got) != tt.want {
+				t.Errorf("pickTiles() = %v, want %v picks", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestKuikae(t *testing.T) {
+	tests := []struct {
+		name string
+		call Action
+		want []int
+	}{
+		{"Chi on the low end", Action{Type: Action_Chi, Tile: ParseTile(2, false), Tiles: tilesOf(3, 4)}, []int{2, 5}},
+		{"Chi on the high end", Action{Type: Action_Chi, Tile: ParseTile(5, false), Tiles: tilesOf(3, 4)}, []int{2, 5}},
+		{"Chi in the middle", Action{Type: Action_Chi, Tile: ParseTile(3, false), Tiles: tilesOf(2, 4)}, []int{3}},
+		{"Chi at the suit edge", Action{Type: Action_Chi, Tile: ParseTile(6, false), Tiles: tilesOf(7, 8)}, []int{6}},
+		{"Pon", Action{Type: Action_Pon, Tile: ParseTile(31, false), Tiles: tilesOf(31, 31)}, []int{31}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := kuikae(tt.call)
+			if len(got) != len(tt.want) {
+				t.Fatalf("kuikae() = %v, want %v", got, tt.want)
+			}
+			for _, id := range tt.want {
+				if !got[id] {
+					t.Errorf("kuikae() = %v, want %v", got, tt.want)
+				}
+			}
+		})
+	}
+}
+
+func TestCallSet(t *testing.T) {
+	set := callSet(Action{Type: Action_Chi, Tile: ParseTile(2, false), Tiles: tilesOf(3, 4)}, 3)
+	want := Set{Type: Shuntsu, Tiles: tilesOf(2, 3, 4), Open: true, Target: 3}
+	if !reflect.DeepEqual(set, want) {
+		t.Errorf("callSet() = %+v, want %+v", set, want)
+	}
+	if kan := callSet(Action{Type: Action_Daiminkan, Tile: ParseTile(9, false), Tiles: tilesOf(9, 9, 9)}, 1); kan.Type != Kantsu || len(kan.Tiles) != 4 {
+		t.Errorf("callSet() daiminkan = %+v", kan)
+	}
+}
+
+func TestOfferCalls(t *testing.T) {
+	ronHand := []int{1, 2, 3, 4, 5, 11, 12, 13, 23, 24, 25, 13, 13} // waits on 1m/4m/7m (0, 3, 6)
+	all := []ActionType{Action_Ron, Action_Ron, Action_Ron, Action_Ron}
+
+	t.Run("Pon beats chi", func(t *testing.T) {
+		r := scriptedRound(DefaultRules(), []ActionType{Action_Pass, Action_Chi, Action_Pon, Action_Pass},
+			nil, []int{1, 2, 30}, []int{0, 0, 30}, []int{27})
+		rons, caller, call := r.offerCalls(0, ParseTile(0, false))
+		if len(rons) != 0 || caller != 2 || call.Type != Action_Pon {
+			t.Errorf("offerCalls() = %v, %v, %v, want pon by seat 2", rons, caller, call.Type)
+		}
+	})
+
+	t.Run("Chi only from the next seat", func(t *testing.T) {
+		r := scriptedRound(DefaultRules(), []ActionType{Action_Pass, Action_Pass, Action_Chi, Action_Chi},
+			nil, []int{27}, []int{1, 2, 30}, []int{1, 2, 30})
+		if _, caller, _ := r.offerCalls(0, ParseTile(0, false)); caller != -1 {
+			t.Errorf("offerCalls() caller = %v, want no chi from a non-adjacent seat", caller)
+		}
+	})
+
+	t.Run("Double ron", func(t *testing.T) {
+		r := scriptedRound(DefaultRules(), all, nil, []int{27}, ronHand, ronHand)
+		r.seats[2].riichi, r.seats[3].riichi = true, true
+		rons, _, _ := r.offerCalls(0, ParseTile(0, false))
+		if !reflect.DeepEqual(rons, []int{2, 3}) {
+			t.Errorf("offerCalls() rons = %v, want [2 3]", rons)
+		}
+	})
+
+	t.Run("Atamahane", func(t *testing.T) {
+		rules := DefaultRules()
+		rules.MultipleRon = false
+		r := scriptedRound(rules, all, nil, []int{27}, ronHand, ronHand)
+		r.seats[2].riichi, r.seats[3].riichi = true, true
+		rons, _, _ := r.offerCalls(1, ParseTile(0, false))
+		if !reflect.DeepEqual(rons, []int{2}) {
+			t.Errorf("offerCalls() rons = %v, want [2]", rons)
+		}
+	})
+
+	t.Run("Triple ron under atamahane", func(t *testing.T) {
+		for _, sanchahou := range []bool{false, true} {
+			rules := DefaultRules()
+			rules.MultipleRon, rules.Sanchahou = false, sanchahou
+			r := scriptedRound(rules, all, []int{27}, ronHand, ronHand, ronHand)
+			r.seats[1].riichi, r.seats[2].riichi, r.seats[3].riichi = true, true, true
+			want := []int{1}
+			if sanchahou {
+				want = []int{1, 2, 3}
+			}
+			if rons, _, _ := r.offerCalls(0, ParseTile(0, false)); !reflect.DeepEqual(rons, want) {
+				t.Errorf("offerCalls() Sanchahou = %v rons = %v, want %v", sanchahou, rons, want)
+			}
+		}
+	})
+
+	t.Run("Furiten cannot ron", func(t *testing.T) {
+		r := scriptedRound(DefaultRules(), all, nil, ronHand)
+		r.seats[1].riichi = true
+		r.seats[1].discards = []Discard{{Tile: ParseTile(6, false)}}
+		if rons, _, _ := r.offerCalls(0, ParseTile(0, false)); len(rons) != 0 {
+			t.Errorf("offerCalls() rons = %v, want none while furiten", rons)
+		}
+	})
+
+	t.Run("Passing a winning tile is temporary furiten", func(t *testing.T) {
+		// Tanyao on 4m without riichi
+		r := scriptedRound(DefaultRules(), []ActionType{Action_Pass, Action_Pass, Action_Pass, Action_Pass}, nil, ronHand)
+		r.offerCalls(0, ParseTile(3, false))
+		if !r.furiten(1) {
+			t.Errorf("seat should be furiten after passing a ron")
+		}
+		r.seats[1].tiles = append(r.seats[1].tiles, ParseTile(30, false))
+		r.discard(1, ParseTile(30, false), true, false)
+		if r.furiten(1) {
+			t.Errorf("temporary furiten should clear on the seat's own discard")
+		}
+	})
+
+	t.Run("Passing a winning tile in riichi is furiten for the round", func(t *testing.T) {
+		r := scriptedRound(DefaultRules(), []ActionType{Action_Pass, Action_Pass, Action_Pass, Action_Pass}, nil, ronHand)
+		r.seats[1].riichi = true
+		r.offerCalls(0, ParseTile(0, false))
+		// The forced tsumogiri does not clear the furiten
+		r.seats[1].tiles = append(r.seats[1].tiles, ParseTile(30, false))
+		r.discard(1, ParseTile(30, false), true, false)
+		if !r.furiten(1) {
+			t.Errorf("riichi seat should stay furiten after its next discard")
+		}
+		r.agents[1] = &scriptAgent{prefer: Action_Ron}
+		if options := r.callOptions(1, 2, ParseTile(6, false)); len(options) > 0 && options[0].Type == Action_Ron {
+			t.Errorf("callOptions() = %v, want no ron on the same wait", options)
+		}
+		if rons, _, _ := r.offerCalls(2, ParseTile(6, false)); len(rons) != 0 {
+			t.Errorf("offerCalls() rons = %v, want none while furiten in riichi", rons)
+		}
+	})
+}
+
+func TestSettleRon(t *testing.T) {
+	setup := testSetup(1)
+	setup.Honba = 1
+	setup.RiichiSticks = 2
+	ronHand := []int{1, 2, 3, 4, 5, 11, 12, 13, 23, 24, 25, 13, 13}
+	r := NewRound(DefaultRules(), setup, greedyAgents())
+	r.seats[2].tiles = tilesOf(ronHand...)
+	r.seats[3].tiles = tilesOf(ronHand...)
+
+	// Pinfu tanyao on 1m/4m: 4m gives tanyao, both winners are non-dealers
+	result := r.settleRon(1, ParseTile(3, false), []int{2, 3})
+	if len(result.Wins) != 2 {
+		t.Fatalf("settleRon() wins = %v", result.Wins)
+	}
+	ron := result.Wins[0].Score.Ron
+	want := []int{0, -(2*ron + 300), ron + 300 + 2000, ron}
+	if !reflect.DeepEqual(result.Deltas, want) {
+		t.Errorf("settleRon() deltas = %v, want %v", result.Deltas, want)
+	}
+}
@@ -0,0 +1,170 @@
+package main
+
+import "sort"
+
+// Deal-in danger estimation for defensive play
+
+type SafetyClass int
+
+const (
+	Safety_Genbutsu  SafetyClass = iota // Discarded by the opponent or passed after their riichi
+	Safety_NoChance                     // Every two-sided wait is impossible from visible tiles (kabe)
+	Safety_Suji                         // Every two-sided wait is furiten through the opponent's discards
+	Safety_Honor                        // Honor tile, only a single or dual pair wait is possible
+	Safety_OneChance                    // Two-sided waits need the last unseen copy of a neighbour
+	Safety_HalfSuji                     // A middle tile with only one side covered by suji
+	Safety_NonSuji                      // No protection
+)
+
+func (c SafetyClass) String() string {
+	switch c {
+	case Safety_Genbutsu:
+		return "Genbutsu"
+	case Safety_NoChance:
+		return "No-chance"
+	case Safety_Suji:
+		return "Suji"
+	case Safety_Honor:
+		return "Honor"
+	case Safety_OneChance:
+		return "One-chance"
+	case Safety_HalfSuji:
+		return "Half-suji"
+	default:
+		return "Non-suji"
+	}
+}
+
+// What is known about an opponent for danger estimation
+type OpponentState struct {
+	Seat        int
+	Discards    []Tile // the opponent's own discards
+	PassedTiles []Tile // tiles discarded by others since the opponent's riichi without a ron
+	Riichi      bool
+	Melds       []Set
+}
+
+// Danger of a tile against one opponent
+type OpponentDanger struct {
+	Seat   int
+	Class  SafetyClass
+	Danger float64 // estimated deal-in chance in percent, scaled by the opponent's threat
+}
+
+// Danger of discarding a tile against every opponent
+type TileDanger struct {
+	Tile        Tile
+	Danger      float64 // chance in percent of dealing in to at least one opponent
+	PerOpponent []OpponentDanger
+}
+
+// Deal-in chance (percent) contributed by each live two-sided wait on a tile
+const ryanmenDanger = 3.5
+
+// Deal-in chance (percent) from closed, edge, dual pair and single waits by rank
+var otherWaitDanger = [9]float64{2.0, 4.0, 5.5, 5.0, 5.0, 5.0, 5.5, 4.0, 2.0}
+
+// Deal-in chance (percent) of an honor tile by the number of unseen copies
+var honorDanger = [5]float64{0, 1.5, 4.5, 7.5, 8.0}
+
+// Scale of the closed/dual pair/single wait danger by the number of unseen copies of the tile
+var ownCopiesScale = [5]float64{0.3, 0.6, 0.9, 1.0, 1.0}
+
+// Estimates the probability that an opponent is tenpai from their riichi, calls and discards
+func TenpaiThreat(o OpponentState) float64 {
+	if o.Riichi {
+		return 1
+	}
+	open := 0
+	for _, m := range o.Melds {
+		if m.Open {
+			open++
+		}
+	}
+	threat := float64(open)*0.2 + float64(len(o.Discards))/30
+	return min(threat, 0.9)
+}
+
+// Builds the set of tiles the opponent cannot ron on
+func safeTiles(o OpponentState) [34]bool {
+	var safe [34]bool
+	for _, t := range o.Discards {
+		safe[t.ID] = true
+	}
+	for _, t := range o.PassedTiles {
+		safe[t.ID] = true
+	}
+	return safe
+}
+
+// Estimates the deal-in chance (percent) of a tile against a tenpai opponent and classifies it
+func tileDanger(id int, safe [34]bool, kb KB) (SafetyClass, float64) {
+	if safe[id] {
+		return Safety_Genbutsu, 0
+	}
+	rem := kb.Remaining(id)
+	if id >= 27 {
+		return Safety_Honor, honorDanger[rem]
+	}
+	rank := id % 9
+	live, oneChance, suji, kabe, shapes := 0.0, 0, 0, 0, 0
+	// Two-sided shapes waiting on this tile: (rank+1, rank+2) and (rank-2, rank-1)
+	for _, dir := range []int{1, -1} {
+		a, b, other := rank+dir, rank+2*dir, rank+3*dir
+		if b < 0 || b > 8 || other < 0 || other > 8 {
+			continue // edge shapes (1-2, 8-9) are single-sided and counted with other waits
+		}
+		shapes++
+		base := id - rank
+		switch {
+		case safe[base+other]:
+			suji++
+		case kb.Remaining(base+a) == 0 || kb.Remaining(base+b) == 0:
+			kabe++
+		case kb.Remaining(base+a) == 1 || kb.Remaining(base+b) == 1:
+			oneChance++
+			live += 0.4
+		default:
+			live++
+		}
+	}
+	danger := live*ryanmenDanger + otherWaitDanger[rank]*ownCopiesScale[rem]
+	switch {
+	case suji+kabe == shapes && suji == 0:
+		return Safety_NoChance, danger
+	case suji+kabe == shapes:
+		return Safety_Suji, danger
+	case oneChance > 0 && suji+kabe+oneChance == shapes:
+		return Safety_OneChance, danger
+	case suji+kabe > 0:
+		return Safety_HalfSuji, danger
+	}
+	return Safety_NonSuji, danger
+}
+
+// Estimates the danger of every tile in a hand against each opponent, using the opponents'
+// discards and riichi-passed tiles for genbutsu and suji, and the KB's unseen counts for
+// kabe, no-chance, one-chance and honor visibility. Danger against a non-riichi opponent is
+// scaled by their estimated tenpai chance. Results are ranked safest first.
+func EstimateDanger(hand Hand, opponents []OpponentState, kb KB) []TileDanger {
+	var table []TileDanger
+	for id, count := range hand.counts {
+		if count == 0 {
+			continue
+		}
+		td := TileDanger{Tile: ParseTile(id, false)}
+		safeAll := 1.0
+		for _, o := range opponents {
+			class, danger := tileDanger(id, safeTiles(o), kb)
+			danger *= TenpaiThreat(o)
+			td.PerOpponent = append(td.PerOpponent, OpponentDanger{Seat: o.Seat, Class: class, Danger: danger})
+			safeAll *= 1 - danger/100
+		}
+		td.Danger = (1 - safeAll) * 100
+		table = append(table, td)
+	}
+	sort.SliceStable(table, func(i, j int) bool {
+		return table[i].Danger < table[j].Danger
+	})
+	return table
+}
@@ -0,0 +1,119 @@
+package main
+
+import (
+	"testing"
+)
+
+func tilesOf(ids ...int) []Tile {
+	tiles := make([]Tile, len(ids))
+	for i, id := range ids {
+		tiles[i] = ParseTile(id, false)
+	}
+	return tiles
+}
+
+func TestTileDanger(t *testing.T) {
+	noChanceKB := NewKB()
+	for i := 0; i < 4; i++ {
+		noChanceKB.Reveal(1) // all four 2-man visible
+	}
+	oneChanceKB := NewKB()
+	for i := 0; i < 3; i++ {
+		oneChanceKB.Reveal(6) // three 7-man visible
+	}
+	honorKB := NewKB()
+	for i := 0; i < 3; i++ {
+		honorKB.Reveal(31)
+	}
+
+	tests := []struct {
+		name      string
+		id        int
+		discards  []Tile
+		passed    []Tile
+		kb        KB
+		wantClass SafetyClass
+		wantMax   float64
+		wantMin   float64
+	}{
+		{"Genbutsu", 4, tilesOf(4), nil, NewKB(), Safety_Genbutsu, 0, 0},
+		{"Passed after riichi", 4, nil, tilesOf(4), NewKB(), Safety_Genbutsu, 0, 0},
+		{"Terminal suji", 0, tilesOf(3), nil, NewKB(), Safety_Suji, 2.0, 2.0},
+		{"Middle tile nakasuji", 4, tilesOf(1, 7), nil, NewKB(), Safety_Suji, 5.0, 5.0},
+		{"Middle tile half-suji", 4, tilesOf(1), nil, NewKB(), Safety_HalfSuji, 8.5, 8.5},
+		{"Middle tile non-suji", 4, nil, nil, NewKB(), Safety_NonSuji, 12.0, 12.0},
+		{"Terminal no-chance", 0, nil, nil, noChanceKB, Safety_NoChance, 2.0, 2.0},
+		{"One-chance", 8, nil, nil, oneChanceKB, Safety_OneChance, 3.4, 3.4},
+		{"Honor with one unseen copy", 31, nil, nil, honorKB, Safety_Honor, 1.5, 1.5},
+		{"Fresh honor", 32, nil, nil, NewKB(), Safety_Honor, 8.0, 8.0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			safe := safeTiles(OpponentState{Discards: tt.discards, PassedTiles: tt.passed})
+			class, danger := tileDanger(tt.id, safe, tt.kb)
+			if class != tt.wantClass {
+				t.Errorf("tileDanger() class = %v, want %v", class, tt.wantClass)
+			}
+			if danger < tt.wantMin-1e-9 || danger > tt.wantMax+1e-9 {
+				t.Errorf("tileDanger() danger = %v, want %v", danger, tt.wantMin)
+			}
+		})
+	}
+}
+
+func TestTenpaiThreat(t *testing.T) {
+	pon := Set{Type: Koutsu, Tiles: tilesOf(31, 31, 31), Open: true}
+	tests := []struct {
+		name string
+		opp  OpponentState
+		want float64
+	}{
+		{"Riichi", OpponentState{Riichi: true}, 1},
+		{"Early closed hand", OpponentState{Discards: tilesOf(27, 28, 29)}, 0.1},
+		{"Two calls", OpponentState{Melds: []Set{pon, pon}, Discards: tilesOf(27, 28, 29)}, 0.5},
+		{"Capped below riichi", OpponentState{Melds: []Set{pon, pon, pon, pon}, Discards: tilesOf(27, 28, 29)}, 0.9},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := TenpaiThreat(tt.opp); got < tt.want-1e-9 || got > tt.want+1e-9 {
+				t.Errorf("TenpaiThreat() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestEstimateDanger(t *testing.T) {
+	hand := handOf(0, 4, 13, 27)
+	kb := NewKB()
+	kb.RevealHand(hand)
+	opponents := []OpponentState{
+		{Seat: 1, Riichi: true, Discards: tilesOf(3, 27)},
+		{Seat: 2, Discards: tilesOf(30, 31, 32)},
+	}
+
+	table := EstimateDanger(hand, opponents, kb)
+	if len(table) != 4 {
+		t.Fatalf("EstimateDanger() returned %v tiles, want 4", len(table))
+	}
+	for i := 1; i < len(table); i++ {
+		if table[i-1].Danger > table[i].Danger {
+			t.Errorf("EstimateDanger() not ranked safest first")
+		}
+	}
+	if table[0].Tile.ID != 27 {
+		t.Errorf("EstimateDanger() safest = %v, want East (genbutsu against riichi)", table[0].Tile.ID)
+	}
+	if table[3].Tile.ID != 4 && table[3].Tile.ID != 13 {
+		t.Errorf("EstimateDanger() most dangerous = %v, want a middle tile", table[3].Tile.ID)
+	}
+	for _, td := range table {
+		if len(td.PerOpponent) != 2 || td.PerOpponent[0].Seat != 1 || td.PerOpponent[1].Seat != 2 {
+			t.Errorf("EstimateDanger() per opponent entries = %+v", td.PerOpponent)
+		}
+		if td.PerOpponent[1].Danger > td.PerOpponent[0].Danger && td.PerOpponent[0].Class != Safety_Genbutsu {
+			t.Errorf("non-riichi opponent should be scaled below the riichi opponent for %v", td.Tile.ID)
+		}
+	}
+}
@@ -0,0 +1,295 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"math"
+)
+
+// Push/fold and riichi/dama decisions combining hand value, win rate and danger
+
+type DecisionType int
+
+const (
+	Decision_Push   DecisionType = iota // Discard for hand efficiency
+	Decision_Fold                       // Discard the safest tile
+	Decision_Riichi                     // Declare riichi with the recommended discard
+	Decision_Dama                       // Stay silent while tenpai
+)
+
+func (d DecisionType) String() string {
+	switch d {
+	case Decision_Push:
+		return "Push"
+	case Decision_Fold:
+		return "Fold"
+	case Decision_Riichi:
+		return "Riichi"
+	default:
+		return "Dama"
+	}
+}
+
+// Game state as seen by the deciding player after drawing
+type DecisionState struct {
+	Hand          Hand       // concealed tiles after the draw
+	Melds         []Set      // called sets and closed kans
+	KB            KB         // tiles unseen by the player
+	WinCtx        WinContext // seat and round wind and dora indicators; the seat wind agrees with Seat and Dealer
+	Opponents     []OpponentState
+	Seat          int   // the player's seat, indexing Scores
+	Dealer        int   // the dealer's seat
+	Scores        []int // points of every seat
+	WallRemaining int   // live wall tiles left to draw
+	Riichi        bool  // the player has already declared riichi
+}
+
+// Factors behind a decision
+type DecisionFactors struct {
+	Shanten       Deficiency // deficiency after the efficient discard
+	Ukeire        int        // unseen tiles improving the hand after the efficient discard
+	OwnValue      float64    // expected points of a win
+	WinRate       float64    // estimated probability of winning the hand
+	DealIn        float64    // probability of dealing in with the efficient discard
+	SafeDealIn    float64    // probability of dealing in with the safest discard
+	Threat        float64    // highest tenpai threat among opponents
+	ThreatValue   float64    // expected points lost when dealing in
+	Placement     int        // current placement, 1 is first
+	PushEV        float64
+	FoldEV        float64
+	RiichiEV      float64
+	DamaEV        float64
+	SafestTile    Tile
+	EfficientTile Tile
+}
+
+type Decision struct {
+	Type    DecisionType
+	Discard Tile
+	Factors DecisionFactors
+	Reasons []string
+}
+
+// Value assumed for a hand too far from tenpai to be estimated directly
+const farHandValue = 3000.0
+
+// Expected points lost when dealing in to an opponent, by how they are playing
+const (
+	riichiDealInValue = 5200.0
+	openDealInValue   = 3900.0
+	damaDealInValue   = 4500.0
+	dealerMultiplier  = 1.5
+)
+
+// Share of wins that riichi keeps compared to dama, since opponents fold and the wait is fixed
+const riichiWinShare = 0.9
+
+// Estimates the probability of winning within the given draws for a hand at the given
+// deficiency, assuming the current ukeire stays constant at each stage. Ron chances on
+// tenpai are approximated by scaling the tsumo chance by the expected share of tsumo wins.
+func EstimateWinRate(shanten Deficiency, ukeire, unseen, draws int) float64 {
+	if shanten < 0 {
+		return 1
+	}
+	if unseen <= 0 || draws <= 0 {
+		return 0
+	}
+	improve := min(1, float64(ukeire)/float64(unseen))
+	win := min(1, improve/defaultTsumoRate)
+	// probability of being at each stage, index 0 is tenpai
+	stages := make([]float64, int(shanten)+1)
+	stages[shanten] = 1
+	won := 0.0
+	for d := 0; d < draws; d++ {
+		won += stages[0] * win
+		stages[0] *= 1 - win
+		for s := 1; s < len(stages); s++ {
+			stages[s-1] += stages[s] * improve
+			stages[s] *= 1 - improve
+		}
+	}
+	return won
+}
+
+// Expected points lost when dealing in to an opponent
+func dealInValue(o OpponentState, dealer int) float64 {
+	value := damaDealInValue
+	switch {
+	case o.Riichi:
+		value = riichiDealInValue
+	case len(o.Melds) > 0:
+		value = openDealInValue
+	}
+	if o.Seat == dealer {
+		value *= dealerMultiplier
+	}
+	return value
+}
+
+// Returns the placement (1-based) of a seat, earlier seats winning ties
+func placement(scores []int, seat int) int {
+	place := 1
+	for s, score := range scores {
+		if score > scores[seat] || (score == scores[seat] && s < seat) {
+			place++
+		}
+	}
+	return place
+}
+
+// Expected points of a win with the hand left after a discard; closed 1-shanten hands are
+// valued as if they will riichi on reaching tenpai
+func ownValue(state DecisionState, value HandValue, shanten Deficiency, closed bool) float64 {
+	if shanten <= 1 && value.Remaining > 0 {
+		return value.Expected(state.Riichi || (closed && shanten == 1), defaultTsumoRate)
+	}
+	if state.Seat == state.Dealer {
+		return farHandValue * dealerMultiplier
+	}
+	return farHandValue
+}
+
+// Expected loss of dealing in with a tile, weighted by each opponent's share of its danger
+func threatValue(state DecisionState, td TileDanger) float64 {
+	total, weighted := 0.0, 0.0
+	for i, od := range td.PerOpponent {
+		total += od.Danger
+		weighted += od.Danger * dealInValue(state.Opponents[i], state.Dealer)
+	}
+	if total == 0 {
+		return riichiDealInValue
+	}
+	return weighted / total
+}
+
+// Returns the placement of the deciding seat (0 when scores are unknown) and the weight it gives
+// to risk: leaders are more cautious and the last place more aggressive
+func placementRisk(state DecisionState) (int, float64) {
+	if len(state.Scores) <= state.Seat {
+		return 0, 1
+	}
+	place := placement(state.Scores, state.Seat)
+	switch {
+	case place == 1:
+		return place, 1.2
+	case place == len(state.Scores) && place > 1:
+		return place, 0.8
+	}
+	return place, 1
+}
+
+// Reports whether the deciding seat has the draws and points left to declare riichi
+func canRiichi(state DecisionState) bool {
+	return state.WallRemaining >= 4 && (len(state.Scores) <= state.Seat || state.Scores[state.Seat] >= 1000)
+}
+
+// Selects the discard that minimises deficiency, then maximises ukeire, then minimises danger
+func efficientDiscard(state DecisionState, danger map[int]float64) (int, Deficiency, int) {
+	best, bestD, bestU := -1, Deficiency(0), 0
+	hand := state.Hand
+	for id, count := range hand.counts {
+		if count == 0 {
+			continue
+		}
+		hand.counts[id]--
+		d := CalculateDeficiency(hand, len(state.Melds))
+		_, u := Ukeire(hand, len(state.Melds), state.KB)
+		hand.counts[id]++
+		switch {
+		case best == -1, d < bestD, d == bestD && u > bestU:
+		case d == bestD && u == bestU && danger[id] < danger[best]:
+		default:
+			continue
+		}
+		best, bestD, bestU = id, d, u
+	}
+	return best, bestD, bestU
+}
+
+// Decides whether to push, fold, riichi or stay dama, explaining the factors behind it.
+// The push EV weighs the chance of winning against the cumulative risk of the dangerous
+// discards needed to get there (one per remaining step to tenpai plus one while waiting);
+// folding only risks the safest discard. Placement makes leaders more cautious and the
+// last place more aggressive. Tenpai hands that push compare riichi against dama.
+// Returns an error when the hand has no tile to discard.
+func Decide(state DecisionState) (Decision, error) {
+	var f DecisionFactors
+	var reasons []string
+
+	table := EstimateDanger(state.Hand, state.Opponents, state.KB)
+	if len(table) == 0 {
+		return Decision{}, errors.New("decide: no tile to discard")
+	}
+	danger := map[int]float64{}
+	for _, td := range table {
+		danger[td.Tile.ID] = td.Danger / 100
+	}
+	safest := table[0].Tile.ID
+	f.SafestTile = ParseTile(safest, false)
+	f.SafeDealIn = danger[safest]
+
+	eff, shanten, ukeire := efficientDiscard(state, danger)
+	f.EfficientTile = ParseTile(eff, false)
+	f.Shanten, f.Ukeire = shanten, ukeire
+	f.DealIn = danger[eff]
+
+	players := len(state.Opponents) + 1
+	draws := state.WallRemaining / players
+	f.WinRate = EstimateWinRate(shanten, ukeire, state.KB.Total(), draws)
+
+	after := state.Hand
+	after.counts[eff]--
+	value := EstimateHandValue(after, state.Melds, state.KB, state.WinCtx)
+	closed := true
+	for _, m := range state.Melds {
+		if m.Open {
+			closed = false
+		}
+	}
+	f.OwnValue = ownValue(state, value, shanten, closed)
+
+	for _, o := range state.Opponents {
+		f.Threat = max(f.Threat, TenpaiThreat(o))
+	}
+	for _, td := range table {
+		if td.Tile.ID == eff {
+			f.ThreatValue = threatValue(state, td)
+		}
+	}
+
+	var risk float64
+	f.Placement, risk = placementRisk(state)
+
+	steps := float64(shanten) + 1
+	f.PushEV = f.WinRate*f.OwnValue - risk*f.ThreatValue*(1-math.Pow(1-f.DealIn, steps))
+	f.FoldEV = -risk * f.ThreatValue * f.SafeDealIn
+	reasons = append(reasons,
+		fmt.Sprintf("own hand: %d shanten, %d ukeire, %.0f points at %.0f%% win rate", shanten, ukeire, f.OwnValue, f.WinRate*100),
+		fmt.Sprintf("danger: %.1f%% with the efficient discard, %.1f%% with the safest", f.DealIn*100, f.SafeDealIn*100),
+		fmt.Sprintf("threat: %.0f%% tenpai, %.0f points per deal-in", f.Threat*100, f.ThreatValue))
+	if f.Placement > 0 {
+		reasons = append(reasons, fmt.Sprintf("placement: %d", f.Placement))
+	}
+
+	d := Decision{Type: Decision_Push, Discard: f.EfficientTile}
+	if f.FoldEV > f.PushEV && !state.Riichi {
+		d = Decision{Type: Decision_Fold, Discard: f.SafestTile}
+		reasons = append(reasons, fmt.Sprintf("fold: %.0f beats push %.0f", f.FoldEV, f.PushEV))
+	} else if shanten == 0 && closed && !state.Riichi && value.Remaining > 0 {
+		f.DamaEV = f.WinRate*value.Expected(false, defaultTsumoRate) - risk*f.ThreatValue*f.DealIn
+		f.RiichiEV = f.WinRate*riichiWinShare*value.Expected(true, defaultTsumoRate) -
+			risk*f.ThreatValue*(1-math.Pow(1-f.DealIn, 2)) - 1000*(1-f.WinRate*riichiWinShare)
+		if canRiichi(state) && f.RiichiEV > f.DamaEV {
+			d.Type = Decision_Riichi
+			reasons = append(reasons, fmt.Sprintf("riichi: %.0f beats dama %.0f", f.RiichiEV, f.DamaEV))
+		} else {
+			d.Type = Decision_Dama
+			reasons = append(reasons, fmt.Sprintf("dama: %.0f, riichi %.0f", f.DamaEV, f.RiichiEV))
+		}
+	} else {
+		reasons = append(reasons, fmt.Sprintf("push: %.0f beats fold %.0f", f.PushEV, f.FoldEV))
+	}
+	d.Factors = f
+	d.Reasons = reasons
+	return d, nil
+}
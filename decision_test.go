@@ -0,0 +1,136 @@
+package main
+
+import (
+	"testing"
+)
+
+func TestEstimateWinRate(t *testing.T) {
+	tenpai := EstimateWinRate(0, 8, 100, 10)
+	oneShanten := EstimateWinRate(1, 8, 100, 10)
+	if !(tenpai > oneShanten && oneShanten > 0) {
+		t.Errorf("EstimateWinRate() tenpai %v should exceed 1-shanten %v", tenpai, oneShanten)
+	}
+	if more := EstimateWinRate(0, 8, 100, 15); more <= tenpai {
+		t.Errorf("EstimateWinRate() with more draws %v should exceed %v", more, tenpai)
+	}
+	if got := EstimateWinRate(-1, 0, 100, 0); got != 1 {
+		t.Errorf("EstimateWinRate() complete hand = %v, want 1", got)
+	}
+	if got := EstimateWinRate(0, 0, 100, 10); got != 0 {
+		t.Errorf("EstimateWinRate() dead wait = %v, want 0", got)
+	}
+	if got := EstimateWinRate(0, 8, 100, 0); got != 0 {
+		t.Errorf("EstimateWinRate() no draws = %v, want 0", got)
+	}
+}
+
+func TestPlacement(t *testing.T) {
+	scores := []int{25000, 30000, 25000, 20000}
+	tests := []struct {
+		seat int
+		want int
+	}{
+		{0, 2}, {1, 1}, {2, 3}, {3, 4},
+	}
+	for _, tt := range tests {
+		if got := placement(scores, tt.seat); got != tt.want {
+			t.Errorf("placement(%d) = %v, want %v", tt.seat, got, tt.want)
+		}
+	}
+}
+
+func decisionState(hand Hand, opponents []OpponentState) DecisionState {
+	kb := NewKB()
+	kb.RevealHand(hand)
+	for _, o := range opponents {
+		for _, t := range o.Discards {
+			kb.Reveal(t.ID)
+		}
+	}
+	return DecisionState{
+		Hand:          hand,
+		KB:            kb,
+		WinCtx:        WinContext{Seat: 1},
+		Opponents:     opponents,
+		Seat:          1,
+		Dealer:        0,
+		Scores:        []int{25000, 25000, 25000, 25000},
+		WallRemaining: 50,
+	}
+}
+
+func TestDecide(t *testing.T) {
+	quiet := []OpponentState{
+		{Seat: 0, Discards: tilesOf(27, 33)},
+		{Seat: 2, Discards: tilesOf(28, 32)},
+		{Seat: 3, Discards: tilesOf(29, 31)},
+	}
+	riichi := []OpponentState{
+		{Seat: 0, Riichi: true, Discards: tilesOf(27, 33, 0, 9, 18, 26, 17, 8, 30, 31, 32)},
+		{Seat: 2, Discards: tilesOf(28, 32)},
+		{Seat: 3, Discards: tilesOf(29, 31)},
+	}
+
+	tests := []struct {
+		name        string
+		state       DecisionState
+		wantType    DecisionType
+		wantDiscard int
+	}{
+		{
+			// Tenpai on 1m/4m after discarding the isolated North, no yaku without riichi
+			name:        "Tenpai without yaku declares riichi",
+			state:       decisionState(handOf(1, 2, 3, 4, 5, 11, 12, 13, 23, 24, 25, 0, 0, 30), quiet),
+			wantType:    Decision_Riichi,
+			wantDiscard: 30,
+		},
+		{
+			name:        "Far hand folds against riichi",
+			state:       decisionState(handOf(0, 4, 8, 10, 13, 16, 19, 23, 27, 28, 29, 30, 31, 33), riichi),
+			wantType:    Decision_Fold,
+			wantDiscard: 31, // genbutsu against the riichi and the player to the left
+		},
+		{
+			name:        "Far hand pushes with no threat",
+			state:       decisionState(handOf(0, 4, 8, 10, 13, 16, 19, 23, 27, 28, 29, 30, 31, 33), quiet),
+			wantType:    Decision_Push,
+			wantDiscard: -1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Decide(tt.state)
+			if err != nil {
+				t.Fatalf("Decide() error = %v", err)
+			}
+			if got.Type != tt.wantType {
+				t.Errorf("Decide() = %v, want %v (%v)", got.Type, tt.wantType, got.Reasons)
+			}
+			if tt.wantDiscard >= 0 && got.Discard.ID != tt.wantDiscard {
+				t.Errorf("Decide() discard = %v, want %v (%v)", got.Discard.ID, tt.wantDiscard, got.Reasons)
+			}
+			if len(got.Reasons) == 0 {
+				t.Errorf("Decide() gave no reasons")
+			}
+		})
+	}
+}
+
+func TestDecide_Dealer(t *testing.T) {
+	quiet := []OpponentState{{Seat: 0}, {Seat: 2}, {Seat: 3}}
+	hand := handOf(0, 4, 8, 10, 13, 16, 19, 23, 27, 28, 29, 30, 31, 33)
+	want, err := Decide(decisionState(hand, quiet))
+	if err != nil {
+		t.Fatalf("Decide() error = %v", err)
+	}
+	state := decisionState(hand, quiet)
+	state.Dealer, state.WinCtx.Seat = 1, 0
+	if dealer, _ := Decide(state); dealer.Factors.OwnValue <= want.Factors.OwnValue {
+		t.Errorf("Decide() dealer own value = %v, want more than %v", dealer.Factors.OwnValue, want.Factors.OwnValue)
+	}
+
+	if _, err := Decide(decisionState(Hand{}, quiet)); err == nil {
+		t.Errorf("Decide() of an empty hand succeeded")
+	}
+}
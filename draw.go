@@ -0,0 +1,77 @@
+package main
+
+// Exhaustive draw: tenpai payments, nagashi mangan and dealer repeat
+
+// Reports whether a seat is tenpai; a wait on a tile the seat already holds all four
+// copies of, concealed or called, does not count
+func (s *seatState) tenpai() bool {
+	hand := s.hand()
+	held := hand
+	for _, m := range s.melds {
+		for _, t := range m.Tiles {
+			held.counts[t.ID]++
+		}
+	}
+	for _, id := range Waits(hand, len(s.melds)) {
+		if held.counts[id] < 4 {
+			return true
+		}
+	}
+	return false
+}
+
+// Reports whether a seat qualifies for nagashi mangan: every discard a terminal or honor
+// and none of them called
+func (s *seatState) nagashi() bool {
+	if len(s.discards) == 0 {
+		return false
+	}
+	for _, d := range s.discards {
+		if d.Called || !d.Tile.IsTerminalOrHonor() {
+			return false
+		}
+	}
+	return true
+}
+
+// Settles an exhaustive draw. Nagashi mangan is paid as a mangan tsumo and replaces the
+// tenpai payments; otherwise noten seats pay the rules' noten bappu to the tenpai seats. The dealer
+// repeats when tenpai.
+func (r *Round) exhaustiveDraw() RoundResult {
+	n := r.players()
+	result := RoundResult{
+		Exhaustive:   true,
+		Deltas:       make([]int, n),
+		Tenpai:       make([]bool, n),
+		RiichiSticks: r.sticks,
+	}
+	tenpai := 0
+	for seat, s := range r.seats {
+		result.Tenpai[seat] = s.tenpai()
+		if result.Tenpai[seat] {
+			tenpai++
+		}
+	}
+	result.DealerRepeat = result.Tenpai[r.Setup.Dealer]
+
+	if r.Rules.NagashiMangan {
+		for seat, s := range r.seats {
+			if !s.nagashi() {
+				continue
+			}
+			result.Nagashi = append(result.Nagashi, seat)
+			r.payTsumo(seat, ScoreFromHanFu(5, 30, seat == r.Setup.Dealer, true), 0, result.Deltas)
+		}
+	}
+	if len(result.Nagashi) == 0 && tenpai > 0 && tenpai < n {
+		for seat := range r.seats {
+			if result.Tenpai[seat] {
+				result.Deltas[seat] += r.Rules.NotenBappu / tenpai
+			} else {
+				result.Deltas[seat] -= r.Rules.NotenBappu / (n - tenpai)
+			}
+		}
+	}
+	r.emit(Event{Type: Event_ExhaustiveDraw})
+	return result
+}
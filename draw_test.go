@@ -0,0 +1,90 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestSeatState_Tenpai(t *testing.T) {
+	tests := []struct {
+		name  string
+		tiles []int
+		melds []Set
+		want  bool
+	}{
+		{"Ryanmen wait", []int{1, 2, 3, 4, 5, 11, 12, 13, 23, 24, 25, 13, 13}, nil, true},
+		{"Noten", []int{0, 2, 4, 6, 8, 10, 12, 14, 16, 18, 20, 22, 27}, nil, false},
+		{"Tanki on a tile held four times", []int{0, 0, 0, 0, 9, 10, 11, 18, 19, 20, 27, 27, 27}, nil, false},
+		{"Tanki on a tile completed by a pon", []int{0, 9, 10, 11, 18, 19, 20, 27, 27, 27},
+			[]Set{{Type: Koutsu, Tiles: tilesOf(0, 0, 0), Open: true, Target: 1}}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := &seatState{tiles: tilesOf(tt.tiles...), melds: tt.melds}
+			if got := s.tenpai(); got != tt.want {
+				t.Errorf("tenpai() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSeatState_Nagashi(t *testing.T) {
+	tests := []struct {
+		name     string
+		discards []Discard
+		want     bool
+	}{
+		{"Terminals and honors", []Discard{{Tile: ParseTile(0, false)}, {Tile: ParseTile(27, false)}, {Tile: ParseTile(17, false)}}, true},
+		{"A simple discard", []Discard{{Tile: ParseTile(0, false)}, {Tile: ParseTile(4, false)}}, false},
+		{"A discard was called", []Discard{{Tile: ParseTile(0, false)}, {Tile: ParseTile(31, false), Called: true}}, false},
+		{"No discards", nil, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := &seatState{discards: tt.discards}
+			if got := s.nagashi(); got != tt.want {
+				t.Errorf("nagashi() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRound_ExhaustiveDraw(t *testing.T) {
+	tenpai := []int{1, 2, 3, 4, 5, 11, 12, 13, 23, 24, 25, 13, 13}
+	noten := []int{0, 2, 4, 6, 8, 10, 12, 14, 16, 18, 20, 22, 27}
+	simple := []Discard{{Tile: ParseTile(4, false)}}
+	pass := []ActionType{Action_Pass, Action_Pass, Action_Pass, Action_Pass}
+
+	tests := []struct {
+		name       string
+		hands      [][]int
+		nagashi    int // seat with terminal and honor discards, -1 for none
+		want       []int
+		wantRepeat bool
+	}{
+		{"One tenpai", [][]int{noten, tenpai, noten, noten}, -1, []int{-1000, 3000, -1000, -1000}, false},
+		{"Two tenpai", [][]int{tenpai, noten, tenpai, noten}, -1, []int{1500, -1500, 1500, -1500}, true},
+		{"Three tenpai", [][]int{tenpai, tenpai, tenpai, noten}, -1, []int{1000, 1000, 1000, -3000}, true},
+		{"All tenpai", [][]int{tenpai, tenpai, tenpai, tenpai}, -1, []int{0, 0, 0, 0}, true},
+		{"All noten", [][]int{noten, noten, noten, noten}, -1, []int{0, 0, 0, 0}, false},
+		{"Nagashi mangan replaces tenpai payments", [][]int{noten, tenpai, noten, noten}, 2, []int{-4000, -2000, 8000, -2000}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := scriptedRound(DefaultRules(), pass, tt.hands...)
+			for seat, s := range r.seats {
+				s.discards = simple
+				if seat == tt.nagashi {
+					s.discards = []Discard{{Tile: ParseTile(8, false)}, {Tile: ParseTile(33, false)}}
+				}
+			}
+			result := r.exhaustiveDraw()
+			if !reflect.DeepEqual(result.Deltas, tt.want) {
+				t.Errorf("exhaustiveDraw() deltas = %v, want %v", result.Deltas, tt.want)
+			}
+			if result.DealerRepeat != tt.wantRepeat {
+				t.Errorf("exhaustiveDraw() dealer repeat = %v, want %v", result.DealerRepeat, tt.wantRepeat)
+			}
+		})
+	}
+}
@@ -1,5 +1,11 @@
 package main
 
+import (
+	"slices"
+	"sort"
+	"sync"
+)
+
 /*
 Knowledge Base; provides context window for algorithmic deficiency calculations;
 KB is updated dynamically throughout gameplay as tiles are revealed
@@ -24,6 +30,337 @@ type pDCMP struct {
 	sets [5]Set // Four sets (sequences or triplets) and a remainder (pair, single, or empty)
 }
 
+// Creates a knowledge base with all four copies of every tile unseen
+func NewKB() KB {
+	var kb KB
+	for i := range kb.remainingTiles {
+		kb.remainingTiles[i] = 4
+	}
+	return kb
+}
+
+// Marks a tile as seen, removing one copy from the unseen pool
+func (kb *KB) Reveal(id int) {
+	if kb.remainingTiles[id] > 0 {
+		kb.remainingTiles[id]--
+	}
+}
+
+// Marks every tile of a hand as seen
+func (kb *KB) RevealHand(hand Hand) {
+	for id, count := range hand.counts {
+		for i := 0; i < count; i++ {
+			kb.Reveal(id)
+		}
+	}
+}
+
+// Returns the number of unseen copies of a tile
+func (kb KB) Remaining(id int) int {
+	return kb.remainingTiles[id]
+}
+
+// Returns the total number of unseen tiles
+func (kb KB) Total() int {
+	total := 0
+	for _, count := range kb.remainingTiles {
+		total += count
+	}
+	return total
+}
+
+// Counts of complete sets, partial sets (pairs and incomplete sequences) and a pair head
+// found in one suit of a hand
+type blockCount struct {
+	sets, partials, pair int
+}
+
+// Caches the non-dominated block counts for each suit configuration, keyed by encodeSuit
+var suitBlockCache, honorBlockCache sync.Map
+
+// Encodes up to nine tile counts (each 0-4) as a base 5 integer
+func encodeSuit(counts []int) int {
+	key := 0
+	for _, c := range counts {
+		key = key*5 + c
+	}
+	return key
+}
+
+// Returns the non-dominated block counts for one suit; sequences are only formed when numbered
+func suitBlocks(counts []int, numbered bool) []blockCount {
+	cache := &suitBlockCache
+	if !numbered {
+		cache = &honorBlockCache
+	}
+	key := encodeSuit(counts)
+	if cached, ok := cache.Load(key); ok {
+		return cached.([]blockCount)
+	}
+	c := make([]int, len(counts))
+	copy(c, counts)
+	found := map[blockCount]bool{}
+	suitDFS(c, 0, blockCount{}, numbered, found)
+	// Drop any block count dominated by another with the same pair usage
+	var blocks []blockCount
+	for b := range found {
+		dominated := false
+		for o := range found {
+			if o != b && o.pair == b.pair && o.sets >= b.sets && o.partials >= b.partials {
+				dominated = true
+				break
+			}
+		}
+		if !dominated {
+			blocks = append(blocks, b)
+		}
+	}
+	cache.Store(key, blocks)
+	return blocks
+}
+
+// Enumerates every split of the suit into sets, partial sets, a pair head and isolated tiles
+func suitDFS(c []int, i int, b blockCount, numbered bool, found map[blockCount]bool) {
+	for i < len(c) && c[i] == 0 {
+		i++
+	}
+	if i == len(c) {
+		found[b] = true
+		return
+	}
+	if c[i] >= 3 {
+		c[i] -= 3
+		suitDFS(c, i, blockCount{b.sets + 1, b.partials, b.pair}, numbered, found)
+		c[i] += 3
+	}
+	if c[i] >= 2 {
+		c[i] -= 2
+		if b.pair == 0 {
+			suitDFS(c, i, blockCount{b.sets, b.partials, 1}, numbered, found)
+		}
+		suitDFS(c, i, blockCount{b.sets, b.partials + 1, b.pair}, numbered, found)
+		c[i] += 2
+	}
+	if numbered {
+		if i+2 < len(c) && c[i+1] > 0 && c[i+2] > 0 {
+			c[i]--
+			c[i+1]--
+			c[i+2]--
+			suitDFS(c, i, blockCount{b.sets + 1, b.partials, b.pair}, numbered, found)
+			c[i]++
+			c[i+1]++
+			c[i+2]++
+		}
+		for _, gap := range []int{1, 2} { // ryanmen/penchan, kanchan
+			if i+gap < len(c) && c[i+gap] > 0 {
+				c[i]--
+				c[i+gap]--
+				suitDFS(c, i, blockCount{b.sets, b.partials + 1, b.pair}, numbered, found)
+				c[i]++
+				c[i+gap]++
+			}
+		}
+	}
+	// Leave one copy isolated
+	c[i]--
+	suitDFS(c, i, b, numbered, found)
+	c[i]++
+}
+
+// Deficiency of a hand as four sets and a pair, with openSets sets already called
+func regularDeficiency(hand Hand, openSets int) Deficiency {
+	groups := [][]blockCount{
+		suitBlocks(hand.counts[0:9], true),
+		suitBlocks(hand.counts[9:18], true),
+		suitBlocks(hand.counts[18:27], true),
+		suitBlocks(hand.counts[27:34], false),
+	}
+	best := Deficiency(8)
+	var combine func(g int, b blockCount)
+	combine = func(g int, b blockCount) {
+		if g == len(groups) {
+			sets := b.sets + openSets
+			partials := b.partials
+			if sets+partials > 4 {
+				partials = 4 - sets
+			}
+			if d := Deficiency(8 - 2*sets - partials - b.pair); d < best {
+				best = d
+			}
+			return
+		}
+		for _, o := range groups[g] {
+			if b.pair+o.pair > 1 {
+				continue
+			}
+			combine(g+1, blockCount{b.sets + o.sets, b.partials + o.partials, b.pair + o.pair})
+		}
+	}
+	combine(0, blockCount{})
+	return best
+}
+
+// Deficiency of a hand as seven distinct pairs
+func chiitoitsuDeficiency(hand Hand) Deficiency {
+	pairs, kinds := 0, 0
+	for _, count := range hand.counts {
+		if count >= 1 {
+			kinds++
+		}
+		if count >= 2 {
+			pairs++
+		}
+	}
+	d := 6 - pairs
+	if kinds < 7 {
+		d += 7 - kinds
+	}
+	return Deficiency(d)
+}
+
+// Deficiency of a hand as Kokushi Musou (one of each terminal and honor plus a pair)
+func kokushiDeficiency(hand Hand) Deficiency {
+	kinds, pair := 0, 0
+	for id, count := range hand.counts {
+		if count == 0 || !ParseTile(id, false).IsTerminalOrHonor() {
+			continue
+		}
+		kinds++
+		if count >= 2 {
+			pair = 1
+		}
+	}
+	return Deficiency(13 - kinds - pair)
+}
+
+// Determines the deficiency (shanten) of a hand: -1 is complete, 0 is tenpai.
+// openSets is the number of sets already called; Chiitoitsu and Kokushi Musou
+// are only considered for closed hands without calls
+func CalculateDeficiency(hand Hand, openSets int) Deficiency {
+	d := regularDeficiency(hand, openSets)
+	if openSets == 0 {
+		d = min(d, chiitoitsuDeficiency(hand), kokushiDeficiency(hand))
+	}
+	return d
+}
+
+// Returns the tile IDs that complete a tenpai hand; a tile the hand already holds
+// all four copies of cannot be a winning tile
+func Waits(hand Hand, openSets int) []int {
+	var waits []int
+	for id := range hand.counts {
+		if hand.counts[id] >= 4 {
+			continue
+		}
+		hand.counts[id]++
+		if CalculateDeficiency(hand, openSets) == -1 {
+			waits = append(waits, id)
+		}
+		hand.counts[id]--
+	}
+	return waits
+}
+
+// Returns the tiles that reduce the deficiency of a hand awaiting a draw,
+// and the total number of unseen copies of those tiles according to the KB
+func Ukeire(hand Hand, openSets int, kb KB) ([]int, int) {
+	current := CalculateDeficiency(hand, openSets)
+	var tiles []int
+	count := 0
+	for id := range hand.counts {
+		if hand.counts[id] >= 4 {
+			continue
+		}
+		hand.counts[id]++
+		if CalculateDeficiency(hand, openSets) < current {
+			tiles = append(tiles, id)
+			count += kb.Remaining(id)
+		}
+		hand.counts[id]--
+	}
+	return tiles, count
+}
+
+// One discard of a hand awaiting a discard and what it leaves the hand
+type DiscardEfficiency struct {
+	Tile         Tile
+	Shanten      Deficiency // deficiency after the discard
+	Ukeire       []int      // tiles that reduce the deficiency after the discard
+	UkeireCount  int        // unseen copies of the ukeire tiles
+	NextUkeire   float64    // ukeire after drawing an ukeire tile and the best discard, averaged over the draws
+	Improvements []int      // other tiles that, once drawn, allow a discard widening the ukeire
+	ImproveCount int        // unseen copies of the improving tiles
+}
+
+// Returns the highest ukeire reachable by one discard from a hand awaiting a discard,
+// keeping its deficiency at most the given one; -1 if no discard does
+func bestUkeire(hand Hand, openSets int, kb KB, shanten Deficiency) int {
+	best := -1
+	for id, count := range hand.counts {
+		if count == 0 {
+			continue
+		}
+		hand.counts[id]--
+		if CalculateDeficiency(hand, openSets) <= shanten {
+			_, u := Ukeire(hand, openSets, kb)
+			best = max(best, u)
+		}
+		hand.counts[id]++
+	}
+	return best
+}
+
+// Ranks every discard of a hand awaiting a discard by deficiency, then ukeire, then the
+// ukeire after the next step and the tiles improving the shape, as nani-kiru calculators do
+func AnalyzeDiscards(hand Hand, openSets int, kb KB) []DiscardEfficiency {
+	var discards []DiscardEfficiency
+	for id, count := range hand.counts {
+		if count == 0 {
+			continue
+		}
+		hand.counts[id]--
+		d := DiscardEfficiency{Tile: ParseTile(id, false), Shanten: CalculateDeficiency(hand, openSets)}
+		d.Ukeire, d.UkeireCount = Ukeire(hand, openSets, kb)
+		weight := 0
+		for draw := range hand.counts {
+			if hand.counts[draw] >= 4 || kb.Remaining(draw) == 0 {
+				continue
+			}
+			after := kb
+			after.Reveal(draw)
+			hand.counts[draw]++
+			if slices.Contains(d.Ukeire, draw) {
+				if d.Shanten > 0 {
+					d.NextUkeire += float64(kb.Remaining(draw) * bestUkeire(hand, openSets, after, d.Shanten-1))
+					weight += kb.Remaining(draw)
+				}
+			} else if bestUkeire(hand, openSets, after, d.Shanten) > d.UkeireCount {
+				d.Improvements = append(d.Improvements, draw)
+				d.ImproveCount += kb.Remaining(draw)
+			}
+			hand.counts[draw]--
+		}
+		if weight > 0 {
+			d.NextUkeire /= float64(weight)
+		}
+		hand.counts[id]++
+		discards = append(discards, d)
+	}
+	sort.SliceStable(discards, func(i, j int) bool {
+		a, b := discards[i], discards[j]
+		switch {
+		case a.Shanten != b.Shanten:
+			return a.Shanten < b.Shanten
+		case a.UkeireCount != b.UkeireCount:
+			return a.UkeireCount > b.UkeireCount
+		case a.NextUkeire != b.NextUkeire:
+			return a.NextUkeire > b.NextUkeire
+		}
+		return a.ImproveCount > b.ImproveCount
+	})
+	return discards
+}
+
 /*
 	The Quadtree Algorithm, determines deficiency of a hand T
 	by constructing and evaluating all possible pseudo-decompositions (pDCMPs);
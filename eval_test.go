@@ -0,0 +1,142 @@
+package main
+
+import (
+	"reflect"
+	"slices"
+	"testing"
+)
+
+func TestKB(t *testing.T) {
+	kb := NewKB()
+	if kb.Total() != 136 {
+		t.Fatalf("NewKB() total = %v, want 136", kb.Total())
+	}
+	kb.RevealHand(handOf(0, 0, 5))
+	if kb.Remaining(0) != 2 || kb.Remaining(5) != 3 {
+		t.Errorf("KB.RevealHand() remaining = %v, %v, want 2, 3", kb.Remaining(0), kb.Remaining(5))
+	}
+	for i := 0; i < 5; i++ {
+		kb.Reveal(33)
+	}
+	if kb.Remaining(33) != 0 {
+		t.Errorf("KB.Reveal() should not go below zero, got %v", kb.Remaining(33))
+	}
+	if kb.Total() != 129 {
+		t.Errorf("KB.Total() = %v, want 129", kb.Total())
+	}
+}
+
+func TestCalculateDeficiency(t *testing.T) {
+	tests := []struct {
+		name     string
+		hand     Hand
+		openSets int
+		want     Deficiency
+	}{
+		{"Complete hand", handOf(0, 1, 2, 3, 4, 5, 11, 12, 13, 23, 24, 25, 13, 13), 0, -1},
+		{"Tenpai ryanmen", handOf(1, 2, 3, 4, 5, 11, 12, 13, 23, 24, 25, 13, 13), 0, 0},
+		{"Tenpai tanki", handOf(0, 1, 2, 3, 4, 5, 11, 12, 13, 23, 24, 25, 30), 0, 0},
+		{"One away", handOf(1, 2, 4, 5, 11, 12, 13, 23, 24, 25, 13, 13, 30), 0, 1},
+		{"Chiitoitsu tenpai", handOf(0, 0, 10, 10, 11, 11, 21, 21, 22, 22, 23, 23, 33), 0, 0},
+		{"Chiitoitsu needs distinct pairs", handOf(0, 0, 0, 0, 10, 10, 11, 11, 21, 21, 22, 22, 33), 0, 2},
+		{"Kokushi thirteen-sided", handOf(0, 8, 9, 17, 18, 26, 27, 28, 29, 30, 31, 32, 33), 0, 0},
+		{"Kokushi with pair one away", handOf(0, 0, 9, 17, 18, 26, 27, 28, 29, 30, 31, 32, 33), 0, 0},
+		{"Scattered hand", handOf(0, 4, 8, 9, 13, 17, 19, 23, 27, 28, 29, 31, 32), 0, 4},
+		{"Open hand tenpai", handOf(1, 2, 11, 12, 13, 30, 30), 2, 0},
+		{"Open hand complete", handOf(3, 11, 12, 13, 30, 30, 2, 1), 2, -1},
+		{"Open hands ignore chiitoitsu", handOf(0, 0, 10, 10, 11, 11, 33), 2, 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := CalculateDeficiency(tt.hand, tt.openSets); got != tt.want {
+				t.Errorf("CalculateDeficiency() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestWaits(t *testing.T) {
+	tests := []struct {
+		name     string
+		hand     Hand
+		openSets int
+		want     []int
+	}{
+		{"Ryanmen", handOf(1, 2, 3, 4, 5, 11, 12, 13, 23, 24, 25, 13, 13), 0, []int{0, 3, 6}},
+		{"Kanchan", handOf(0, 2, 3, 4, 5, 11, 12, 13, 23, 24, 25, 13, 13), 0, []int{1}},
+		{"Nobetan", handOf(10, 11, 12, 13, 4, 5, 6, 23, 24, 25, 30, 30, 30), 0, []int{10, 13}},
+		{"Shanpon", handOf(0, 1, 2, 3, 4, 5, 23, 24, 25, 13, 13, 30, 30), 0, []int{13, 30}},
+		{"Kokushi thirteen-sided", handOf(0, 8, 9, 17, 18, 26, 27, 28, 29, 30, 31, 32, 33), 0, []int{0, 8, 9, 17, 18, 26, 27, 28, 29, 30, 31, 32, 33}},
+		{"Fifth tile is not a wait", handOf(0, 0, 0, 0, 1, 2, 11, 12, 13, 23, 24, 25, 30), 0, []int{30}},
+		{"Not tenpai", handOf(1, 2, 4, 5, 11, 12, 13, 23, 24, 25, 13, 13, 30), 0, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Waits(tt.hand, tt.openSets); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Waits() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestUkeire(t *testing.T) {
+	hand := handOf(1, 2, 3, 4, 5, 11, 12, 13, 23, 24, 25, 13, 13)
+	kb := NewKB()
+	kb.RevealHand(hand)
+	tiles, count := Ukeire(hand, 0, kb)
+	if !reflect.DeepEqual(tiles, []int{0, 3, 6}) {
+		t.Errorf("Ukeire() tiles = %v, want [0 3 6]", tiles)
+	}
+	if count != 11 {
+		t.Errorf("Ukeire() count = %v, want 11", count)
+	}
+
+	// Seen tiles reduce the count but not the tiles
+	kb.Reveal(0)
+	kb.Reveal(0)
+	if _, count := Ukeire(hand, 0, kb); count != 9 {
+		t.Errorf("Ukeire() count after reveal = %v, want 9", count)
+	}
+}
+
+func TestAnalyzeDiscards(t *testing.T) {
+	// Tenpai on a 2m kanchan by discarding the isolated 9p
+	hand := handOf(0, 2, 12, 13, 14, 18, 19, 20, 24, 25, 26, 27, 27, 17)
+	kb := NewKB()
+	kb.RevealHand(hand)
+	got := AnalyzeDiscards(hand, 0, kb)
+	if len(got) != 13 {
+		t.Fatalf("AnalyzeDiscards() = %d discards, want one per kind of tile", len(got))
+	}
+	best := got[0]
+	if best.Tile.ID != 17 || best.Shanten != 0 || !reflect.DeepEqual(best.Ukeire, []int{1}) || best.UkeireCount != 4 {
+		t.Errorf("AnalyzeDiscards() best = %+v, want 9p waiting on 2m", best)
+	}
+	if best.NextUkeire != 0 {
+		t.Errorf("AnalyzeDiscards() tenpai next ukeire = %v, want 0", best.NextUkeire)
+	}
+	// Drawing 4m allows discarding 1m for a 2-5m wait
+	if !slices.Contains(best.Improvements, 3) || best.ImproveCount < 4 {
+		t.Errorf("AnalyzeDiscards() improvements = %v, want 4m among them", best.Improvements)
+	}
+	for i := 1; i < len(got); i++ {
+		a, b := got[i-1], got[i]
+		if a.Shanten > b.Shanten || a.Shanten == b.Shanten && a.UkeireCount < b.UkeireCount {
+			t.Errorf("AnalyzeDiscards() %v ranked before %v", a, b)
+		}
+	}
+
+	// A 1-shanten hand looks one step further
+	hand = handOf(1, 2, 4, 5, 11, 12, 13, 23, 24, 25, 13, 13, 31, 33)
+	kb = NewKB()
+	kb.RevealHand(hand)
+	got = AnalyzeDiscards(hand, 0, kb)
+	if got[0].Shanten != 1 || got[0].NextUkeire <= 0 {
+		t.Errorf("AnalyzeDiscards() best = %+v, want 1 shanten with next ukeire", got[0])
+	}
+	if id := got[0].Tile.ID; id != 31 && id != 33 {
+		t.Errorf("AnalyzeDiscards() best discard = %v, want a dragon", got[0].Tile)
+	}
+}
@@ -0,0 +1,480 @@
+package main
+
+import (
+	"math/rand/v2"
+	"sort"
+)
+
+// A single round of play: dealing, the draw/discard loop and settlement
+
+// Starting conditions of a round
+type RoundSetup struct {
+	RoundWind    int // 0 East, 1 South, ...
+	Dealer       int
+	Honba        int
+	RiichiSticks int
+	Scores       []int
+	Seed         uint64 // seeds the wall shuffle so the round can be replayed
+}
+
+// A winning hand
+type Win struct {
+	Seat   int
+	From   int // seat that dealt in, the winner itself on tsumo
+	Tile   Tile
+	Score  HandScore
+	Liable int // seat paying under pao, -1 if none
+}
+
+// Outcome of a round
+type RoundResult struct {
+	Wins         []Win
+	Exhaustive   bool         // the wall ran out
+	Abortive     AbortiveDraw // the round was aborted, Abortive_None otherwise
+	Tenpai       []bool       // tenpai seats at an exhaustive draw
+	Nagashi      []int        // seats paid nagashi mangan at an exhaustive draw
+	DealerRepeat bool         // the dealer keeps the seat (renchan)
+	Deltas       []int        // point changes per seat
+	Scores       []int        // points per seat after the round
+	RiichiSticks int          // riichi sticks left on the table for the next round
+}
+
+// Private state of a seat
+type seatState struct {
+	tiles         []Tile // concealed tiles
+	melds         []Set
+	discards      []Discard
+	riichi        bool
+	doubleRiichi  bool // riichi declared on the first uninterrupted discard
+	ippatsu       bool // riichi declared and the seat has not discarded or been interrupted since
+	tempFuriten   bool // let a winning tile pass since the last discard
+	riichiFuriten bool // let a winning tile pass after declaring riichi, furiten for the rest of the round
+	kita          []Tile
+	pao           map[string]int // liable seat per yakuman name
+}
+
+type Round struct {
+	Rules  Rules
+	Setup  RoundSetup
+	Events []Event // every event of the round, unredacted
+
+	wall        *Wall
+	agents      []Agent
+	hands       [][]Tile // hands as dealt
+	seats       []*seatState
+	scores      []int
+	discards    int  // discards made so far, used to order Discard.Turn
+	sticks      int  // riichi sticks on the table, including deposits made this round
+	pendingDora int  // kan dora to reveal after the next discard
+	rinshan     bool // the current turn drew a rinshan tile
+	chankan     bool // a kan is being offered for robbing
+	interrupted bool // a call or kan has been made this round
+}
+
+// Where a seat's turn starts from
+type drawKind int
+
+const (
+	drawLive    drawKind = iota // draw from the live wall
+	drawRinshan                 // draw a replacement tile after a kan
+	drawNone                    // discard straight after a chi or pon
+	drawKita                    // draw a replacement tile after setting aside a kita
+)
+
+// Creates a round for the given agents, one per seat, shuffling the wall from the setup seed
+func NewRound(rules Rules, setup RoundSetup, agents []Agent) *Round {
+	r := &Round{
+		Rules:  rules,
+		Setup:  setup,
+		wall:   NewWall(rules, rand.New(rand.NewPCG(setup.Seed, 0))),
+		agents: agents,
+		scores: append([]int{}, setup.Scores...),
+		sticks: setup.RiichiSticks,
+	}
+	for range agents {
+		r.seats = append(r.seats, &seatState{pao: map[string]int{}})
+	}
+	return r
+}
+
+// Number of seats in the round
+func (r *Round) players() int {
+	return len(r.seats)
+}
+
+// Sorts tiles by ID, plain copies before red fives
+func sortTiles(tiles []Tile) {
+	sort.SliceStable(tiles, func(i, j int) bool {
+		if tiles[i].ID != tiles[j].ID {
+			return tiles[i].ID < tiles[j].ID
+		}
+		return !tiles[i].Red && tiles[j].Red
+	})
+}
+
+// Deals 13 tiles to every seat, four at a time starting from the dealer, then one each
+func (r *Round) deal() {
+	n := r.players()
+	for pass := 0; pass < 4; pass++ {
+		count := 4
+		if pass == 3 {
+			count = 1
+		}
+		for i := 0; i < n; i++ {
+			seat := r.seats[(r.Setup.Dealer+i)%n]
+			for c := 0; c < count; c++ {
+				t, _ := r.wall.Draw()
+				seat.tiles = append(seat.tiles, t)
+			}
+		}
+	}
+	for _, seat := range r.seats {
+		sortTiles(seat.tiles)
+		r.hands = append(r.hands, append([]Tile{}, seat.tiles...))
+	}
+}
+
+// Builds the view of the table for a seat
+func (r *Round) view(seat int, drawn *Tile) *PlayerView {
+	n := r.players()
+	v := &PlayerView{
+		Seat:           seat,
+		Dealer:         r.Setup.Dealer,
+		RoundWind:      r.Setup.RoundWind,
+		Honba:          r.Setup.Honba,
+		RiichiSticks:   r.sticks,
+		Scores:         append([]int{}, r.scores...),
+		Tiles:          append([]Tile{}, r.seats[seat].tiles...),
+		Drawn:          drawn,
+		Melds:          make([][]Set, n),
+		Discards:       make([][]Discard, n),
+		Riichi:         make([]bool, n),
+		DoraIndicators: r.wall.DoraIndicators(),
+		Kita:           make([]int, n),
+		WallRemaining:  r.wall.Remaining(),
+	}
+	sortTiles(v.Tiles)
+	for i, s := range r.seats {
+		v.Melds[i] = append([]Set{}, s.melds...)
+		v.Discards[i] = append([]Discard{}, s.discards...)
+		v.Riichi[i] = s.riichi
+		v.Kita[i] = len(s.kita)
+	}
+	return v
+}
+
+// Records an event and notifies every agent; draws are hidden from other seats as tile ID -1
+func (r *Round) emit(ev Event) {
+	r.Events = append(r.Events, ev)
+	for seat, agent := range r.agents {
+		seen := ev
+		if ev.Type == Event_Draw && seat != ev.Seat {
+			seen.Tile = Tile{ID: -1}
+		}
+		agent.OnEvent(r.view(seat, nil), seen)
+	}
+}
+
+// Returns the concealed tiles of a seat as tile counts
+func (s *seatState) hand() Hand {
+	var h Hand
+	for _, t := range s.tiles {
+		h.counts[t.ID]++
+	}
+	return h
+}
+
+// Removes one copy of a tile (matching red) from the concealed tiles
+func (s *seatState) remove(t Tile) bool {
+	for i, c := range s.tiles {
+		if c.ID == t.ID && c.Red == t.Red {
+			s.tiles = append(s.tiles[:i], s.tiles[i+1:]...)
+			return true
+		}
+	}
+	return false
+}
+
+// Counts red fives held by a seat, concealed or called
+func (s *seatState) akaDora() int {
+	count := 0
+	for _, t := range s.tiles {
+		if t.Red {
+			count++
+		}
+	}
+	for _, m := range s.melds {
+		for _, t := range m.Tiles {
+			if t.Red {
+				count++
+			}
+		}
+	}
+	return count
+}
+
+// Builds the win context for a seat winning on a tile
+func (r *Round) winContext(seat int, tile Tile, tsumo bool) WinContext {
+	s := r.seats[seat]
+	ctx := WinContext{
+		WinningTile:    tile,
+		Tsumo:          tsumo,
+		Seat:           (seat - r.Setup.Dealer + r.players()) % r.players(),
+		Round:          r.Setup.RoundWind,
+		Riichi:         s.riichi,
+		DoubleRiichi:   s.doubleRiichi,
+		Ippatsu:        s.ippatsu,
+		TurnCount:      len(s.discards),
+		DoraIndicators: r.wall.DoraIndicators(),
+		AkaDora:        s.akaDora(),
+		Rinshan:        r.rinshan && tsumo,
+		Chankan:        r.chankan && !tsumo,
+		Sanma:          r.sanma(),
+		Kita:           len(s.kita),
+	}
+	if s.riichi {
+		ctx.UraDoraIndicators = r.wall.UraDoraIndicators()
+	}
+	return ctx
+}
+
+// Scores a seat's hand completed by a tile; for ron the tile is not yet in the hand
+func (r *Round) scoreWin(seat int, tile Tile, tsumo bool) (HandScore, bool) {
+	s := r.seats[seat]
+	hand := s.hand()
+	ctx := r.winContext(seat, tile, tsumo)
+	if !tsumo {
+		hand.counts[tile.ID]++
+		if tile.Red {
+			ctx.AkaDora++
+		}
+	}
+	return ScoreHand(hand, s.melds, ctx)
+}
+
+// Lists the legal actions on a seat's turn: discards (the drawn tile first), riichi,
+// kyuushu kyuuhai, kita, tsumo and kans; forbidden holds tiles that may not be discarded after a call. A seat in riichi
+// may only discard the drawn tile.
+func (r *Round) turnOptions(seat int, drawn *Tile, forbidden map[int]bool) []Action {
+	s := r.seats[seat]
+	var options []Action
+	if drawn != nil {
+		options = append(options, Action{Type: Action_Discard, Tile: *drawn})
+	}
+	seen := map[Tile]bool{}
+	if drawn != nil {
+		seen[*drawn] = true
+	}
+	for _, t := range s.tiles {
+		if !seen[t] && !forbidden[t.ID] && !s.riichi {
+			seen[t] = true
+			options = append(options, Action{Type: Action_Discard, Tile: t})
+		}
+	}
+	options = append(options, r.riichiOptions(seat, drawn)...)
+	options = append(options, r.kyuushuOptions(seat, drawn)...)
+	options = append(options, r.kitaOptions(seat, drawn)...)
+	if drawn != nil {
+		if _, ok := r.scoreWin(seat, *drawn, true); ok {
+			options = append(options, Action{Type: Action_Tsumo, Tile: *drawn})
+		}
+		options = append(options, r.kanOptions(seat, *drawn)...)
+	}
+	return options
+}
+
+// Returns the offered action matching the agent's choice, or the first option if the choice
+// was not offered
+func matchOption(options []Action, chosen Action) Action {
+	for _, o := range options {
+		if sameAction(o, chosen) {
+			return o
+		}
+	}
+	return options[0]
+}
+
+// Reports whether two actions have the same type and tiles, telling red fives apart
+func sameAction(a, b Action) bool {
+	if a.Type != b.Type || a.Tile.ID != b.Tile.ID || a.Tile.Red != b.Tile.Red || len(a.Tiles) != len(b.Tiles) {
+		return false
+	}
+	for i := range a.Tiles {
+		if a.Tiles[i] != b.Tiles[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// Discards a tile from a seat's hand; riichi marks the riichi declaration tile
+func (r *Round) discard(seat int, tile Tile, tsumogiri, riichi bool) {
+	s := r.seats[seat]
+	s.remove(tile)
+	s.discards = append(s.discards, Discard{Tile: tile, Turn: r.discards, Tsumogiri: tsumogiri, Riichi: riichi})
+	s.tempFuriten = false
+	s.ippatsu = false
+	r.rinshan = false
+	r.discards++
+	r.emit(Event{Type: Event_Discard, Seat: seat, Tile: tile, Tsumogiri: tsumogiri})
+	r.revealPendingDora()
+}
+
+// Reveals kan dora that were waiting for a discard
+func (r *Round) revealPendingDora() {
+	for ; r.pendingDora > 0; r.pendingDora-- {
+		if t, ok := r.wall.RevealDora(); ok {
+			r.emit(Event{Type: Event_NewDora, Tile: t})
+		}
+	}
+}
+
+// Settles a tsumo win: every other seat pays its share plus honba, the winner takes the riichi sticks.
+// A seat liable under pao pays the liable yakuman alone.
+func (r *Round) settleTsumo(seat int, tile Tile, score HandScore) RoundResult {
+	n := r.players()
+	result := RoundResult{Deltas: make([]int, n)}
+	liable, count := r.liability(seat, score)
+	result.Wins = []Win{{Seat: seat, From: seat, Tile: tile, Score: score, Liable: liable}}
+	if liable >= 0 {
+		r.settlePaoTsumo(seat, liable, count, score, &result)
+		result.Deltas[seat] += riichiDeposit * r.sticks
+		return result
+	}
+	r.payTsumo(seat, score, r.Setup.Honba, result.Deltas)
+	result.Deltas[seat] += riichiDeposit * r.sticks
+	return result
+}
+
+// Pays the tsumo shares of a score, plus honba, from every other seat to the seat.
+// In sanma with north bisection the missing non-dealer share is split between the payers.
+func (r *Round) payTsumo(seat int, score HandScore, honba int, deltas []int) {
+	for other := 0; other < r.players(); other++ {
+		if other == seat {
+			continue
+		}
+		pay := score.TsumoOther
+		if other == r.Setup.Dealer {
+			pay = score.TsumoDealer
+		}
+		if r.sanma() && r.Rules.SanmaTsumo == SanmaTsumo_NorthBisection {
+			pay += roundUp100(score.TsumoOther / 2)
+		}
+		pay += 100 * honba
+		deltas[other] -= pay
+		deltas[seat] += pay
+	}
+}
+
+// Applies the point changes of a result and records final scores; the deltas returned
+// include riichi deposits made during the round
+func (r *Round) finish(result RoundResult) RoundResult {
+	for _, win := range result.Wins {
+		if win.Seat == r.Setup.Dealer {
+			result.DealerRepeat = true
+		}
+	}
+	for seat, d := range result.Deltas {
+		r.scores[seat] += d
+		result.Deltas[seat] = r.scores[seat] - r.Setup.Scores[seat]
+	}
+	result.Scores = append([]int{}, r.scores...)
+	return result
+}
+
+// Draws the tile that starts a seat's turn; returns nil after a call and false when
+// the wall has run out
+func (r *Round) drawFor(seat int, kind drawKind) (*Tile, bool) {
+	var tile Tile
+	var ok bool
+	switch kind {
+	case drawNone:
+		return nil, true
+	case drawLive:
+		tile, ok = r.wall.Draw()
+	case drawRinshan:
+		tile, ok = r.wall.DrawRinshan()
+	case drawKita:
+		tile, ok = r.wall.DrawKita()
+	}
+	if !ok {
+		return nil, false
+	}
+	r.rinshan = kind == drawRinshan
+	r.seats[seat].tiles = append(r.seats[seat].tiles, tile)
+	r.emit(Event{Type: Event_Draw, Seat: seat, Tile: tile})
+	return &tile, true
+}
+
+// Plays the round to completion and returns its result
+func (r *Round) Play() RoundResult {
+	r.deal()
+	r.emit(Event{Type: Event_StartRound, Seat: r.Setup.Dealer})
+	r.emit(Event{Type: Event_NewDora, Tile: r.wall.DoraIndicators()[0]})
+	seat, kind := r.Setup.Dealer, drawLive
+	var forbidden map[int]bool
+	for {
+		drawn, ok := r.drawFor(seat, kind)
+		if !ok {
+			return r.finish(r.exhaustiveDraw())
+		}
+
+		options := r.turnOptions(seat, drawn, forbidden)
+		action := matchOption(options, r.agents[seat].ChooseAction(r.view(seat, drawn), options))
+		forbidden = nil
+		switch action.Type {
+		case Action_Tsumo:
+			score, _ := r.scoreWin(seat, action.Tile, true)
+			result := r.settleTsumo(seat, action.Tile, score)
+			r.emit(Event{Type: Event_Win, Seat: seat, From: seat, Tile: action.Tile, Score: score})
+			return r.finish(result)
+		case Action_Ankan, Action_Shouminkan:
+			if result, robbed := r.declareKan(seat, action); robbed {
+				return r.finish(result)
+			}
+			kind = drawRinshan
+			continue
+		case Action_KyuushuKyuuhai:
+			return r.finish(r.abort(Abortive_KyuushuKyuuhai))
+		case Action_Kita:
+			r.declareKita(seat, action)
+			kind = drawKita
+			continue
+		}
+		riichi := action.Type == Action_Riichi
+		if riichi {
+			r.declareRiichi(seat)
+		}
+		r.discard(seat, action.Tile, drawn != nil && action.Tile == *drawn, riichi)
+
+		rons, caller, call := r.offerCalls(seat, action.Tile)
+		if len(rons) == 3 && r.Rules.Sanchahou {
+			return r.finish(r.abort(Abortive_Sanchahou))
+		}
+		if len(rons) > 0 {
+			result := r.settleRon(seat, action.Tile, rons)
+			for _, win := range result.Wins {
+				r.emit(Event{Type: Event_Win, Seat: win.Seat, From: seat, Tile: action.Tile, Score: win.Score})
+			}
+			return r.finish(result)
+		}
+		if riichi {
+			r.payRiichiDeposit(seat)
+		}
+		if abortive := r.abortAfterDiscard(); abortive != Abortive_None {
+			return r.finish(r.abort(abortive))
+		}
+		switch {
+		case caller < 0:
+			seat, kind = (seat+1)%r.players(), drawLive
+		case call.Type == Action_Daiminkan:
+			r.applyCall(caller, seat, call)
+			r.kanDora(false)
+			seat, kind = caller, drawRinshan
+		default:
+			r.applyCall(caller, seat, call)
+			forbidden = kuikae(call)
+			seat, kind = caller, drawNone
+		}
+	}
+}
@@ -0,0 +1,94 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+func testSetup(seed uint64) RoundSetup {
+	return RoundSetup{Scores: []int{25000, 25000, 25000, 25000}, Seed: seed}
+}
+
+func greedyAgents() []Agent {
+	return []Agent{NewGreedyAgent(), NewGreedyAgent(), NewGreedyAgent(), NewGreedyAgent()}
+}
+
+func TestRound_Deal(t *testing.T) {
+	r := NewRound(DefaultRules(), testSetup(1), greedyAgents())
+	r.deal()
+	for seat, s := range r.seats {
+		if len(s.tiles) != 13 {
+			t.Errorf("seat %d dealt %d tiles, want 13", seat, len(s.tiles))
+		}
+	}
+	if r.wall.Remaining() != 122-52 {
+		t.Errorf("wall remaining after deal = %v, want 70", r.wall.Remaining())
+	}
+}
+
+func TestRound_PlayDeterministic(t *testing.T) {
+	for seed := uint64(0); seed < 5; seed++ {
+		a := NewRound(DefaultRules(), testSetup(seed), greedyAgents())
+		b := NewRound(DefaultRules(), testSetup(seed), greedyAgents())
+		ra, rb := a.Play(), b.Play()
+		if !reflect.DeepEqual(ra, rb) || !reflect.DeepEqual(a.Events, b.Events) {
+			t.Errorf("seed %d: rounds with the same seed differ", seed)
+		}
+		sum := 0
+		for _, d := range ra.Deltas {
+			sum += d
+		}
+		// Riichi deposits left on the table are the only points that leave the players
+		if sum != -riichiDeposit*(ra.RiichiSticks-a.Setup.RiichiSticks) {
+			t.Errorf("seed %d: deltas %v do not balance with %d sticks left", seed, ra.Deltas, ra.RiichiSticks)
+		}
+		if !ra.Exhaustive && ra.Abortive == Abortive_None && len(ra.Wins) == 0 {
+			t.Errorf("seed %d: round ended without a win or draw", seed)
+		}
+	}
+}
+
+func TestRound_RandomAgentsTerminate(t *testing.T) {
+	agents := []Agent{NewRandomAgent(1), NewRandomAgent(2), NewRandomAgent(3), NewRandomAgent(4)}
+	r := NewRound(DefaultRules(), testSetup(7), agents)
+	result := r.Play()
+	if len(result.Scores) != 4 {
+		t.Fatalf("Play() scores = %v", result.Scores)
+	}
+	draws := 0
+	for _, ev := range r.Events {
+		if ev.Type == Event_Draw {
+			draws++
+		}
+	}
+	if result.Exhaustive && draws != 70 {
+		t.Errorf("exhaustive round had %v draws, want 70", draws)
+	}
+}
+
+func TestRound_SettleTsumo(t *testing.T) {
+	setup := testSetup(1)
+	setup.Honba = 2
+	setup.RiichiSticks = 1
+	r := NewRound(DefaultRules(), setup, greedyAgents())
+
+	score := ScoreFromHanFu(3, 30, false, true) // 1000/2000
+	result := r.settleTsumo(2, ParseTile(0, false), score)
+	want := []int{-2200, -1200, 5600, -1200}
+	if !reflect.DeepEqual(result.Deltas, want) {
+		t.Errorf("settleTsumo() deltas = %v, want %v", result.Deltas, want)
+	}
+}
+
+func TestMatchOption(t *testing.T) {
+	options := []Action{
+		{Type: Action_Discard, Tile: ParseTile(3, false)},
+		{Type: Action_Discard, Tile: ParseTile(4, true)},
+	}
+	if got := matchOption(options, Action{Type: Action_Discard, Tile: ParseTile(4, true)}); !reflect.DeepEqual(got, options[1]) {
+		t.Errorf("matchOption() = %v, want the red five", got)
+	}
+	if got := matchOption(options, Action{Type: Action_Tsumo}); got.Tile.ID != 3 {
+		t.Errorf("matchOption() for an illegal choice = %v, want the first option", got)
+	}
+}
@@ -0,0 +1,172 @@
+package main
+
+// Closed kans, added kans, chankan and kan dora timing
+
+// Reports whether a closed kan of a tile keeps a riichi hand's waits and shape:
+// the kan tile must be the drawn tile, the waits must not change, and the tile
+// must form a triplet in every winning decomposition
+func (r *Round) riichiAnkanAllowed(seat int, drawn Tile) bool {
+	s := r.seats[seat]
+	before := s.hand()
+	before.counts[drawn.ID]--
+	waits := Waits(before, len(s.melds))
+	after := before
+	after.counts[drawn.ID] -= 3
+	if !equalInts(waits, Waits(after, len(s.melds)+1)) {
+		return false
+	}
+	for _, w := range waits {
+		hand := before
+		hand.counts[w]++
+		for _, dcmp := range AllDecompositions(hand.counts[:]) {
+			triplet := false
+			for _, set := range dcmp[1:] {
+				if set.Type == Koutsu && set.Tiles[0].ID == drawn.ID {
+					triplet = true
+				}
+			}
+			if !triplet {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// Reports whether two int slices hold the same values in order
+func equalInts(a, b []int) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// Lists the closed and added kans a seat may declare after drawing
+func (r *Round) kanOptions(seat int, drawn Tile) []Action {
+	s := r.seats[seat]
+	if r.wall.Remaining() == 0 || r.wall.Kans() >= 4 {
+		return nil
+	}
+	var options []Action
+	hand := s.hand()
+	for id, count := range hand.counts {
+		if count < 4 {
+			continue
+		}
+		if s.riichi && (id != drawn.ID || !r.riichiAnkanAllowed(seat, drawn)) {
+			continue
+		}
+		tiles := pickTiles(s.tiles, id, id, id, id)[0]
+		options = append(options, Action{Type: Action_Ankan, Tile: tiles[0], Tiles: tiles})
+	}
+	if s.riichi {
+		return options
+	}
+	for _, m := range s.melds {
+		if m.Type != Koutsu || !m.Open {
+			continue
+		}
+		for _, t := range s.tiles {
+			if t.ID == m.Tiles[0].ID {
+				options = append(options, Action{Type: Action_Shouminkan, Tile: t})
+				break
+			}
+		}
+	}
+	return options
+}
+
+// Reveals a kan dora now, or after the next discard, per the rules for the kan type
+func (r *Round) kanDora(closed bool) {
+	r.revealPendingDora()
+	r.pendingDora++
+	if closed || !r.Rules.OpenKanDoraAfterDiscard {
+		r.revealPendingDora()
+	}
+}
+
+// Offers the tile of a kan to the other seats for chankan; for a closed kan only
+// Kokushi Musou may rob it, and only when the rules allow it. Returns the winning seats.
+func (r *Round) offerChankan(seat int, tile Tile, closed bool) []int {
+	if closed && !r.Rules.KokushiAnkanChankan {
+		return nil
+	}
+	n := r.players()
+	var rons []int
+	r.chankan = true
+	defer func() { r.chankan = false }()
+	for i := 1; i < n; i++ {
+		other := (seat + i) % n
+		if r.furiten(other) {
+			continue
+		}
+		if _, ok := r.scoreWin(other, tile, false); !ok {
+			continue
+		}
+		if closed {
+			hand := r.seats[other].hand()
+			hand.counts[tile.ID]++
+			if _, kokushi := (Yaku_KokushiMusou{}).Check(hand, nil, WinContext{}); !kokushi {
+				continue
+			}
+		}
+		options := []Action{{Type: Action_Ron, Tile: tile}, {Type: Action_Pass}}
+		choice := matchOption(options, r.agents[other].ChooseCall(r.view(other, nil), options))
+		if choice.Type == Action_Ron {
+			rons = append(rons, other)
+		} else {
+			r.seats[other].missWin()
+		}
+	}
+	return r.trimRons(rons)
+}
+
+// Declares a closed or added kan. Returns the result if the kan was robbed (chankan), or
+// aborted by three robbing seats; otherwise the seat goes on to draw a rinshan tile.
+func (r *Round) declareKan(seat int, action Action) (RoundResult, bool) {
+	s := r.seats[seat]
+	closed := action.Type == Action_Ankan
+	if rons := r.offerChankan(seat, action.Tile, closed); len(rons) > 0 {
+		if len(rons) == 3 && r.Rules.Sanchahou {
+			return r.abort(Abortive_Sanchahou), true
+		}
+		r.chankan = true
+		result := r.settleRon(seat, action.Tile, rons)
+		r.chankan = false
+		for _, win := range result.Wins {
+			r.emit(Event{Type: Event_Win, Seat: win.Seat, From: seat, Tile: action.Tile, Score: win.Score})
+		}
+		return result, true
+	}
+	var set Set
+	if closed {
+		for _, t := range action.Tiles {
+			s.remove(t)
+		}
+		set = Set{Type: Kantsu, Tiles: append([]Tile{}, action.Tiles...), Target: seat}
+		s.melds = append(s.melds, set)
+	} else {
+		s.remove(action.Tile)
+		for i, m := range s.melds {
+			if m.Type == Koutsu && m.Tiles[0].ID == action.Tile.ID {
+				m.Type = Kantsu
+				m.Tiles = append(append([]Tile{}, m.Tiles...), action.Tile)
+				sortTiles(m.Tiles)
+				s.melds[i] = m
+				set = m
+			}
+		}
+		// The pon was checked for dragon and wind liability when called; the added kan can
+		// only complete Suukantsu
+		r.checkKanPao(seat, set)
+	}
+	r.interrupt()
+	r.emit(Event{Type: Event_Call, Seat: seat, Tile: action.Tile, Set: set, Call: action.Type})
+	r.kanDora(closed)
+	return RoundResult{}, false
+}
@@ -0,0 +1,155 @@
+package main
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestRound_KanOptions(t *testing.T) {
+	r := scriptedRound(DefaultRules(), []ActionType{Action_Pass, Action_Pass, Action_Pass, Action_Pass},
+		[]int{5, 5, 5, 9, 10, 11, 18, 19, 20, 30, 31, 32, 5})
+	r.seats[0].melds = []Set{{Type: Koutsu, Tiles: tilesOf(27, 27, 27), Open: true, Target: 3}}
+	r.seats[0].tiles = append(r.seats[0].tiles, ParseTile(27, false))
+
+	options := r.kanOptions(0, ParseTile(5, false))
+	if _, ok := findAction(options, Action_Ankan, 5); !ok {
+		t.Errorf("kanOptions() = %v, want ankan of 6m", options)
+	}
+	if _, ok := findAction(options, Action_Shouminkan, 27); !ok {
+		t.Errorf("kanOptions() = %v, want shouminkan of East", options)
+	}
+}
+
+func TestRound_RiichiAnkanAllowed(t *testing.T) {
+	tests := []struct {
+		name  string
+		tiles []int
+		drawn int
+		want  bool
+	}{
+		{"Triplet outside the wait", []int{0, 0, 0, 9, 10, 11, 18, 19, 20, 3, 4, 30, 30}, 0, true},
+		{"Kan changes the waits", []int{0, 0, 0, 1, 2, 9, 10, 11, 18, 19, 20, 30, 30}, 0, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := scriptedRound(DefaultRules(), []ActionType{Action_Pass, Action_Pass, Action_Pass, Action_Pass},
+				append(tt.tiles, tt.drawn))
+			r.seats[0].riichi = true
+			if got := r.riichiAnkanAllowed(0, ParseTile(tt.drawn, false)); got != tt.want {
+				t.Errorf("riichiAnkanAllowed() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRound_KanDora(t *testing.T) {
+	tests := []struct {
+		name        string
+		afterRule   bool
+		closed      bool
+		wantShown   int
+		wantPending int
+	}{
+		{"Closed kan reveals immediately", true, true, 2, 0},
+		{"Open kan waits for the discard", true, false, 1, 1},
+		{"Open kan reveals immediately when configured", false, false, 2, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rules := DefaultRules()
+			rules.OpenKanDoraAfterDiscard = tt.afterRule
+			r := NewRound(rules, testSetup(1), greedyAgents())
+			r.kanDora(tt.closed)
+			if got := len(r.wall.DoraIndicators()); got != tt.wantShown || r.pendingDora != tt.wantPending {
+				t.Errorf("kanDora() shown %v pending %v, want %v and %v", got, r.pendingDora, tt.wantShown, tt.wantPending)
+			}
+		})
+	}
+}
+
+func TestRound_Chankan(t *testing.T) {
+	ronHand := []int{3, 4, 9, 10, 11, 18, 19, 20, 21, 22, 23, 30, 30}  // waits on 3m/6m
+	kokushi := []int{8, 9, 17, 18, 26, 27, 28, 29, 30, 31, 32, 33, 33} // waits on 1m
+	pass := []ActionType{Action_Ron, Action_Ron, Action_Ron, Action_Ron}
+
+	t.Run("Shouminkan can be robbed", func(t *testing.T) {
+		r := scriptedRound(DefaultRules(), pass, nil, ronHand)
+		if got := r.offerChankan(0, ParseTile(5, false), false); len(got) != 1 || got[0] != 1 {
+			t.Errorf("offerChankan() = %v, want [1]", got)
+		}
+		r.chankan = true
+		if score, ok := r.scoreWin(1, ParseTile(5, false), false); !ok || !slices.Contains(score.Yaku, "Chankan (Robbing a Kan)") {
+			t.Errorf("scoreWin() yaku = %v, want Chankan", score.Yaku)
+		}
+	})
+
+	t.Run("Ankan only robbed by kokushi", func(t *testing.T) {
+		r := scriptedRound(DefaultRules(), pass, nil, ronHand, kokushi)
+		if got := r.offerChankan(0, ParseTile(5, false), true); len(got) != 0 {
+			t.Errorf("offerChankan() ankan = %v, want none", got)
+		}
+		if got := r.offerChankan(0, ParseTile(0, false), true); len(got) != 1 || got[0] != 2 {
+			t.Errorf("offerChankan() kokushi = %v, want [2]", got)
+		}
+	})
+
+	t.Run("Triple chankan under atamahane", func(t *testing.T) {
+		for _, sanchahou := range []bool{false, true} {
+			rules := DefaultRules()
+			rules.MultipleRon, rules.Sanchahou = false, sanchahou
+			r := scriptedRound(rules, pass, []int{5, 9, 10, 11, 18, 19, 20, 30, 31, 32, 33}, ronHand, ronHand, ronHand)
+			r.seats[0].melds = []Set{{Type: Koutsu, Tiles: tilesOf(5, 5, 5), Open: true, Target: 1}}
+			result, robbed := r.declareKan(0, Action{Type: Action_Shouminkan, Tile: ParseTile(5, false)})
+			switch {
+			case !robbed:
+				t.Errorf("declareKan() Sanchahou = %v not robbed", sanchahou)
+			case sanchahou && result.Abortive != Abortive_Sanchahou:
+				t.Errorf("declareKan() Sanchahou = %v abortive = %v, want Sanchahou", sanchahou, result.Abortive)
+			case !sanchahou && (len(result.Wins) != 1 || result.Wins[0].Seat != 1):
+				t.Errorf("declareKan() Sanchahou = %v wins = %+v, want seat 1 alone", sanchahou, result.Wins)
+			}
+		}
+	})
+
+	t.Run("Kokushi ankan chankan disabled", func(t *testing.T) {
+		rules := DefaultRules()
+		rules.KokushiAnkanChankan = false
+		r := scriptedRound(rules, pass, nil, nil, kokushi)
+		if got := r.offerChankan(0, ParseTile(0, false), true); len(got) != 0 {
+			t.Errorf("offerChankan() = %v, want none", got)
+		}
+	})
+}
+
+func TestRound_DeclareKan(t *testing.T) {
+	r := scriptedRound(DefaultRules(), []ActionType{Action_Pass, Action_Pass, Action_Pass, Action_Pass},
+		[]int{5, 5, 5, 5, 9, 10, 11, 18, 19, 20, 30, 31, 32, 33})
+	options := r.kanOptions(0, ParseTile(5, false))
+	ankan, _ := findAction(options, Action_Ankan, 5)
+	if _, robbed := r.declareKan(0, ankan); robbed {
+		t.Fatal("declareKan() robbed, want no chankan")
+	}
+	s := r.seats[0]
+	if len(s.tiles) != 10 || len(s.melds) != 1 || s.melds[0].Type != Kantsu || s.melds[0].Open {
+		t.Errorf("declareKan() tiles %v melds %+v, want a closed kan and 10 tiles", len(s.tiles), s.melds)
+	}
+	if tile, ok := r.drawFor(0, drawRinshan); !ok || !r.rinshan || tile == nil {
+		t.Errorf("drawFor() rinshan = %v, %v, want a rinshan draw", tile, ok)
+	}
+}
+
+func TestRound_PlayWithKans(t *testing.T) {
+	for seed := uint64(1); seed <= 20; seed++ {
+		r := NewRound(DefaultRules(), testSetup(seed), []Agent{
+			&scriptAgent{prefer: Action_Ankan}, &scriptAgent{prefer: Action_Daiminkan},
+			&scriptAgent{prefer: Action_Shouminkan}, &scriptAgent{prefer: Action_Pon},
+		})
+		result := r.Play()
+		if len(result.Wins) == 0 && !result.Exhaustive && result.Abortive == Abortive_None {
+			t.Errorf("seed %d: round ended without a result", seed)
+		}
+		if r.wall.Kans() > 4 {
+			t.Errorf("seed %d: %d kans declared, want at most 4", seed, r.wall.Kans())
+		}
+	}
+}
@@ -1,5 +1,10 @@
 package main
 
+import (
+	"fmt"
+	"strings"
+)
+
 // Basic Tile, Hand, and Set Definitions
 
 type Suit int
@@ -49,6 +54,84 @@ func ParseTile(id int, red bool) Tile {
 	}
 }
 
+// Writes a tile in MPSZ notation: 1m-9m, 1p-9p, 1s-9s, 1z-7z for the honors (E S W N Wh G R),
+// 0 for a red five and ? for a hidden tile
+func (t Tile) String() string {
+	if t.ID < 0 || t.ID > 33 {
+		return "?"
+	}
+	rank := t.Rank + 1
+	if t.Red {
+		rank = 0
+	}
+	return fmt.Sprintf("%d%c", rank, "mpsz"[t.Suit])
+}
+
+func (t Tile) MarshalText() ([]byte, error) {
+	return []byte(t.String()), nil
+}
+
+// Reads a tile in MPSZ notation; the hidden tile ? is refused, see recordTile
+func (t *Tile) UnmarshalText(text []byte) error {
+	s := string(text)
+	if len(s) != 2 || s[0] < '0' || s[0] > '9' {
+		return fmt.Errorf("invalid tile %q", s)
+	}
+	suit := strings.IndexByte("mpsz", s[1])
+	rank := int(s[0] - '0')
+	switch {
+	case suit < 0, suit == int(Honor) && (rank < 1 || rank > 7):
+		return fmt.Errorf("invalid tile %q", s)
+	case rank == 0:
+		*t = ParseTile(suit*9+4, true)
+	default:
+		*t = ParseTile(suit*9+rank-1, false)
+	}
+	return nil
+}
+
+// Parses tiles in MPSZ notation, where each run of digits takes the suit letter that
+// follows it: "123m0p55z" is 1m 2m 3m, a red 5p and two white dragons
+func ParseTiles(s string) ([]Tile, error) {
+	var tiles []Tile
+	ranks := ""
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if c >= '0' && c <= '9' {
+			ranks += string(c)
+			continue
+		}
+		if ranks == "" {
+			return nil, fmt.Errorf("invalid tiles %q: %q has no ranks", s, c)
+		}
+		for _, r := range []byte(ranks) {
+			var t Tile
+			if err := t.UnmarshalText([]byte{r, c}); err != nil {
+				return nil, fmt.Errorf("invalid tiles %q: %w", s, err)
+			}
+			tiles = append(tiles, t)
+		}
+		ranks = ""
+	}
+	if ranks != "" {
+		return nil, fmt.Errorf("invalid tiles %q: missing suit after %q", s, ranks)
+	}
+	return tiles, nil
+}
+
+// Writes tiles in compact MPSZ notation, grouping the ranks of consecutive tiles of a suit
+func FormatTiles(tiles []Tile) string {
+	var b strings.Builder
+	for i, t := range tiles {
+		s := t.String()
+		b.WriteByte(s[0])
+		if i+1 == len(tiles) || tiles[i+1].ID < 0 || t.ID < 0 || tiles[i+1].Suit != t.Suit {
+			b.WriteString(s[1:])
+		}
+	}
+	return b.String()
+}
+
 func (t Tile) IsTerminalOrHonor() bool {
 	if t.Suit == Honor {
 		return true
@@ -84,3 +84,42 @@ func TestTile_IsTerminalOrHonor(t *testing.T) {
 		})
 	}
 }
+
+func TestParseTiles(t *testing.T) {
+	tests := []struct {
+		in   string
+		want []Tile
+		text string
+	}{
+		{"123m", tilesOf(0, 1, 2), "123m"},
+		{"1m2m3m", tilesOf(0, 1, 2), "123m"},
+		{"0p55z", []Tile{ParseTile(13, true), ParseTile(31, false), ParseTile(31, false)}, "0p55z"},
+		{"19m19p19s1234567z", tilesOf(0, 8, 9, 17, 18, 26, 27, 28, 29, 30, 31, 32, 33), "19m19p19s1234567z"},
+		{"", nil, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.in, func(t *testing.T) {
+			got, err := ParseTiles(tt.in)
+			if err != nil {
+				t.Fatalf("ParseTiles() error = %v", err)
+			}
+			if len(got) != len(tt.want) {
+				t.Fatalf("ParseTiles() = %v, want %v", got, tt.want)
+			}
+			for i := range got {
+				if got[i] != tt.want[i] {
+					t.Errorf("ParseTiles()[%d] = %+v, want %+v", i, got[i], tt.want[i])
+				}
+			}
+			if text := FormatTiles(got); text != tt.text {
+				t.Errorf("FormatTiles() = %q, want %q", text, tt.text)
+			}
+		})
+	}
+
+	for _, in := range []string{"123", "m", "8z", "12x", "1m2"} {
+		if _, err := ParseTiles(in); err == nil {
+			t.Errorf("ParseTiles(%q) error = nil, want an error", in)
+		}
+	}
+}
@@ -1 +1,86 @@
 package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+)
+
+// Command-line entry point: riichi-mahjong <command> [flags] [arguments]
+
+// A subcommand of the command-line tool
+type command struct {
+	name    string
+	summary string
+	run     func(args []string, stdout, stderr io.Writer) error
+}
+
+var commands = []command{
+	{"score", "score a winning hand", runScore},
+	{"nanikiru", "rank the discards of a hand by efficiency", runNanikiru},
+	{"play", "play a match in the terminal against bots", runPlay},
+	{"simulate", "play a batch of matches between bots and compare them", runSimulate},
+	{"serve", "serve the scoring and analysis HTTP JSON API", runServe},
+}
+
+// Returned by a subcommand whose flag set has already reported the error
+var errUsage = errors.New("invalid usage")
+
+func main() {
+	os.Exit(run(os.Args[1:], os.Stdout, os.Stderr))
+}
+
+// Runs the subcommand named by the first argument and returns the exit status
+func run(args []string, stdout, stderr io.Writer) int {
+	if len(args) == 0 {
+		usage(stderr)
+		return 2
+	}
+	for _, c := range commands {
+		if c.name != args[0] {
+			continue
+		}
+		err := c.run(args[1:], stdout, stderr)
+		switch {
+		case err == nil, errors.Is(err, flag.ErrHelp):
+			return 0
+		case errors.Is(err, errUsage):
+			return 2
+		}
+		fmt.Fprintf(stderr, "%s: %v\n", c.name, err)
+		return 1
+	}
+	fmt.Fprintf(stderr, "unknown command %q\n", args[0])
+	usage(stderr)
+	return 2
+}
+
+// Lists the subcommands
+func usage(w io.Writer) {
+	fmt.Fprintln(w, "usage: riichi-mahjong <command> [flags] [arguments]")
+	fmt.Fprintln(w, "commands:")
+	for _, c := range commands {
+		fmt.Fprintf(w, "  %-10s %s\n", c.name, c.summary)
+	}
+}
+
+// Parses a subcommand's flags, which may come before or after its positional arguments,
+// and returns the positional arguments
+func parseFlags(fs *flag.FlagSet, args []string) ([]string, error) {
+	var positional []string
+	for {
+		if err := fs.Parse(args); err != nil {
+			if errors.Is(err, flag.ErrHelp) {
+				return nil, err
+			}
+			return nil, errUsage
+		}
+		if fs.NArg() == 0 {
+			return positional, nil
+		}
+		positional = append(positional, fs.Arg(0))
+		args = fs.Args()[1:]
+	}
+}
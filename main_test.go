@@ -0,0 +1,33 @@
+package main
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestRun(t *testing.T) {
+	tests := []struct {
+		name       string
+		args       []string
+		wantStatus int
+		wantErr    string
+	}{
+		{"no command", nil, 2, "commands:"},
+		{"unknown command", []string{"deal"}, 2, `unknown command "deal"`},
+		{"help", []string{"score", "-h"}, 0, "usage: riichi-mahjong score"},
+		{"bad flag", []string{"score", "-bogus"}, 2, "flag provided but not defined"},
+		{"command error", []string{"score", "123m"}, 1, "score: hand has 3 tiles"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var stdout, stderr bytes.Buffer
+			if got := run(tt.args, &stdout, &stderr); got != tt.wantStatus {
+				t.Errorf("run() = %d, want %d", got, tt.wantStatus)
+			}
+			if !strings.Contains(stderr.String(), tt.wantErr) {
+				t.Errorf("run() stderr = %q, want %q", stderr.String(), tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,173 @@
+package main
+
+import (
+	"math/rand/v2"
+	"sort"
+)
+
+// A full match: dealer rotation, round winds, honba, riichi sticks and the end of the game
+
+type MatchLength int
+
+const (
+	Length_Tonpuusen MatchLength = iota // East round only
+	Length_Hanchan                      // East and South rounds
+)
+
+// Number of round winds played before the match can end
+func (l MatchLength) winds() int {
+	if l == Length_Tonpuusen {
+		return 1
+	}
+	return 2
+}
+
+// Final position of a seat
+type Standing struct {
+	Seat  int
+	Score int
+	Place int // 1 for first place
+}
+
+type MatchResult struct {
+	Rounds    []RoundRecord
+	Scores    []int // final points per seat, including leftover riichi sticks
+	Standings []Standing
+	Placement []PlacementResult // placement points with uma and oka, in standing order
+}
+
+type Match struct {
+	Rules Rules
+	Seed  uint64 // seeds every round of the match
+
+	agents []Agent
+}
+
+// Creates a match between agents, seat 0 being the first dealer
+func NewMatch(rules Rules, seed uint64, agents []Agent) *Match {
+	return &Match{Rules: rules, Seed: seed, agents: agents}
+}
+
+// Builds the game record of a played match, naming players after their agents
+func (m *Match) Record(result MatchResult) GameRecord {
+	record := GameRecord{Rules: m.Rules, Seed: m.Seed, Rounds: result.Rounds}
+	for _, agent := range m.agents {
+		record.Players = append(record.Players, agent.Name())
+	}
+	return record
+}
+
+// Ranks seats by score; ties go to the seat closest to the first dealer
+func Standings(scores []int) []Standing {
+	standings := make([]Standing, len(scores))
+	for seat, score := range scores {
+		standings[seat] = Standing{Seat: seat, Score: score}
+	}
+	sort.SliceStable(standings, func(i, j int) bool {
+		return standings[i].Score > standings[j].Score
+	})
+	for i := range standings {
+		standings[i].Place = i + 1
+	}
+	return standings
+}
+
+// Reports whether any seat has busted
+func busted(scores []int) bool {
+	for _, score := range scores {
+		if score < 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// Reports whether any seat has reached the target
+func reachedTarget(scores []int, target int) bool {
+	for _, score := range scores {
+		if score >= target {
+			return true
+		}
+	}
+	return false
+}
+
+// Reports whether the dealer may end the match on the last hand instead of repeating:
+// the dealer must be first with at least the target, and have won (agari-yame) or
+// been tenpai at an exhaustive draw (tenpai-yame)
+func (m *Match) dealerStops(dealer int, result RoundResult) bool {
+	if Standings(result.Scores)[0].Seat != dealer || result.Scores[dealer] < m.Rules.TargetPoints {
+		return false
+	}
+	if len(result.Wins) > 0 {
+		return m.Rules.AgariYame
+	}
+	return result.Exhaustive && m.Rules.TenpaiYame && result.Tenpai[dealer]
+}
+
+// Position of a match between rounds
+type matchState struct {
+	wind   int // round wind, 0 East
+	dealer int
+	honba  int
+	sticks int
+	scores []int
+}
+
+// Moves the match past a round; returns true when the match is over
+func (m *Match) advance(st *matchState, result RoundResult) bool {
+	n, winds := len(st.scores), m.Rules.Length.winds()
+	st.scores, st.sticks, st.honba = result.Scores, result.RiichiSticks, result.NextHonba(st.honba)
+	if m.Rules.Tobi && busted(st.scores) {
+		return true
+	}
+	// The last hand of the regular match, or any hand of the extension
+	last := st.wind > winds-1 || (st.wind == winds-1 && st.dealer == n-1)
+	if result.DealerRepeat {
+		if last && m.dealerStops(st.dealer, result) {
+			return true
+		}
+		return st.wind >= winds && reachedTarget(st.scores, m.Rules.TargetPoints)
+	}
+	if st.dealer++; st.dealer == n {
+		st.wind, st.dealer = st.wind+1, 0
+	}
+	if st.wind < winds {
+		return false
+	}
+	return reachedTarget(st.scores, m.Rules.TargetPoints) || !m.Rules.WestExtension || st.wind > winds
+}
+
+// Plays the match to completion
+func (m *Match) Play() MatchResult {
+	rng := rand.New(rand.NewPCG(m.Seed, 1))
+	st := &matchState{scores: make([]int, len(m.agents))}
+	for seat := range st.scores {
+		st.scores[seat] = m.Rules.StartingPoints
+	}
+	var res MatchResult
+	for done := false; !done; {
+		setup := RoundSetup{
+			RoundWind:    st.wind,
+			Dealer:       st.dealer,
+			Honba:        st.honba,
+			RiichiSticks: st.sticks,
+			Scores:       append([]int{}, st.scores...),
+			Seed:         rng.Uint64(),
+		}
+		round := NewRound(m.Rules, setup, m.agents)
+		result := round.Play()
+		res.Rounds = append(res.Rounds, round.Record(result))
+		done = m.advance(st, result)
+	}
+
+	scores := append([]int{}, st.scores...)
+	res.Standings = Standings(scores)
+	if m.Rules.LeftoverSticksToFirst {
+		scores[res.Standings[0].Seat] += riichiDeposit * st.sticks
+		res.Standings[0].Score = scores[res.Standings[0].Seat]
+	}
+	res.Scores = scores
+	res.Placement = PlacementScores(scores, m.Rules)
+	return res
+}
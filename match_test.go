@@ -0,0 +1,137 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestStandings(t *testing.T) {
+	got := Standings([]int{25000, 31000, 25000, 19000})
+	want := []Standing{{1, 31000, 1}, {0, 25000, 2}, {2, 25000, 3}, {3, 19000, 4}}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Standings() = %v, want %v", got, want)
+	}
+}
+
+func TestMatch_Advance(t *testing.T) {
+	nonDealerWin := func(scores ...int) RoundResult {
+		return RoundResult{Wins: []Win{{Seat: 1}}, Scores: scores}
+	}
+	dealerWin := func(dealer int, scores ...int) RoundResult {
+		return RoundResult{Wins: []Win{{Seat: dealer}}, DealerRepeat: true, Scores: scores}
+	}
+	even := []int{25000, 25000, 25000, 25000}
+
+	tests := []struct {
+		name     string
+		rules    func(r *Rules)
+		state    matchState
+		result   RoundResult
+		want     matchState
+		wantDone bool
+	}{
+		{"Non-dealer win passes the deal and clears honba", nil,
+			matchState{wind: 0, dealer: 0, honba: 2, scores: even},
+			nonDealerWin(even...),
+			matchState{wind: 0, dealer: 1, honba: 0}, false},
+		{"Dealer win repeats with a honba", nil,
+			matchState{wind: 0, dealer: 2, honba: 0, scores: even},
+			dealerWin(2, even...),
+			matchState{wind: 0, dealer: 2, honba: 1}, false},
+		{"Draw with the dealer noten passes the deal and adds a honba", nil,
+			matchState{wind: 0, dealer: 3, honba: 1, scores: even},
+			RoundResult{Exhaustive: true, Tenpai: make([]bool, 4), Scores: even, RiichiSticks: 2},
+			matchState{wind: 1, dealer: 0, honba: 2, sticks: 2}, false},
+		{"Bust ends the match", nil,
+			matchState{scores: even},
+			nonDealerWin(-100, 50000, 25100, 25000),
+			matchState{dealer: 0}, true},
+		{"Bust disabled", func(r *Rules) { r.Tobi = false },
+			matchState{scores: even},
+			nonDealerWin(-100, 50000, 25100, 25000),
+			matchState{dealer: 1}, false},
+		{"South 4 ends with a winner over the target", nil,
+			matchState{wind: 1, dealer: 3, scores: even},
+			nonDealerWin(20000, 35000, 25000, 20000),
+			matchState{wind: 2, dealer: 0}, true},
+		{"South 4 goes into the west extension", nil,
+			matchState{wind: 1, dealer: 3, scores: even},
+			nonDealerWin(24000, 29000, 24000, 23000),
+			matchState{wind: 2, dealer: 0}, false},
+		{"No extension when disabled", func(r *Rules) { r.WestExtension = false },
+			matchState{wind: 1, dealer: 3, scores: even},
+			nonDealerWin(24000, 29000, 24000, 23000),
+			matchState{wind: 2, dealer: 0}, true},
+		{"Extension ends when someone reaches the target", nil,
+			matchState{wind: 2, dealer: 0, scores: even},
+			nonDealerWin(20000, 31000, 25000, 24000),
+			matchState{wind: 2, dealer: 1}, true},
+		{"Extension ends after the west round", nil,
+			matchState{wind: 2, dealer: 3, scores: even},
+			nonDealerWin(24000, 29000, 24000, 23000),
+			matchState{wind: 3, dealer: 0}, true},
+		{"Agari-yame by the leading dealer", nil,
+			matchState{wind: 1, dealer: 3, scores: even},
+			dealerWin(3, 20000, 25000, 20000, 35000),
+			matchState{wind: 1, dealer: 3, honba: 1}, true},
+		{"Dealer win while not leading continues", nil,
+			matchState{wind: 1, dealer: 3, scores: even},
+			dealerWin(3, 20000, 32000, 20000, 28000),
+			matchState{wind: 1, dealer: 3, honba: 1}, false},
+		{"Agari-yame disabled", func(r *Rules) { r.AgariYame = false },
+			matchState{wind: 1, dealer: 3, scores: even},
+			dealerWin(3, 20000, 25000, 20000, 35000),
+			matchState{wind: 1, dealer: 3, honba: 1}, false},
+		{"Tenpai-yame by the leading dealer", func(r *Rules) { r.TenpaiYame = true },
+			matchState{wind: 1, dealer: 3, scores: even},
+			RoundResult{Exhaustive: true, Tenpai: []bool{false, false, false, true}, DealerRepeat: true, Scores: []int{24000, 24000, 20000, 32000}},
+			matchState{wind: 1, dealer: 3, honba: 1}, true},
+		{"Tonpuusen ends after East 4", func(r *Rules) { r.Length = Length_Tonpuusen },
+			matchState{wind: 0, dealer: 3, scores: even},
+			nonDealerWin(20000, 35000, 25000, 20000),
+			matchState{wind: 1, dealer: 0}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rules := DefaultRules()
+			if tt.rules != nil {
+				tt.rules(&rules)
+			}
+			m := NewMatch(rules, 1, greedyAgents())
+			st := tt.state
+			done := m.advance(&st, tt.result)
+			if done != tt.wantDone {
+				t.Errorf("advance() done = %v, want %v", done, tt.wantDone)
+			}
+			if st.wind != tt.want.wind || st.dealer != tt.want.dealer || st.honba != tt.want.honba || st.sticks != tt.want.sticks {
+				t.Errorf("advance() state = %+v, want %+v", st, tt.want)
+			}
+		})
+	}
+}
+
+func TestMatch_Play(t *testing.T) {
+	rules := DefaultRules()
+	rules.Length = Length_Tonpuusen
+	for seed := uint64(0); seed < 3; seed++ {
+		a := NewMatch(rules, seed, greedyAgents()).Play()
+		b := NewMatch(rules, seed, greedyAgents()).Play()
+		if !reflect.DeepEqual(a.Scores, b.Scores) || len(a.Rounds) != len(b.Rounds) {
+			t.Errorf("seed %d: matches with the same seed differ", seed)
+		}
+		total := 0
+		for _, score := range a.Scores {
+			total += score
+		}
+		last := a.Rounds[len(a.Rounds)-1].Result
+		if total != 4*rules.StartingPoints {
+			t.Errorf("seed %d: final scores %v sum to %v with %d sticks left", seed, a.Scores, total, last.RiichiSticks)
+		}
+		if len(a.Rounds) < 4 && !busted(last.Scores) {
+			t.Errorf("seed %d: match ended after %d rounds", seed, len(a.Rounds))
+		}
+		if a.Standings[0].Score < a.Standings[3].Score {
+			t.Errorf("seed %d: standings out of order %v", seed, a.Standings)
+		}
+	}
+}
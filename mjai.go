@@ -0,0 +1,525 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"strings"
+)
+
+// Adapter connecting an Agent to an mjai server. The server sends one JSON message per line
+// and the client answers every message with one line, "none" when it has nothing to do.
+// The client mirrors the table into a shadow round, so the agent is offered exactly the
+// actions the engine itself would offer.
+
+// A message of the mjai protocol; fields not used by a message type are left empty
+type mjaiMessage struct {
+	Type       string     `json:"type"`
+	Actor      int        `json:"actor"`
+	Target     int        `json:"target"`
+	Pai        string     `json:"pai"`
+	Consumed   []string   `json:"consumed"`
+	Tsumogiri  bool       `json:"tsumogiri"`
+	ID         int        `json:"id"`
+	Names      []string   `json:"names"`
+	Bakaze     string     `json:"bakaze"`
+	Kyoku      int        `json:"kyoku"`
+	Honba      int        `json:"honba"`
+	Kyotaku    int        `json:"kyotaku"`
+	Oya        int        `json:"oya"`
+	DoraMarker string     `json:"dora_marker"`
+	Tehais     [][]string `json:"tehais"`
+	Scores     []int      `json:"scores"`
+	Reason     string     `json:"reason"`
+	Message    string     `json:"message"`
+}
+
+// Mjai names of the honor tiles, in tile ID order from East
+var mjaiHonors = [...]string{"E", "S", "W", "N", "P", "F", "C"}
+
+// Mjai reasons for abortive draws
+var mjaiAbortive = map[string]AbortiveDraw{
+	"kyushukyuhai": Abortive_KyuushuKyuuhai,
+	"sufonrenda":   Abortive_SuufonRenda,
+	"suchareach":   Abortive_SuuchaRiichi,
+	"sukaikan":     Abortive_Suukaikan,
+	"sanchaho":     Abortive_Sanchahou,
+}
+
+// Converts a tile to its mjai name: 1m-9m, 1p-9p, 1s-9s, 5mr for a red five, E S W N P F C for honors
+func MjaiTile(t Tile) string {
+	if t.Suit == Honor {
+		return mjaiHonors[t.Rank]
+	}
+	name := fmt.Sprintf("%d%c", t.Rank+1, "mps"[t.Suit])
+	if t.Red {
+		name += "r"
+	}
+	return name
+}
+
+// Parses an mjai tile name
+func ParseMjaiTile(s string) (Tile, error) {
+	for rank, name := range mjaiHonors {
+		if s == name {
+			return ParseTile(27+rank, false), nil
+		}
+	}
+	red := strings.HasSuffix(s, "r")
+	name := strings.TrimSuffix(s, "r")
+	if len(name) != 2 || name[0] < '1' || name[0] > '9' {
+		return Tile{}, fmt.Errorf("invalid tile %q", s)
+	}
+	suit := strings.IndexByte("mps", name[1])
+	if suit < 0 || red && name[0] != '5' {
+		return Tile{}, fmt.Errorf("invalid tile %q", s)
+	}
+	return ParseTile(suit*9+int(name[0]-'1'), red), nil
+}
+
+// Parses a list of mjai tile names
+func parseMjaiTiles(names []string) ([]Tile, error) {
+	var tiles []Tile
+	for _, name := range names {
+		t, err := ParseMjaiTile(name)
+		if err != nil {
+			return nil, err
+		}
+		tiles = append(tiles, t)
+	}
+	return tiles, nil
+}
+
+// Converts tiles to mjai names
+func mjaiTiles(tiles []Tile) []string {
+	names := []string{}
+	for _, t := range tiles {
+		names = append(names, MjaiTile(t))
+	}
+	return names
+}
+
+// Plays one seat on an mjai server
+type MjaiClient struct {
+	Agent Agent
+	Rules Rules
+	Room  string // room to join, "default" if empty
+
+	seat      int
+	round     *Round
+	drawn     *Tile
+	declaring []bool   // seats whose next discard declares riichi
+	riichi    Action   // the agent's riichi, discarded once the server accepts the declaration
+	next      drawKind // where the next draw comes from
+}
+
+// Creates a client playing the agent under the given rules
+func NewMjaiClient(agent Agent, rules Rules) *MjaiClient {
+	return &MjaiClient{Agent: agent, Rules: rules}
+}
+
+// Connects to an mjai server over TCP and plays until the game ends
+func DialMjai(addr string, client *MjaiClient) error {
+	conn, err := net.Dial("tcp", addr)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+	return client.Run(conn, conn)
+}
+
+// Reads server messages and writes the answers until end_game or the end of the input;
+// stdin and stdout serve as well as a network connection
+func (c *MjaiClient) Run(r io.Reader, w io.Writer) error {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	enc := json.NewEncoder(w)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+		var msg mjaiMessage
+		if err := json.Unmarshal([]byte(line), &msg); err != nil {
+			return fmt.Errorf("mjai: %w", err)
+		}
+		reply, err := c.handle(msg)
+		if err != nil {
+			return fmt.Errorf("mjai %s: %w", msg.Type, err)
+		}
+		if err := enc.Encode(reply); err != nil {
+			return err
+		}
+		if msg.Type == "end_game" {
+			return nil
+		}
+	}
+	return scanner.Err()
+}
+
+// Answer with nothing to do
+func mjaiNone() map[string]any {
+	return map[string]any{"type": "none"}
+}
+
+// Handles one server message and returns the answer
+func (c *MjaiClient) handle(msg mjaiMessage) (map[string]any, error) {
+	// Seats index the shadow round; messages leave the fields they do not use at 0
+	for _, seat := range []int{msg.Actor, msg.Target, msg.ID, msg.Oya} {
+		if seat < 0 || seat >= c.Rules.Players {
+			return nil, fmt.Errorf("invalid seat %d", seat)
+		}
+	}
+	switch msg.Type {
+	case "error":
+		return nil, fmt.Errorf("server error: %s", msg.Message)
+	case "hello":
+		room := c.Room
+		if room == "" {
+			room = "default"
+		}
+		return map[string]any{"type": "join", "name": c.Agent.Name(), "room": room}, nil
+	case "start_game":
+		c.seat = msg.ID
+		if len(msg.Names) == 3 && c.Rules.Players != 3 {
+			c.Rules = SanmaRules()
+		}
+		return mjaiNone(), nil
+	case "start_kyoku":
+		return mjaiNone(), c.startRound(msg)
+	case "end_kyoku":
+		c.round = nil
+		return mjaiNone(), nil
+	case "end_game":
+		return mjaiNone(), nil
+	}
+	if c.round == nil {
+		return nil, fmt.Errorf("message outside a round")
+	}
+
+	switch msg.Type {
+	case "tsumo":
+		return c.draw(msg)
+	case "dahai":
+		return c.discard(msg)
+	case "reach":
+		return c.declareRiichi(msg)
+	case "reach_accepted":
+		c.round.payRiichiDeposit(msg.Actor)
+		c.updateScores(msg.Scores)
+	case "chi", "pon", "daiminkan":
+		return c.call(msg)
+	case "ankan", "kakan":
+		return c.kan(msg)
+	case "nukidora":
+		t, err := ParseMjaiTile(msg.Pai)
+		if err != nil {
+			return nil, err
+		}
+		c.round.declareKita(msg.Actor, Action{Type: Action_Kita, Tile: t})
+		c.next = drawKita
+	case "dora":
+		t, err := ParseMjaiTile(msg.DoraMarker)
+		if err != nil {
+			return nil, err
+		}
+		if c.round.wall.showIndicator(t) {
+			c.round.emit(Event{Type: Event_NewDora, Tile: t})
+		}
+	case "hora":
+		t, err := ParseMjaiTile(msg.Pai)
+		if err != nil {
+			return nil, err
+		}
+		c.round.emit(Event{Type: Event_Win, Seat: msg.Actor, From: msg.Target, Tile: t})
+		c.updateScores(msg.Scores)
+	case "ryukyoku":
+		if kind, ok := mjaiAbortive[msg.Reason]; ok {
+			c.round.emit(Event{Type: Event_AbortiveDraw, Abortive: kind})
+		} else {
+			c.round.emit(Event{Type: Event_ExhaustiveDraw})
+		}
+		c.updateScores(msg.Scores)
+	}
+	return mjaiNone(), nil
+}
+
+// Takes the scores the server sends with a result or riichi deposit
+func (c *MjaiClient) updateScores(scores []int) {
+	if len(scores) >= c.round.players() {
+		copy(c.round.scores, scores)
+	}
+}
+
+// Stands in for the seats of a shadow round, which never asks it to decide
+type shadowAgent struct{}
+
+func (a shadowAgent) Name() string                                           { return "Shadow" }
+func (a shadowAgent) OnEvent(view *PlayerView, ev Event)                     {}
+func (a shadowAgent) ChooseAction(view *PlayerView, options []Action) Action { return options[0] }
+func (a shadowAgent) ChooseCall(view *PlayerView, options []Action) Action {
+	return options[len(options)-1]
+}
+
+// Creates a shadow round, rebuilt from observed events rather than played: the wall is unseen,
+// the hands are empty and every seat is a shadowAgent until the caller fills them in
+func newShadowRound(rules Rules, setup RoundSetup) *Round {
+	agents := make([]Agent, rules.Players)
+	for seat := range agents {
+		agents[seat] = shadowAgent{}
+	}
+	r := NewRound(rules, setup, agents)
+	r.wall = unseenWall(rules)
+	return r
+}
+
+// Sets up the shadow round: the agent's hand, an unseen wall of the right size and the first dora indicator
+func (c *MjaiClient) startRound(msg mjaiMessage) error {
+	n := c.Rules.Players
+	if len(msg.Tehais) < n || c.seat >= n {
+		return fmt.Errorf("expected %d hands", n)
+	}
+	wind := strings.Index("ESWN", msg.Bakaze)
+	if wind < 0 || len(msg.Bakaze) != 1 {
+		return fmt.Errorf("invalid round wind %q", msg.Bakaze)
+	}
+	setup := RoundSetup{RoundWind: wind, Dealer: msg.Oya, Honba: msg.Honba, RiichiSticks: msg.Kyotaku}
+	setup.Scores = make([]int, n)
+	for seat := range setup.Scores {
+		setup.Scores[seat] = c.Rules.StartingPoints
+	}
+	if len(msg.Scores) >= n {
+		copy(setup.Scores, msg.Scores)
+	}
+	hand, err := parseMjaiTiles(msg.Tehais[c.seat])
+	if err != nil {
+		return err
+	}
+	dora, err := ParseMjaiTile(msg.DoraMarker)
+	if err != nil {
+		return err
+	}
+	r := newShadowRound(c.Rules, setup)
+	r.agents[c.seat] = c.Agent
+	r.wall.showIndicator(dora)
+	sortTiles(hand)
+	r.seats[c.seat].tiles = hand
+	c.round, c.drawn, c.next = r, nil, drawLive
+	c.declaring = make([]bool, n)
+	r.emit(Event{Type: Event_StartRound, Seat: setup.Dealer})
+	r.emit(Event{Type: Event_NewDora, Tile: dora})
+	return nil
+}
+
+// Handles a draw; on the agent's own draw it asks for the turn's action
+func (c *MjaiClient) draw(msg mjaiMessage) (map[string]any, error) {
+	r := c.round
+	switch c.next {
+	case drawRinshan:
+		r.wall.DrawRinshan()
+	case drawKita:
+		r.wall.DrawKita()
+	default:
+		r.wall.Draw()
+	}
+	c.next = drawLive
+	if msg.Actor != c.seat {
+		r.emit(Event{Type: Event_Draw, Seat: msg.Actor, Tile: Tile{ID: -1}})
+		return mjaiNone(), nil
+	}
+	t, err := ParseMjaiTile(msg.Pai)
+	if err != nil {
+		return nil, err
+	}
+	s := r.seats[c.seat]
+	s.tiles = append(s.tiles, t)
+	c.drawn = &t
+	r.emit(Event{Type: Event_Draw, Seat: c.seat, Tile: t})
+	return c.turn(nil), nil
+}
+
+// Asks the agent for its action on its turn and translates it into an answer
+func (c *MjaiClient) turn(forbidden map[int]bool) map[string]any {
+	r := c.round
+	options := r.turnOptions(c.seat, c.drawn, forbidden)
+	action := matchOption(options, c.Agent.ChooseAction(r.view(c.seat, c.drawn), options))
+	reply := map[string]any{"actor": c.seat}
+	switch action.Type {
+	case Action_Discard:
+		reply["type"] = "dahai"
+		reply["pai"] = MjaiTile(action.Tile)
+		reply["tsumogiri"] = c.drawn != nil && *c.drawn == action.Tile
+	case Action_Riichi:
+		reply["type"] = "reach"
+		c.riichi = action
+	case Action_Tsumo:
+		reply["type"] = "hora"
+		reply["target"] = c.seat
+		reply["pai"] = MjaiTile(action.Tile)
+	case Action_Ankan:
+		reply["type"] = "ankan"
+		reply["consumed"] = mjaiTiles(action.Tiles)
+	case Action_Shouminkan:
+		reply["type"] = "kakan"
+		reply["pai"] = MjaiTile(action.Tile)
+		for _, m := range r.seats[c.seat].melds {
+			if m.Type == Koutsu && m.Tiles[0].ID == action.Tile.ID {
+				reply["consumed"] = mjaiTiles(m.Tiles)
+			}
+		}
+	case Action_KyuushuKyuuhai:
+		reply["type"] = "ryukyoku"
+		reply["reason"] = "kyushukyuhai"
+	case Action_Kita:
+		reply["type"] = "nukidora"
+		reply["pai"] = MjaiTile(action.Tile)
+	}
+	return reply
+}
+
+// Handles a discard; on another seat's discard it offers the agent its calls
+func (c *MjaiClient) discard(msg mjaiMessage) (map[string]any, error) {
+	t, err := ParseMjaiTile(msg.Pai)
+	if err != nil {
+		return nil, err
+	}
+	r := c.round
+	r.discard(msg.Actor, t, msg.Tsumogiri, c.declaring[msg.Actor])
+	c.declaring[msg.Actor] = false
+	if msg.Actor == c.seat {
+		c.drawn = nil
+		return mjaiNone(), nil
+	}
+	options := r.callOptions(c.seat, msg.Actor, t)
+	for _, id := range Waits(r.seats[c.seat].hand(), len(r.seats[c.seat].melds)) {
+		if id == t.ID {
+			// Passing a winning tile, or being unable to ron it, is furiten until the next discard,
+			// or for the rest of the round in riichi
+			r.seats[c.seat].missWin()
+		}
+	}
+	if options == nil {
+		return mjaiNone(), nil
+	}
+	choice := matchOption(options, c.Agent.ChooseCall(r.view(c.seat, nil), options))
+	reply := map[string]any{"actor": c.seat, "target": msg.Actor, "pai": msg.Pai}
+	switch choice.Type {
+	case Action_Ron:
+		reply["type"] = "hora"
+	case Action_Chi:
+		reply["type"] = "chi"
+	case Action_Pon:
+		reply["type"] = "pon"
+	case Action_Daiminkan:
+		reply["type"] = "daiminkan"
+	default:
+		return mjaiNone(), nil
+	}
+	if choice.Type != Action_Ron {
+		reply["consumed"] = mjaiTiles(choice.Tiles)
+	}
+	return reply, nil
+}
+
+// Handles a riichi declaration; the agent's own declaration is answered with its discard
+func (c *MjaiClient) declareRiichi(msg mjaiMessage) (map[string]any, error) {
+	c.round.declareRiichi(msg.Actor)
+	c.declaring[msg.Actor] = true
+	if msg.Actor != c.seat {
+		return mjaiNone(), nil
+	}
+	t := c.riichi.Tile
+	return map[string]any{
+		"type":      "dahai",
+		"actor":     c.seat,
+		"pai":       MjaiTile(t),
+		"tsumogiri": c.drawn != nil && *c.drawn == t,
+	}, nil
+}
+
+// Handles a chi, pon or called kan; after the agent's own chi or pon it asks for the discard
+func (c *MjaiClient) call(msg mjaiMessage) (map[string]any, error) {
+	t, err := ParseMjaiTile(msg.Pai)
+	if err != nil {
+		return nil, err
+	}
+	consumed, err := parseMjaiTiles(msg.Consumed)
+	if err != nil {
+		return nil, err
+	}
+	call := Action{Type: map[string]ActionType{"chi": Action_Chi, "pon": Action_Pon, "daiminkan": Action_Daiminkan}[msg.Type], Tile: t, Tiles: consumed}
+	c.round.applyCall(msg.Actor, msg.Target, call)
+	if call.Type == Action_Daiminkan {
+		c.next = drawRinshan
+		return mjaiNone(), nil
+	}
+	if msg.Actor != c.seat {
+		return mjaiNone(), nil
+	}
+	c.drawn = nil
+	return c.turn(kuikae(call)), nil
+}
+
+// Handles a closed or added kan; another seat's added kan may be robbed
+func (c *MjaiClient) kan(msg mjaiMessage) (map[string]any, error) {
+	consumed, err := parseMjaiTiles(msg.Consumed)
+	if err != nil {
+		return nil, err
+	}
+	r := c.round
+	s := r.seats[msg.Actor]
+	var set Set
+	var tile Tile
+	if msg.Type == "ankan" {
+		if len(consumed) != 4 {
+			return nil, fmt.Errorf("closed kan of %d tiles", len(consumed))
+		}
+		for _, t := range consumed {
+			s.remove(t)
+		}
+		tile = consumed[0]
+		set = Set{Type: Kantsu, Tiles: consumed, Target: msg.Actor}
+		sortTiles(set.Tiles)
+		s.melds = append(s.melds, set)
+	} else {
+		if tile, err = ParseMjaiTile(msg.Pai); err != nil {
+			return nil, err
+		}
+		s.remove(tile)
+		for i, m := range s.melds {
+			if m.Type == Koutsu && m.Tiles[0].ID == tile.ID {
+				m.Type = Kantsu
+				m.Tiles = append(append([]Tile{}, m.Tiles...), tile)
+				sortTiles(m.Tiles)
+				s.melds[i] = m
+				set = m
+			}
+		}
+		r.checkKanPao(msg.Actor, set)
+	}
+	action := map[string]ActionType{"ankan": Action_Ankan, "kakan": Action_Shouminkan}[msg.Type]
+	r.interrupt()
+	r.emit(Event{Type: Event_Call, Seat: msg.Actor, Tile: tile, Set: set, Call: action})
+	c.next = drawRinshan
+	if msg.Actor == c.seat || msg.Type == "ankan" {
+		return mjaiNone(), nil
+	}
+
+	// Chankan
+	if r.furiten(c.seat) {
+		return mjaiNone(), nil
+	}
+	r.chankan = true
+	defer func() { r.chankan = false }()
+	if _, ok := r.scoreWin(c.seat, tile, false); !ok {
+		return mjaiNone(), nil
+	}
+	options := []Action{{Type: Action_Ron, Tile: tile}, {Type: Action_Pass}}
+	if choice := matchOption(options, c.Agent.ChooseCall(r.view(c.seat, nil), options)); choice.Type != Action_Ron {
+		r.seats[c.seat].missWin()
+		return mjaiNone(), nil
+	}
+	return map[string]any{"type": "hora", "actor": c.seat, "target": msg.Actor, "pai": msg.Pai}, nil
+}
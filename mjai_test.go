@@ -0,0 +1,189 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"net"
+	"strings"
+	"testing"
+)
+
+func TestMjaiTile(t *testing.T) {
+	for id := 0; id < 34; id++ {
+		tile := ParseTile(id, false)
+		got, err := ParseMjaiTile(MjaiTile(tile))
+		if err != nil || got != tile {
+			t.Errorf("ParseMjaiTile(%q) = %+v, %v, want %+v", MjaiTile(tile), got, err, tile)
+		}
+	}
+	tests := []struct {
+		name string
+		want Tile
+	}{
+		{"5mr", ParseTile(4, true)},
+		{"5sr", ParseTile(22, true)},
+		{"E", ParseTile(27, false)},
+		{"P", ParseTile(31, false)},
+		{"C", ParseTile(33, false)},
+	}
+	for _, tt := range tests {
+		if got, err := ParseMjaiTile(tt.name); err != nil || got != tt.want || MjaiTile(got) != tt.name {
+			t.Errorf("ParseMjaiTile(%q) = %+v, %v, want %+v", tt.name, got, err, tt.want)
+		}
+	}
+	for _, name := range []string{"", "?", "0m", "5x", "4pr", "10s", "Wh"} {
+		if _, err := ParseMjaiTile(name); err == nil {
+			t.Errorf("ParseMjaiTile(%q) error = nil, want an error", name)
+		}
+	}
+}
+
+// Runs a client over a transcript of server messages and returns its answers, one per message up to end_game
+func runMjai(t *testing.T, agent Agent, messages ...string) []map[string]any {
+	t.Helper()
+	var out bytes.Buffer
+	if err := NewMjaiClient(agent, DefaultRules()).Run(strings.NewReader(strings.Join(messages, "\n")), &out); err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+	var replies []map[string]any
+	dec := json.NewDecoder(&out)
+	for dec.More() {
+		var reply map[string]any
+		if err := dec.Decode(&reply); err != nil {
+			t.Fatalf("Run() wrote invalid JSON: %v", err)
+		}
+		replies = append(replies, reply)
+	}
+	return replies
+}
+
+const mjaiStart = `{"type":"start_kyoku","bakaze":"E","kyoku":1,"honba":0,"kyotaku":0,"oya":0,"dora_marker":"1p","scores":[25000,25000,25000,25000],"tehais":[%s,["?","?","?","?","?","?","?","?","?","?","?","?","?"],["?","?","?","?","?","?","?","?","?","?","?","?","?"],["?","?","?","?","?","?","?","?","?","?","?","?","?"]]}`
+
+func mjaiStartWith(hand string) string {
+	return strings.Replace(mjaiStart, "%s", hand, 1)
+}
+
+func TestMjaiClient_Calls(t *testing.T) {
+	replies := runMjai(t, &scriptAgent{prefer: Action_Pon},
+		`{"type":"hello","protocol":"mjsonp","protocol_version":3}`,
+		`{"type":"start_game","id":0,"names":["a","b","c","d"]}`,
+		mjaiStartWith(`["1m","1m","2p","3p","4p","5s","6s","7s","E","E","S","W","N"]`),
+		`{"type":"tsumo","actor":0,"pai":"9m"}`,
+		`{"type":"dahai","actor":0,"pai":"9m","tsumogiri":true}`,
+		`{"type":"tsumo","actor":1,"pai":"?"}`,
+		`{"type":"dahai","actor":1,"pai":"1m","tsumogiri":true}`,
+		`{"type":"pon","actor":0,"target":1,"pai":"1m","consumed":["1m","1m"]}`,
+		`{"type":"dahai","actor":0,"pai":"2p","tsumogiri":false}`,
+		`{"type":"tsumo","actor":1,"pai":"?"}`,
+		`{"type":"dahai","actor":1,"pai":"8s","tsumogiri":true}`,
+		`{"type":"hora","actor":2,"target":1,"pai":"8s","scores":[25000,17000,33000,25000]}`,
+		`{"type":"end_kyoku"}`,
+		`{"type":"end_game"}`,
+		`{"type":"tsumo","actor":0,"pai":"1m"}`,
+	)
+	want := []string{
+		`{"name":"Script","room":"default","type":"join"}`,
+		`{"type":"none"}`,
+		`{"type":"none"}`,
+		`{"actor":0,"pai":"9m","tsumogiri":true,"type":"dahai"}`,
+		`{"type":"none"}`,
+		`{"type":"none"}`,
+		`{"actor":0,"consumed":["1m","1m"],"pai":"1m","target":1,"type":"pon"}`,
+		`{"actor":0,"pai":"2p","tsumogiri":false,"type":"dahai"}`,
+		`{"type":"none"}`,
+		`{"type":"none"}`,
+		`{"type":"none"}`,
+		`{"type":"none"}`,
+		`{"type":"none"}`,
+		`{"type":"none"}`,
+	}
+	// Nothing is read after end_game
+	if len(replies) != len(want) {
+		t.Fatalf("Run() answered %d messages, want %d", len(replies), len(want))
+	}
+	for i, w := range want {
+		got, _ := json.Marshal(replies[i])
+		if string(got) != w {
+			t.Errorf("reply %d = %s, want %s", i, got, w)
+		}
+	}
+}
+
+func TestMjaiClient_Riichi(t *testing.T) {
+	replies := runMjai(t, NewGreedyAgent(),
+		`{"type":"start_game","id":0,"names":["a","b","c","d"]}`,
+		mjaiStartWith(`["1m","2m","3m","4p","5p","6p","7s","8s","9s","E","E","S","1p"]`),
+		`{"type":"tsumo","actor":0,"pai":"3p"}`,
+		`{"type":"reach","actor":0}`,
+		`{"type":"dahai","actor":0,"pai":"S","tsumogiri":false}`,
+		`{"type":"reach_accepted","actor":0,"scores":[24000,25000,25000,25000]}`,
+		`{"type":"tsumo","actor":1,"pai":"?"}`,
+		`{"type":"dahai","actor":1,"pai":"E","tsumogiri":true}`,
+		`{"type":"tsumo","actor":2,"pai":"?"}`,
+		`{"type":"dahai","actor":2,"pai":"2p","tsumogiri":true}`,
+	)
+	if len(replies) != 10 {
+		t.Fatalf("Run() answered %d messages, want 10", len(replies))
+	}
+	if replies[2]["type"] != "reach" {
+		t.Errorf("reply to the draw = %v, want reach", replies[2])
+	}
+	if replies[3]["type"] != "dahai" || replies[3]["pai"] != "S" {
+		t.Errorf("reply to the riichi = %v, want the discard of S", replies[3])
+	}
+	// In riichi the client only offers the win; E completes no hand and the pon is not offered
+	if replies[7]["type"] != "none" {
+		t.Errorf("reply to E = %v, want none", replies[7])
+	}
+	if replies[9]["type"] != "hora" || replies[9]["target"] != 2.0 {
+		t.Errorf("reply to 2p = %v, want a ron from seat 2", replies[9])
+	}
+}
+
+func TestMjaiClient_Errors(t *testing.T) {
+	start := mjaiStartWith(`["1m","1m","2p","3p","4p","5s","6s","7s","E","E","S","W","N"]`)
+	for _, input := range []string{
+		`{"type":"tsumo","actor":0,"pai":"1m"}`,
+		`{"type":"error","message":"bad"}`,
+		`not json`,
+		`{"type":"start_kyoku","bakaze":"E","dora_marker":"1p","tehais":[]}`,
+		`{"type":"start_game","id":4}`,
+		strings.Replace(start, `"oya":0`, `"oya":-1`, 1),
+		start + "\n" + `{"type":"dahai","actor":9,"pai":"1m","tsumogiri":false}`,
+		start + "\n" + `{"type":"pon","actor":1,"target":7,"pai":"1m","consumed":["1m","1m"]}`,
+	} {
+		if err := NewMjaiClient(NewGreedyAgent(), DefaultRules()).Run(strings.NewReader(input), &bytes.Buffer{}); err == nil {
+			t.Errorf("Run(%q) error = nil, want an error", input)
+		}
+	}
+}
+
+func TestDialMjai(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Skipf("no local network: %v", err)
+	}
+	defer ln.Close()
+	joined := make(chan string, 1)
+	go func() {
+		conn, err := ln.Accept()
+		if err != nil {
+			joined <- ""
+			return
+		}
+		defer conn.Close()
+		in := bufio.NewReader(conn)
+		conn.Write([]byte(`{"type":"hello","protocol":"mjsonp","protocol_version":3}` + "\n"))
+		line, _ := in.ReadString('\n')
+		conn.Write([]byte(`{"type":"end_game"}` + "\n"))
+		in.ReadString('\n')
+		joined <- line
+	}()
+	if err := DialMjai(ln.Addr().String(), NewMjaiClient(NewGreedyAgent(), DefaultRules())); err != nil {
+		t.Fatalf("DialMjai() error = %v", err)
+	}
+	if line := <-joined; !strings.Contains(line, `"type":"join"`) || !strings.Contains(line, `"name":"Greedy"`) {
+		t.Errorf("DialMjai() sent %q, want a join", line)
+	}
+}
@@ -0,0 +1,537 @@
+package main
+
+import (
+	"bufio"
+	"compress/gzip"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// Import of Tenhou mjlog XML replays. Tiles are numbered 0-135, four copies per tile type
+// in ID order, with copy 0 of each five being red when red fives are played.
+
+// Tenhou yaku IDs to yaku names; the names of yaku this package scores match Yaku.Name
+var tenhouYaku = [...]string{
+	"Tsumo (Self-draw)", "Riichi", "Ippatsu (One-shot)", "Chankan (Robbing a Kan)",
+	"Rinshan Kaihou (After a Kan)", "Haitei Raoyue", "Houtei Raoyui", "Pinfu (All Sequences)",
+	"Tanyao (All Simples)", "Iipeikou", "Yakuhai (Value Tiles)", "Yakuhai (Value Tiles)",
+	"Yakuhai (Value Tiles)", "Yakuhai (Value Tiles)", "Yakuhai (Value Tiles)", "Yakuhai (Value Tiles)",
+	"Yakuhai (Value Tiles)", "Yakuhai (Value Tiles)", "Yakuhai (Value Tiles)", "Yakuhai (Value Tiles)",
+	"Yakuhai (Value Tiles)", "Double Riichi", "Chiitoitsu (Seven Pairs)", "Chanta",
+	"Ittsu", "Sanshoku Doujun", "Sanshoku Doukou", "Sankantsu",
+	"Toitoi (All Triplets)", "Sanankou", "Shousangen", "Honroutou",
+	"Ryanpeikou", "Junchan", "Honitsu (Half Flush)", "Chinitsu (Full Flush)",
+	"Renhou", "Tenhou", "Chiihou", "Daisangen (Big Three Dragons)",
+	"Suuankou (Four Concealed Triplets)", "Suuankou (Four Concealed Triplets)", "Tsuuiisou", "Ryuuiisou",
+	"Chinroutou", "Chuuren Poutou", "Chuuren Poutou", "Kokushi Musou (Thirteen Orphans)",
+	"Kokushi Musou (Thirteen Orphans)", "Daisuushii (Big Four Winds)", "Shousuushii", "Suukantsu (Four Quads)",
+	"Dora", "Ura Dora", "Aka Dora",
+}
+
+// Tenhou limit index to limit name
+var tenhouLimits = [...]string{"", "Mangan", "Haneman", "Baiman", "Sanbaiman", "Yakuman"}
+
+// Tenhou abortive draw types
+var tenhouAbortive = map[string]AbortiveDraw{
+	"yao9":   Abortive_KyuushuKyuuhai,
+	"kaze4":  Abortive_SuufonRenda,
+	"reach4": Abortive_SuuchaRiichi,
+	"kan4":   Abortive_Suukaikan,
+	"ron3":   Abortive_Sanchahou,
+}
+
+// Converts a Tenhou tile number (0-135) to a tile
+func tenhouTile(n int, red bool) Tile {
+	return ParseTile(n/4, red && (n == 16 || n == 52 || n == 88))
+}
+
+// Decodes a Tenhou meld. who is the calling seat; the result is the set, the called
+// (or added) tile and the kind of call.
+func decodeTenhouMeld(who, m, players int, red bool) (Set, Tile, ActionType) {
+	from := (who + m&3) % players
+	tile := func(n int) Tile { return tenhouTile(n, red) }
+	switch {
+	case m&0x4 != 0: // chi
+		t := (m & 0xFC00) >> 10
+		called := t % 3
+		t /= 3
+		t = (t/7*9 + t%7) * 4
+		ns := []int{t + (m&0x18)>>3, t + 4 + (m&0x60)>>5, t + 8 + (m&0x180)>>7}
+		set := Set{Type: Shuntsu, Open: true, Target: from}
+		for _, n := range ns {
+			set.Tiles = append(set.Tiles, tile(n))
+		}
+		return set, tile(ns[called]), Action_Chi
+	case m&0x18 != 0: // pon or added kan
+		unused := (m & 0x60) >> 5
+		t := (m & 0xFE00) >> 9
+		called := t % 3
+		t = t / 3 * 4
+		var ns []int
+		for i := 0; i < 4; i++ {
+			if i != unused {
+				ns = append(ns, t+i)
+			}
+		}
+		set := Set{Type: Koutsu, Open: true, Target: from}
+		for _, n := range ns {
+			set.Tiles = append(set.Tiles, tile(n))
+		}
+		if m&0x10 != 0 {
+			set.Type = Kantsu
+			set.Tiles = append(set.Tiles, tile(t+unused))
+			sortTiles(set.Tiles)
+			return set, tile(t + unused), Action_Shouminkan
+		}
+		return set, tile(ns[called]), Action_Pon
+	case m&0x20 != 0: // kita
+		return Set{}, tile((m & 0xFF00) >> 8), Action_Kita
+	default: // closed or called kan
+		n := (m & 0xFF00) >> 8
+		t := n / 4 * 4
+		set := Set{Type: Kantsu, Tiles: []Tile{tile(t), tile(t + 1), tile(t + 2), tile(t + 3)}, Open: true, Target: from}
+		sortTiles(set.Tiles)
+		if from == who {
+			set.Open = false
+			return set, tile(n), Action_Ankan
+		}
+		return set, tile(n), Action_Daiminkan
+	}
+}
+
+// Parses a comma separated list of integers
+func parseInts(s string) ([]int, error) {
+	if s == "" {
+		return nil, nil
+	}
+	var values []int
+	for _, field := range strings.Split(s, ",") {
+		v, err := strconv.Atoi(strings.TrimSpace(field))
+		if err != nil {
+			return nil, fmt.Errorf("invalid number %q", field)
+		}
+		values = append(values, v)
+	}
+	return values, nil
+}
+
+// State of an mjlog being parsed
+type mjlogParser struct {
+	record   *GameRecord
+	players  int
+	red      bool
+	round    *RoundRecord
+	drawn    []int // last tile number drawn by each seat, -1 after a discard
+	deposits int   // riichi deposits made in the current round
+}
+
+// Reads an mjlog file, plain or gzip compressed
+func ReadMjlog(path string) (*GameRecord, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return ParseMjlog(f)
+}
+
+// Parses an mjlog XML replay, plain or gzip compressed
+func ParseMjlog(r io.Reader) (*GameRecord, error) {
+	br := bufio.NewReader(r)
+	if magic, err := br.Peek(2); err == nil && magic[0] == 0x1f && magic[1] == 0x8b {
+		gz, err := gzip.NewReader(br)
+		if err != nil {
+			return nil, err
+		}
+		defer gz.Close()
+		r = gz
+	} else {
+		r = br
+	}
+
+	p := &mjlogParser{record: &GameRecord{Rules: DefaultRules()}, players: 4, red: true}
+	dec := xml.NewDecoder(r)
+	for {
+		tok, err := dec.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("mjlog: %w", err)
+		}
+		start, ok := tok.(xml.StartElement)
+		if !ok {
+			continue
+		}
+		attrs := map[string]string{}
+		for _, a := range start.Attr {
+			attrs[a.Name.Local] = a.Value
+		}
+		if err := p.element(start.Name.Local, attrs); err != nil {
+			return nil, fmt.Errorf("mjlog %s: %w", start.Name.Local, err)
+		}
+	}
+	p.endRound()
+	return p.record, nil
+}
+
+// Splits a draw or discard tag such as T52 into its letter and tile number
+func splitTileTag(name string) (byte, int, bool) {
+	if len(name) < 2 {
+		return 0, 0, false
+	}
+	n, err := strconv.Atoi(name[1:])
+	if err != nil {
+		return 0, 0, false
+	}
+	return name[0], n, true
+}
+
+// Reads a seat number attribute such as who or oya
+func (p *mjlogParser) seat(attrs map[string]string, key string) (int, error) {
+	seat, err := strconv.Atoi(attrs[key])
+	if err != nil || seat < 0 || seat >= p.players {
+		return 0, fmt.Errorf("invalid seat %s=%q", key, attrs[key])
+	}
+	return seat, nil
+}
+
+// Decodes a tile number, 0 to 135
+func (p *mjlogParser) tile(n int) (Tile, error) {
+	if n < 0 || n > 135 {
+		return Tile{}, fmt.Errorf("invalid tile %d", n)
+	}
+	return tenhouTile(n, p.red), nil
+}
+
+// Decodes a list of tile numbers
+func (p *mjlogParser) tiles(s string) ([]Tile, error) {
+	ns, err := parseInts(s)
+	if err != nil {
+		return nil, err
+	}
+	var tiles []Tile
+	for _, n := range ns {
+		t, err := p.tile(n)
+		if err != nil {
+			return nil, err
+		}
+		tiles = append(tiles, t)
+	}
+	return tiles, nil
+}
+
+// Handles one XML element
+func (p *mjlogParser) element(name string, attrs map[string]string) error {
+	if letter, n, ok := splitTileTag(name); ok {
+		return p.tileTag(letter, n)
+	}
+	switch name {
+	case "GO":
+		p.game(attrs)
+	case "UN":
+		p.names(attrs)
+	case "INIT":
+		return p.init(attrs)
+	case "N":
+		return p.meld(attrs)
+	case "REACH":
+		return p.reach(attrs)
+	case "DORA":
+		n, err := strconv.Atoi(attrs["hai"])
+		if err != nil {
+			return err
+		}
+		t, err := p.tile(n)
+		if err != nil {
+			return err
+		}
+		p.emit(Event{Type: Event_NewDora, Tile: t})
+	case "AGARI":
+		return p.agari(attrs)
+	case "RYUUKYOKU":
+		return p.ryuukyoku(attrs)
+	}
+	return nil
+}
+
+// Reads the rules from the game type flags
+func (p *mjlogParser) game(attrs map[string]string) {
+	flags, err := strconv.Atoi(attrs["type"])
+	if err != nil {
+		return
+	}
+	rules := DefaultRules()
+	if flags&0x10 != 0 {
+		rules = SanmaRules()
+		p.players = 3
+	}
+	if flags&0x02 != 0 {
+		rules.RedFives = [3]int{}
+		p.red = false
+	}
+	if flags&0x08 == 0 {
+		rules.Length = Length_Tonpuusen
+	}
+	p.record.Rules = rules
+}
+
+// Reads the URL-encoded player names
+func (p *mjlogParser) names(attrs map[string]string) {
+	if _, ok := attrs["n0"]; !ok {
+		return // a reconnection notice
+	}
+	p.record.Players = nil
+	for seat := 0; seat < p.players; seat++ {
+		name, err := url.QueryUnescape(attrs[fmt.Sprintf("n%d", seat)])
+		if err != nil {
+			name = attrs[fmt.Sprintf("n%d", seat)]
+		}
+		p.record.Players = append(p.record.Players, name)
+	}
+}
+
+// Starts a round
+func (p *mjlogParser) init(attrs map[string]string) error {
+	p.endRound()
+	seed, err := parseInts(attrs["seed"])
+	if err != nil || len(seed) < 6 {
+		return fmt.Errorf("invalid seed %q", attrs["seed"])
+	}
+	dora, err := p.tile(seed[5])
+	if err != nil {
+		return err
+	}
+	ten, err := parseInts(attrs["ten"])
+	if err != nil || len(ten) < p.players {
+		return fmt.Errorf("invalid scores %q", attrs["ten"])
+	}
+	dealer, err := p.seat(attrs, "oya")
+	if err != nil {
+		return err
+	}
+	setup := RoundSetup{RoundWind: seed[0] / 4, Dealer: dealer, Honba: seed[1], RiichiSticks: seed[2]}
+	for seat := 0; seat < p.players; seat++ {
+		setup.Scores = append(setup.Scores, ten[seat]*100)
+	}
+	p.round = &RoundRecord{Setup: setup}
+	p.deposits = 0
+	p.drawn = make([]int, p.players)
+	for seat := 0; seat < p.players; seat++ {
+		hand, err := p.tiles(attrs[fmt.Sprintf("hai%d", seat)])
+		if err != nil {
+			return err
+		}
+		sortTiles(hand)
+		p.round.Hands = append(p.round.Hands, hand)
+		p.drawn[seat] = -1
+	}
+	p.emit(Event{Type: Event_StartRound, Seat: dealer})
+	p.emit(Event{Type: Event_NewDora, Tile: dora})
+	return nil
+}
+
+// Records the current round, if any; riichi sticks stay on the table unless someone won
+func (p *mjlogParser) endRound() {
+	if p.round != nil {
+		if len(p.round.Result.Wins) == 0 {
+			p.round.Result.RiichiSticks = p.round.Setup.RiichiSticks + p.deposits
+		}
+		p.record.Rounds = append(p.record.Rounds, *p.round)
+		p.round = nil
+	}
+}
+
+// Appends an event to the current round
+func (p *mjlogParser) emit(ev Event) {
+	if p.round != nil {
+		p.round.Events = append(p.round.Events, ev)
+	}
+}
+
+// Handles a draw (T, U, V, W) or discard (D, E, F, G)
+func (p *mjlogParser) tileTag(letter byte, n int) error {
+	if p.round == nil {
+		return fmt.Errorf("tile before INIT")
+	}
+	t, err := p.tile(n)
+	if err != nil {
+		return err
+	}
+	if seat := strings.IndexByte("TUVW", letter); seat >= 0 && seat < p.players {
+		p.drawn[seat] = n
+		p.emit(Event{Type: Event_Draw, Seat: seat, Tile: t})
+		return nil
+	}
+	if seat := strings.IndexByte("DEFG", letter); seat >= 0 && seat < p.players {
+		p.emit(Event{Type: Event_Discard, Seat: seat, Tile: t, Tsumogiri: p.drawn[seat] == n})
+		p.drawn[seat] = -1
+		return nil
+	}
+	return nil
+}
+
+// Handles a call
+func (p *mjlogParser) meld(attrs map[string]string) error {
+	who, err := p.seat(attrs, "who")
+	if err != nil {
+		return err
+	}
+	m, err := strconv.Atoi(attrs["m"])
+	if err != nil || m < 0 || m > 0xFFFF {
+		return fmt.Errorf("invalid meld %q", attrs["m"])
+	}
+	set, tile, call := decodeTenhouMeld(who, m, p.players, p.red)
+	// Out of range fields decode to tile IDs past the honors
+	for _, t := range append([]Tile{tile}, set.Tiles...) {
+		if t.ID > 33 {
+			return fmt.Errorf("invalid meld %q", attrs["m"])
+		}
+	}
+	if call == Action_Kita {
+		p.emit(Event{Type: Event_Kita, Seat: who, Tile: tile})
+		return nil
+	}
+	p.emit(Event{Type: Event_Call, Seat: who, Tile: tile, Set: set, Call: call})
+	return nil
+}
+
+// Handles a riichi declaration; step 2 (the deposit) carries no event of its own
+func (p *mjlogParser) reach(attrs map[string]string) error {
+	who, err := p.seat(attrs, "who")
+	if err != nil {
+		return err
+	}
+	switch attrs["step"] {
+	case "1":
+		p.emit(Event{Type: Event_Riichi, Seat: who})
+	case "2":
+		p.deposits++
+	}
+	return nil
+}
+
+// Applies the sc attribute (score before and change, in hundreds, per seat) to the round result.
+// Deltas are measured from the start of the round, so they include riichi deposits.
+func (p *mjlogParser) applyScores(sc string) error {
+	values, err := parseInts(sc)
+	if err != nil {
+		return err
+	}
+	res := &p.round.Result
+	res.Scores = make([]int, p.players)
+	res.Deltas = make([]int, p.players)
+	for seat := 0; seat < p.players && 2*seat+1 < len(values); seat++ {
+		res.Scores[seat] = (values[2*seat] + values[2*seat+1]) * 100
+		res.Deltas[seat] = res.Scores[seat] - p.round.Setup.Scores[seat]
+	}
+	return nil
+}
+
+// Handles a win
+func (p *mjlogParser) agari(attrs map[string]string) error {
+	if p.round == nil {
+		return fmt.Errorf("AGARI before INIT")
+	}
+	who, err := p.seat(attrs, "who")
+	if err != nil {
+		return err
+	}
+	from, err := p.seat(attrs, "fromWho")
+	if err != nil {
+		return err
+	}
+	machi, err1 := strconv.Atoi(attrs["machi"])
+	ten, err2 := parseInts(attrs["ten"])
+	if err1 != nil || err2 != nil || len(ten) < 3 {
+		return fmt.Errorf("invalid win")
+	}
+	tile, err := p.tile(machi)
+	if err != nil {
+		return err
+	}
+	score := HandScore{
+		Fu:     ten[0],
+		Dealer: who == p.round.Setup.Dealer,
+		Tsumo:  who == from,
+	}
+	if ten[2] < len(tenhouLimits) {
+		score.Limit = tenhouLimits[ten[2]]
+	}
+	yaku, err := parseInts(attrs["yaku"])
+	if err != nil {
+		return err
+	}
+	for i := 0; i+1 < len(yaku); i += 2 {
+		if yaku[i+1] == 0 {
+			continue
+		}
+		score.Han += yaku[i+1]
+		if yaku[i] < len(tenhouYaku) {
+			score.Yaku = append(score.Yaku, tenhouYaku[yaku[i]])
+			score.YakuHan = append(score.YakuHan, yaku[i+1])
+		}
+	}
+	yakuman, err := parseInts(attrs["yakuman"])
+	if err != nil {
+		return err
+	}
+	for _, id := range yakuman {
+		score.Yakuman++
+		score.Han += 13
+		if id < len(tenhouYaku) {
+			score.Yaku = append(score.Yaku, tenhouYaku[id])
+			score.YakuHan = append(score.YakuHan, 13)
+		}
+	}
+	score.computePayments()
+
+	liable := -1
+	if _, ok := attrs["paoWho"]; ok {
+		if liable, err = p.seat(attrs, "paoWho"); err != nil {
+			return err
+		}
+	}
+	ura, err := p.tiles(attrs["doraHaiUra"])
+	if err != nil {
+		return err
+	}
+	// Every win of a multiple ron repeats the indicators
+	if p.round.UraDora == nil {
+		p.round.UraDora = ura
+	}
+	p.round.Result.Wins = append(p.round.Result.Wins, Win{Seat: who, From: from, Tile: tile, Score: score, Liable: liable})
+	if who == p.round.Setup.Dealer {
+		p.round.Result.DealerRepeat = true
+	}
+	p.emit(Event{Type: Event_Win, Seat: who, From: from, Tile: tile, Score: score})
+	return p.applyScores(attrs["sc"])
+}
+
+// Handles an exhaustive or abortive draw
+func (p *mjlogParser) ryuukyoku(attrs map[string]string) error {
+	if p.round == nil {
+		return fmt.Errorf("RYUUKYOKU before INIT")
+	}
+	res := &p.round.Result
+	kind, abortive := tenhouAbortive[attrs["type"]]
+	if abortive {
+		res.Abortive = kind
+		res.DealerRepeat = true
+		p.emit(Event{Type: Event_AbortiveDraw, Abortive: kind})
+	} else {
+		res.Exhaustive = true
+		res.Tenpai = make([]bool, p.players)
+		for seat := 0; seat < p.players; seat++ {
+			_, res.Tenpai[seat] = attrs[fmt.Sprintf("hai%d", seat)]
+		}
+		res.DealerRepeat = res.Tenpai[p.round.Setup.Dealer]
+		p.emit(Event{Type: Event_ExhaustiveDraw})
+	}
+	return p.applyScores(attrs["sc"])
+}
@@ -0,0 +1,149 @@
+package main
+
+import (
+	"bytes"
+	"compress/gzip"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+const sampleMjlog = `<mjloggm ver="2.3"><SHUFFLE seed="mt19937ar-sha512-n288-base64,x" ref=""/>` +
+	`<GO type="169" lobby="0"/><UN n0="%41%6C%69%63%65" n1="Bob" n2="Carol" n3="Dave" dan="0,0,0,0" rate="1500,1500,1500,1500" sx="M,M,F,M"/>` +
+	`<TAIKYOKU oya="0"/>` +
+	`<INIT seed="0,0,0,2,3,52" ten="250,250,250,250" oya="0" hai0="0,4,8,12,17,20,36,40,44,72,76,80,124" hai1="1,5,9,13,21,24,37,41,45,73,77,81,125" hai2="2,6,10,14,25,28,38,42,46,74,78,82,126" hai3="3,7,11,15,29,32,39,43,47,75,79,83,127"/>` +
+	`<T16/><D124/><U100/><E100/><N who="2" m="48138"/><F28/><G104/><T108/>` +
+	`<REACH who="0" step="1"/><D108/><REACH who="0" ten="240,250,250,250" step="2"/>` +
+	`<U60/><E60/>` +
+	`<AGARI ba="0,1" hai="0,4,8,12,16,17,20,36,40,44,60,72,76,80" machi="60" ten="40,3900,0" yaku="1,1,8,1" doraHai="52" who="0" fromWho="1" sc="240,49,250,-39,250,0,250,0"/>` +
+	`<INIT seed="1,0,0,1,1,108" ten="289,211,250,250" oya="1" hai0="0,4,8,12,17,20,36,40,44,72,76,80,124" hai1="1,5,9,13,21,24,37,41,45,73,77,81,125" hai2="2,6,10,14,25,28,38,42,46,74,78,82,126" hai3="3,7,11,15,29,32,39,43,47,75,79,83,127"/>` +
+	`<RYUUKYOKU ba="0,0" sc="289,30,211,-10,250,-10,250,-10" hai0="0,4,8,12,17,20,36,40,44,72,76,80,124"/>` +
+	`<INIT seed="1,1,0,1,1,108" ten="319,201,240,240" oya="1" hai0="0,4,8,12,17,20,36,40,44,72,76,80,124" hai1="1,5,9,13,21,24,37,41,45,73,77,81,125" hai2="2,6,10,14,25,28,38,42,46,74,78,82,126" hai3="3,7,11,15,29,32,39,43,47,75,79,83,127"/>` +
+	`<RYUUKYOKU type="yao9" ba="1,0" sc="319,0,201,0,240,0,240,0"/>` +
+	`</mjloggm>`
+
+func TestDecodeTenhouMeld(t *testing.T) {
+	tests := []struct {
+		name     string
+		who, m   int
+		wantCall ActionType
+		wantTile int
+		wantSet  []int
+		wantFrom int
+	}{
+		{"Chi 2-3-4m calling 3m", 1, 4103, Action_Chi, 2, []int{1, 2, 3}, 0},
+		{"Pon of White from across", 2, 48138, Action_Pon, 31, []int{31, 31, 31}, 0},
+		{"Closed kan of East", 3, 27648, Action_Ankan, 27, []int{27, 27, 27, 27}, 3},
+		{"Called kan of East", 3, 27905, Action_Daiminkan, 27, []int{27, 27, 27, 27}, 0},
+		{"Added kan of East", 0, 41585, Action_Shouminkan, 27, []int{27, 27, 27, 27}, 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			set, tile, call := decodeTenhouMeld(tt.who, tt.m, 4, true)
+			if call != tt.wantCall || tile.ID != tt.wantTile {
+				t.Errorf("decodeTenhouMeld() = %v %v, want %v %v", call, tile.ID, tt.wantCall, tt.wantTile)
+			}
+			var ids []int
+			for _, t := range set.Tiles {
+				ids = append(ids, t.ID)
+			}
+			if !reflect.DeepEqual(ids, tt.wantSet) || set.Target != tt.wantFrom {
+				t.Errorf("decodeTenhouMeld() set = %v from %v, want %v from %v", ids, set.Target, tt.wantSet, tt.wantFrom)
+			}
+		})
+	}
+}
+
+func TestTenhouTile(t *testing.T) {
+	if got := tenhouTile(16, true); got.ID != 4 || !got.Red {
+		t.Errorf("tenhouTile(16) = %+v, want red 5m", got)
+	}
+	if got := tenhouTile(17, true); got.Red {
+		t.Errorf("tenhouTile(17) = %+v, want plain 5m", got)
+	}
+	if got := tenhouTile(52, false); got.Red {
+		t.Errorf("tenhouTile(52) without red fives = %+v, want plain 5p", got)
+	}
+}
+
+func TestParseMjlog(t *testing.T) {
+	record, err := ParseMjlog(strings.NewReader(sampleMjlog))
+	if err != nil {
+		t.Fatalf("ParseMjlog() error = %v", err)
+	}
+	if !reflect.DeepEqual(record.Players, []string{"Alice", "Bob", "Carol", "Dave"}) {
+		t.Errorf("ParseMjlog() players = %v", record.Players)
+	}
+	if record.Rules.Length != Length_Hanchan || record.Rules.RedFives != [3]int{1, 1, 1} {
+		t.Errorf("ParseMjlog() rules = %+v", record.Rules)
+	}
+	if len(record.Rounds) != 3 {
+		t.Fatalf("ParseMjlog() rounds = %v, want 3", len(record.Rounds))
+	}
+
+	first := record.Rounds[0]
+	if len(first.Hands) != 4 || len(first.Hands[0]) != 13 || first.Setup.Scores[0] != 25000 {
+		t.Errorf("ParseMjlog() first round setup = %+v", first.Setup)
+	}
+	var types []EventType
+	for _, ev := range first.Events {
+		types = append(types, ev.Type)
+	}
+	want := []EventType{Event_StartRound, Event_NewDora, Event_Draw, Event_Discard, Event_Draw, Event_Discard,
+		Event_Call, Event_Discard, Event_Discard, Event_Draw, Event_Riichi, Event_Discard, Event_Draw, Event_Discard, Event_Win}
+	if !reflect.DeepEqual(types, want) {
+		t.Errorf("ParseMjlog() events = %v, want %v", types, want)
+	}
+	if ev := first.Events[5]; !ev.Tsumogiri || ev.Seat != 1 {
+		t.Errorf("ParseMjlog() discard = %+v, want a tsumogiri by seat 1", ev)
+	}
+	if ev := first.Events[6]; ev.Call != Action_Pon || ev.Set.Target != 0 || ev.Seat != 2 {
+		t.Errorf("ParseMjlog() call = %+v, want a pon by seat 2 from seat 0", ev)
+	}
+	win := first.Result.Wins[0]
+	if win.Seat != 0 || win.From != 1 || win.Score.Han != 2 || win.Score.Fu != 40 || win.Score.Ron != 3900 {
+		t.Errorf("ParseMjlog() win = %+v", win)
+	}
+	if !reflect.DeepEqual(first.Result.Deltas, []int{3900, -3900, 0, 0}) || first.Result.RiichiSticks != 0 {
+		t.Errorf("ParseMjlog() deltas = %v sticks %v", first.Result.Deltas, first.Result.RiichiSticks)
+	}
+
+	draw := record.Rounds[1].Result
+	if !draw.Exhaustive || !reflect.DeepEqual(draw.Tenpai, []bool{true, false, false, false}) || draw.DealerRepeat {
+		t.Errorf("ParseMjlog() exhaustive draw = %+v", draw)
+	}
+	if abort := record.Rounds[2].Result; abort.Abortive != Abortive_KyuushuKyuuhai || !abort.DealerRepeat {
+		t.Errorf("ParseMjlog() abortive draw = %+v", abort)
+	}
+}
+
+func TestParseMjlog_Gzip(t *testing.T) {
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	gz.Write([]byte(sampleMjlog))
+	gz.Close()
+	record, err := ParseMjlog(&buf)
+	if err != nil || len(record.Rounds) != 3 {
+		t.Errorf("ParseMjlog() gzip = %v rounds, error %v", len(record.Rounds), err)
+	}
+}
+
+func TestParseMjlog_Invalid(t *testing.T) {
+	for _, input := range []string{
+		`<mjloggm><INIT seed="0,0" ten="250,250,250,250" oya="0"/></mjloggm>`,
+		`<mjloggm><T16/></mjloggm>`,
+		`<mjloggm><INIT`,
+		`<mjloggm><INIT seed="0,0,0,0,0,0" ten="250,250,250,250" oya="7"/><RYUUKYOKU sc="250,0,250,0,250,0,250,0"/></mjloggm>`,
+		`<mjloggm><INIT seed="0,0,0,0,0,0" ten="250,250" oya="0"/><RYUUKYOKU sc="250,0,250,0,250,0,250,0"/></mjloggm>`,
+		`<mjloggm><INIT seed="0,0,0,0,0,200" ten="250,250,250,250" oya="0"/></mjloggm>`,
+		`<mjloggm><INIT seed="0,0,0,0,0,0" ten="250,250,250,250" oya="0"/><T136/></mjloggm>`,
+		`<mjloggm><INIT seed="0,0,0,0,0,0" ten="250,250,250,250" oya="0"/><N who="-1" m="42031"/></mjloggm>`,
+		`<mjloggm><INIT seed="0,0,0,0,0,0" ten="250,250,250,250" oya="0"/><N who="0" m="65032"/></mjloggm>`,
+		`<mjloggm><INIT seed="0,0,0,0,0,0" ten="250,250,250,250" oya="0"/><REACH who="-1" step="1"/></mjloggm>`,
+		`<mjloggm><INIT seed="0,0,0,0,0,0" ten="250,250,250,250" oya="0"/><AGARI who="0" fromWho="4" machi="0" ten="30,1000,0" yaku="1,1"/></mjloggm>`,
+	} {
+		if _, err := ParseMjlog(strings.NewReader(input)); err == nil {
+			t.Errorf("ParseMjlog(%q) error = nil, want an error", input)
+		}
+	}
+}
@@ -0,0 +1,200 @@
+package main
+
+import (
+	"math/rand/v2"
+	"runtime"
+	"sort"
+	"sync"
+)
+
+// Monte Carlo estimation of tenpai and win probabilities by sampling walls from the KB
+
+type SimulationConfig struct {
+	Draws   int    // number of draws remaining for the player
+	Samples int    // number of sampled walls per candidate discard
+	Seed    uint64 // seed for reproducible sampling
+	Workers int    // concurrent workers, defaults to the number of CPUs
+}
+
+// Estimated outcome of discarding a tile
+type DiscardSimulation struct {
+	Discard    Tile
+	Shanten    Deficiency // deficiency immediately after the discard
+	TenpaiRate float64    // probability of reaching tenpai within the remaining draws
+	WinRate    float64    // probability of completing the hand by tsumo within the remaining draws
+	AvgTenpai  float64    // average draws needed to reach tenpai, over samples that reached it
+}
+
+// Tallies from a set of simulated playouts
+type playoutTally struct {
+	tenpai, wins, tenpaiDraws int
+}
+
+// Preference for discarding a tile when several discards keep the same deficiency;
+// lower values are discarded first: isolated honors, isolated terminals, then tiles by
+// distance from the middle of the suit
+func discardPriority(hand Hand, id int) int {
+	if id >= 27 {
+		return hand.counts[id]
+	}
+	rank := id % 9
+	isolated := true
+	for d := -2; d <= 2; d++ {
+		n := rank + d
+		if d != 0 && n >= 0 && n <= 8 && hand.counts[id+d] > 0 {
+			isolated = false
+		}
+	}
+	edge := min(rank, 8-rank)
+	if isolated && hand.counts[id] == 1 {
+		return 5 + edge
+	}
+	return 10 + edge + hand.counts[id]*5
+}
+
+// Chooses the discard minimising deficiency, preferring to discard the drawn tile on ties
+func greedyDiscard(hand Hand, openSets int, drawn int) int {
+	best, bestD, bestP := -1, Deficiency(0), 0
+	for id, count := range hand.counts {
+		if count == 0 {
+			continue
+		}
+		hand.counts[id]--
+		d := CalculateDeficiency(hand, openSets)
+		hand.counts[id]++
+		p := discardPriority(hand, id)
+		if id == drawn {
+			p = -1
+		}
+		if best == -1 || d < bestD || (d == bestD && p < bestP) {
+			best, bestD, bestP = id, d, p
+		}
+	}
+	return best
+}
+
+// Plays out one sampled wall for a hand awaiting a draw, returning the draw on which
+// tenpai and a win were first reached (0 if tenpai already, -1 if never)
+func playout(hand Hand, openSets int, wall []int) (tenpaiAt, winAt int) {
+	tenpaiAt, winAt = -1, -1
+	if CalculateDeficiency(hand, openSets) == 0 {
+		tenpaiAt = 0
+	}
+	for i, id := range wall {
+		hand.counts[id]++
+		if CalculateDeficiency(hand, openSets) == -1 {
+			return tenpaiAt, i + 1
+		}
+		hand.counts[greedyDiscard(hand, openSets, id)]--
+		if tenpaiAt == -1 && CalculateDeficiency(hand, openSets) == 0 {
+			tenpaiAt = i + 1
+		}
+	}
+	return tenpaiAt, winAt
+}
+
+// Samples the player's next draws uniformly from the unseen tiles of the KB
+func sampleWall(pool []int, draws int, rng *rand.Rand) []int {
+	wall := make([]int, len(pool))
+	copy(wall, pool)
+	n := min(draws, len(wall))
+	for i := 0; i < n; i++ {
+		j := i + rng.IntN(len(wall)-i)
+		wall[i], wall[j] = wall[j], wall[i]
+	}
+	return wall[:n]
+}
+
+// Runs the configured number of playouts for a hand awaiting a draw, concurrently across workers.
+// Sample i always uses the same sampled wall regardless of worker count or hand, so results are
+// reproducible and candidate discards are compared on identical walls.
+func simulateHand(hand Hand, openSets int, pool []int, cfg SimulationConfig) playoutTally {
+	workers := cfg.Workers
+	if workers <= 0 {
+		workers = runtime.NumCPU()
+	}
+	tallies := make([]playoutTally, workers)
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func(w int) {
+			defer wg.Done()
+			for i := w; i < cfg.Samples; i += workers {
+				rng := rand.New(rand.NewPCG(cfg.Seed, uint64(i)))
+				tenpaiAt, winAt := playout(hand, openSets, sampleWall(pool, cfg.Draws, rng))
+				if tenpaiAt >= 0 {
+					tallies[w].tenpai++
+					tallies[w].tenpaiDraws += tenpaiAt
+				}
+				if winAt >= 0 {
+					tallies[w].wins++
+				}
+			}
+		}(w)
+	}
+	wg.Wait()
+	var total playoutTally
+	for _, t := range tallies {
+		total.tenpai += t.tenpai
+		total.wins += t.wins
+		total.tenpaiDraws += t.tenpaiDraws
+	}
+	return total
+}
+
+// Lists the unseen tiles of the KB, one entry per copy
+func unseenPool(kb KB) []int {
+	var pool []int
+	for id, count := range kb.remainingTiles {
+		for i := 0; i < count; i++ {
+			pool = append(pool, id)
+		}
+	}
+	return pool
+}
+
+// Estimates, for each candidate discard of a hand after drawing (14 tiles counting melds),
+// the probability of reaching tenpai and of winning by tsumo within cfg.Draws draws.
+// Walls are sampled from the unseen tiles of the KB; results are sorted by win rate,
+// then tenpai rate.
+func SimulateDiscards(hand Hand, openSets int, kb KB, cfg SimulationConfig) []DiscardSimulation {
+	pool := unseenPool(kb)
+	var results []DiscardSimulation
+	for id, count := range hand.counts {
+		if count == 0 {
+			continue
+		}
+		hand.counts[id]--
+		tally := simulateHand(hand, openSets, pool, cfg)
+		sim := DiscardSimulation{
+			Discard: ParseTile(id, false),
+			Shanten: CalculateDeficiency(hand, openSets),
+		}
+		if cfg.Samples > 0 {
+			sim.TenpaiRate = float64(tally.tenpai) / float64(cfg.Samples)
+			sim.WinRate = float64(tally.wins) / float64(cfg.Samples)
+		}
+		if tally.tenpai > 0 {
+			sim.AvgTenpai = float64(tally.tenpaiDraws) / float64(tally.tenpai)
+		}
+		hand.counts[id]++
+		results = append(results, sim)
+	}
+	sort.SliceStable(results, func(i, j int) bool {
+		if results[i].WinRate != results[j].WinRate {
+			return results[i].WinRate > results[j].WinRate
+		}
+		return results[i].TenpaiRate > results[j].TenpaiRate
+	})
+	return results
+}
+
+// Estimates the probability of reaching tenpai and of winning by tsumo within cfg.Draws draws
+// for a hand awaiting a draw (13 tiles counting melds)
+func SimulateWinRate(hand Hand, openSets int, kb KB, cfg SimulationConfig) (tenpaiRate, winRate float64) {
+	if cfg.Samples <= 0 {
+		return 0, 0
+	}
+	tally := simulateHand(hand, openSets, unseenPool(kb), cfg)
+	return float64(tally.tenpai) / float64(cfg.Samples), float64(tally.wins) / float64(cfg.Samples)
+}
@@ -0,0 +1,65 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestSimulateDiscards_Reproducible(t *testing.T) {
+	hand := handOf(1, 2, 4, 5, 11, 12, 13, 23, 24, 25, 13, 13, 30, 33)
+	kb := NewKB()
+	kb.RevealHand(hand)
+
+	cfg := SimulationConfig{Draws: 8, Samples: 200, Seed: 42, Workers: 1}
+	first := SimulateDiscards(hand, 0, kb, cfg)
+	cfg.Workers = 4
+	second := SimulateDiscards(hand, 0, kb, cfg)
+	if !reflect.DeepEqual(first, second) {
+		t.Errorf("SimulateDiscards() differs across worker counts")
+	}
+
+	for i, sim := range first {
+		if sim.TenpaiRate < sim.WinRate || sim.TenpaiRate > 1 || sim.WinRate < 0 {
+			t.Errorf("discard %v has invalid rates %v/%v", sim.Discard.ID, sim.TenpaiRate, sim.WinRate)
+		}
+		if i > 0 && first[i-1].WinRate < sim.WinRate {
+			t.Errorf("SimulateDiscards() results not sorted by win rate")
+		}
+	}
+}
+
+func TestSimulateDiscards_PrefersIsolatedTiles(t *testing.T) {
+	// Discarding the isolated honors keeps the 1-shanten, breaking a shape does not
+	hand := handOf(1, 2, 4, 5, 11, 12, 13, 23, 24, 25, 13, 13, 30, 33)
+	kb := NewKB()
+	kb.RevealHand(hand)
+	results := SimulateDiscards(hand, 0, kb, SimulationConfig{Draws: 10, Samples: 300, Seed: 7})
+
+	rates := map[int]float64{}
+	for _, sim := range results {
+		rates[sim.Discard.ID] = sim.TenpaiRate
+	}
+	if rates[30] <= rates[12] || rates[33] <= rates[12] {
+		t.Errorf("isolated honor discards %v/%v should beat breaking a set %v", rates[30], rates[33], rates[12])
+	}
+}
+
+func TestSimulateWinRate(t *testing.T) {
+	// Tenpai on 1m/4m with only winning tiles left unseen: one draw always wins
+	hand := handOf(1, 2, 4, 5, 6, 11, 12, 13, 23, 24, 25, 13, 13)
+	kb := KB{}
+	kb.remainingTiles[0] = 2
+	kb.remainingTiles[3] = 2
+	tenpai, win := SimulateWinRate(hand, 0, kb, SimulationConfig{Draws: 1, Samples: 50, Seed: 1})
+	if tenpai != 1 || win != 1 {
+		t.Errorf("SimulateWinRate() = %v, %v, want 1, 1", tenpai, win)
+	}
+
+	// With no winning tiles left the hand never wins
+	kb = KB{}
+	kb.remainingTiles[30] = 4
+	tenpai, win = SimulateWinRate(hand, 0, kb, SimulationConfig{Draws: 3, Samples: 50, Seed: 1})
+	if tenpai != 1 || win != 0 {
+		t.Errorf("SimulateWinRate() = %v, %v, want 1, 0", tenpai, win)
+	}
+}
@@ -0,0 +1,155 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+	"text/tabwriter"
+)
+
+// The nanikiru subcommand: ranks every discard of a hand by efficiency
+
+// Discard of a hand as written by nanikiru --json
+type discardJSON struct {
+	Discard      Tile        `json:"discard"`
+	Shanten      Deficiency  `json:"shanten"`
+	Ukeire       []tileCount `json:"ukeire"`
+	UkeireCount  int         `json:"ukeire_count"`
+	NextUkeire   float64     `json:"next_ukeire"`
+	Improvements []tileCount `json:"improvements"`
+	ImproveCount int         `json:"improve_count"`
+}
+
+// Unseen copies of a tile
+type tileCount struct {
+	Tile  Tile `json:"tile"`
+	Count int  `json:"count"`
+}
+
+// Lists tiles with their unseen copies
+func tileCounts(ids []int, kb KB) []tileCount {
+	counts := []tileCount{}
+	for _, id := range ids {
+		counts = append(counts, tileCount{ParseTile(id, false), kb.Remaining(id)})
+	}
+	return counts
+}
+
+// Converts ranked discards to their JSON form
+func discardJSONs(discards []DiscardEfficiency, kb KB) []discardJSON {
+	out := []discardJSON{}
+	for _, d := range discards {
+		out = append(out, discardJSON{
+			Discard: d.Tile, Shanten: d.Shanten,
+			Ukeire: tileCounts(d.Ukeire, kb), UkeireCount: d.UkeireCount, NextUkeire: d.NextUkeire,
+			Improvements: tileCounts(d.Improvements, kb), ImproveCount: d.ImproveCount,
+		})
+	}
+	return out
+}
+
+// Builds the concealed hand and the KB of tiles unseen by its owner, who also sees their
+// melds and the visible tiles; no tile may be shown more than four times
+func handKB(tiles []Tile, melds []Set, visible []Tile) (Hand, KB, error) {
+	var hand Hand
+	for _, t := range tiles {
+		hand.counts[t.ID]++
+	}
+	kb := NewKB()
+	kb.RevealHand(hand)
+	shown := hand
+	seen := slices.Clone(visible)
+	for _, m := range melds {
+		seen = append(seen, m.Tiles...)
+	}
+	for _, t := range seen {
+		kb.Reveal(t.ID)
+		shown.counts[t.ID]++
+	}
+	for id, count := range shown.counts {
+		if count > 4 {
+			return Hand{}, KB{}, fmt.Errorf("%d copies of %v", count, ParseTile(id, false))
+		}
+	}
+	return hand, kb, nil
+}
+
+// Runs the nanikiru subcommand
+func runNanikiru(args []string, stdout, stderr io.Writer) error {
+	fs := flag.NewFlagSet("nanikiru", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	fs.Usage = func() {
+		fmt.Fprintln(stderr, "usage: riichi-mahjong nanikiru [flags] hand")
+		fmt.Fprintln(stderr, "hand holds the concealed tiles in MPSZ notation after a draw, e.g. 13m456p789s123s11z9p")
+		fs.PrintDefaults()
+	}
+	visible := fs.String("visible", "", "other visible `tiles`: discards, calls of other players and dora indicators")
+	var melds meldFlags
+	fs.Var(&melds, "meld", "called set or closed kan as `kind:tiles` (chi, pon, kan or ankan), repeatable")
+	asJSON := fs.Bool("json", false, "write the discards as JSON")
+	positional, err := parseFlags(fs, args)
+	if err != nil {
+		return err
+	}
+	if len(positional) != 1 {
+		fs.Usage()
+		return errUsage
+	}
+
+	tiles, err := ParseTiles(positional[0])
+	if err != nil {
+		return err
+	}
+	if want := 14 - 3*len(melds); len(tiles) != want {
+		return fmt.Errorf("hand has %d tiles, want %d with %d melds", len(tiles), want, len(melds))
+	}
+	seen, err := ParseTiles(*visible)
+	if err != nil {
+		return err
+	}
+	hand, kb, err := handKB(tiles, melds, seen)
+	if err != nil {
+		return err
+	}
+	discards := AnalyzeDiscards(hand, len(melds), kb)
+	markRedDiscards(discards, tiles)
+	if *asJSON {
+		enc := json.NewEncoder(stdout)
+		enc.SetIndent("", "  ")
+		return enc.Encode(discardJSONs(discards, kb))
+	}
+	return writeDiscards(stdout, discards, kb)
+}
+
+// Marks a discard as a red five when the tiles hold no plain copy of it to discard
+func markRedDiscards(discards []DiscardEfficiency, tiles []Tile) {
+	plain := map[int]bool{}
+	for _, t := range tiles {
+		plain[t.ID] = plain[t.ID] || !t.Red
+	}
+	for i, d := range discards {
+		discards[i].Tile.Red = !plain[d.Tile.ID]
+	}
+}
+
+// Writes a table of ranked discards: the ukeire tiles with their unseen copies, the average
+// ukeire after the next step and the unseen copies of tiles improving the shape
+func writeDiscards(w io.Writer, discards []DiscardEfficiency, kb KB) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "DISCARD\tSHANTEN\tUKEIRE\tTILES\tNEXT\tIMPROVE")
+	for _, d := range discards {
+		var tiles []string
+		for _, c := range tileCounts(d.Ukeire, kb) {
+			tiles = append(tiles, fmt.Sprintf("%v(%d)", c.Tile, c.Count))
+		}
+		next := "-"
+		if d.Shanten > 0 {
+			next = fmt.Sprintf("%.1f", d.NextUkeire)
+		}
+		fmt.Fprintf(tw, "%v\t%d\t%d\t%s\t%s\t%d\n", d.Tile, d.Shanten, d.UkeireCount, strings.Join(tiles, " "), next, d.ImproveCount)
+	}
+	return tw.Flush()
+}
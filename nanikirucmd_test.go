@@ -0,0 +1,66 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func TestRunNanikiru(t *testing.T) {
+	var stdout bytes.Buffer
+	if err := runNanikiru([]string{"13m456p789s123s11z9p", "-visible", "4m4m"}, &stdout, &bytes.Buffer{}); err != nil {
+		t.Fatalf("runNanikiru() error = %v", err)
+	}
+	lines := strings.Split(strings.TrimSpace(stdout.String()), "\n")
+	if len(lines) != 14 || !strings.HasPrefix(lines[0], "DISCARD") {
+		t.Fatalf("runNanikiru() = %q, want a header and 13 discards", stdout.String())
+	}
+	// Two visible 4m leave two copies improving the kanchan
+	if fields := strings.Fields(lines[1]); len(fields) != 6 || fields[0] != "9p" || fields[1] != "0" || fields[3] != "2m(4)" || fields[5] != "2" {
+		t.Errorf("runNanikiru() best = %q", lines[1])
+	}
+}
+
+func TestRunNanikiru_JSON(t *testing.T) {
+	var stdout bytes.Buffer
+	args := []string{"--json", "-meld", "pon:777z", "23m0p67p789s11z9p"}
+	if err := runNanikiru(args, &stdout, &bytes.Buffer{}); err != nil {
+		t.Fatalf("runNanikiru() error = %v", err)
+	}
+	var got []discardJSON
+	if err := json.Unmarshal(stdout.Bytes(), &got); err != nil {
+		t.Fatalf("runNanikiru() = %q, error = %v", stdout.String(), err)
+	}
+	if len(got) != 10 {
+		t.Fatalf("runNanikiru() = %d discards, want 10", len(got))
+	}
+	best := got[0]
+	if best.Discard != ParseTile(17, false) || best.Shanten != 0 || best.UkeireCount != 8 || len(best.Ukeire) != 2 {
+		t.Errorf("runNanikiru() best = %+v, want 9p waiting on 1-4m", best)
+	}
+	for _, d := range got {
+		if d.Discard.ID == 13 && !d.Discard.Red {
+			t.Errorf("runNanikiru() discard %v, want the red 5p held", d.Discard)
+		}
+	}
+}
+
+func TestRunNanikiru_Invalid(t *testing.T) {
+	tests := []struct {
+		name string
+		args []string
+	}{
+		{"no hand", nil},
+		{"bad tiles", []string{"13m456p789s123s11z9x"}},
+		{"wrong tile count", []string{"13m456p789s123s11z"}},
+		{"too many copies", []string{"-visible", "1z1z1z", "13m456p789s123s11z9p"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := runNanikiru(tt.args, &bytes.Buffer{}, &bytes.Buffer{}); err == nil {
+				t.Errorf("runNanikiru() error = nil, want an error")
+			}
+		})
+	}
+}
@@ -0,0 +1,84 @@
+package main
+
+// Pao (sekinin barai): the seat that fed the meld completing Daisangen, Daisuushii or,
+// optionally, Suukantsu is liable for that yakuman
+
+// Records liability after a seat calls a set from another seat: feeding the third dragon
+// triplet, the fourth wind triplet or, when the rules allow, the fourth kan
+func (r *Round) checkPao(seat int, set Set) {
+	s := r.seats[seat]
+	if !set.Open || set.Target == seat {
+		return
+	}
+	id := set.Tiles[0].ID
+	if set.Type == Koutsu || set.Type == Kantsu {
+		if id >= 31 && countTriplets(s.melds, 31, 33) == 3 {
+			s.pao[Yaku_Daisangen{}.Name()] = set.Target
+		}
+		if id >= 27 && id <= 30 && countTriplets(s.melds, 27, 30) == 4 {
+			s.pao[Yaku_Daisuushii{}.Name()] = set.Target
+		}
+	}
+	r.checkKanPao(seat, set)
+}
+
+// Records Suukantsu liability, when the rules allow it, after a seat's open kan makes its
+// fourth: a called kan or an added kan, whose pon was fed by the same seat
+func (r *Round) checkKanPao(seat int, set Set) {
+	s := r.seats[seat]
+	if !r.Rules.PaoSuukantsu || set.Type != Kantsu || !set.Open || set.Target == seat {
+		return
+	}
+	if _, ok := (Yaku_Suukantsu{}).Check(Hand{}, s.melds, WinContext{}); ok {
+		s.pao[Yaku_Suukantsu{}.Name()] = set.Target
+	}
+}
+
+// Returns the seat liable for a winning score and the yakuman multiples it answers for,
+// or -1 when no pao applies
+func (r *Round) liability(seat int, score HandScore) (int, int) {
+	liable, count := -1, 0
+	for _, name := range score.Yaku {
+		if from, ok := r.seats[seat].pao[name]; ok {
+			// A hand with liability towards two seats is settled against the first
+			if liable < 0 || liable == from {
+				liable = from
+				count++
+			}
+		}
+	}
+	return liable, count
+}
+
+// Returns the ron value of a number of yakuman
+func yakumanRon(count int, dealer bool) int {
+	s := HandScore{Yakuman: count, Dealer: dealer}
+	s.computePayments()
+	return s.Ron
+}
+
+// Settles a tsumo under pao: the liable seat pays the liable yakuman in full as if it had
+// dealt in, plus all honba; the other seats pay their tsumo shares of any further yakuman
+func (r *Round) settlePaoTsumo(seat, liable, count int, score HandScore, result *RoundResult) {
+	n := r.players()
+	pay := yakumanRon(count, score.Dealer) + 100*r.Setup.Honba*(n-1)
+	result.Deltas[liable] -= pay
+	result.Deltas[seat] += pay
+
+	rest := HandScore{Yakuman: score.Yakuman - count, Dealer: score.Dealer, Tsumo: true}
+	if rest.Yakuman == 0 {
+		return
+	}
+	rest.computePayments()
+	r.payTsumo(seat, rest, 0, result.Deltas)
+}
+
+// Returns the part of a ron the liable seat pays: half of the liable yakuman, shared with
+// the discarder; nothing when the liable seat dealt in itself
+func (r *Round) paoRonShare(seat, from int, score HandScore) (int, int) {
+	liable, count := r.liability(seat, score)
+	if liable < 0 || liable == from {
+		return liable, 0
+	}
+	return liable, yakumanRon(count, score.Dealer) / 2
+}
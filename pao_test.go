@@ -0,0 +1,109 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+// Seat 1 holds open White and Green triplets and calls Red from seat 3, waiting on 1p
+func paoRound(t *testing.T, rules Rules) *Round {
+	t.Helper()
+	r := scriptedRound(rules, []ActionType{Action_Pass, Action_Pass, Action_Pass, Action_Pass},
+		nil, []int{0, 1, 2, 9, 33, 33, 20}, nil, []int{33})
+	r.seats[1].melds = []Set{
+		{Type: Koutsu, Tiles: tilesOf(31, 31, 31), Open: true, Target: 0},
+		{Type: Koutsu, Tiles: tilesOf(32, 32, 32), Open: true, Target: 2},
+	}
+	r.discard(3, ParseTile(33, false), false, false)
+	r.applyCall(1, 3, Action{Type: Action_Pon, Tile: ParseTile(33, false), Tiles: tilesOf(33, 33)})
+	r.seats[1].remove(ParseTile(20, false))
+	return r
+}
+
+func TestRound_CheckPao(t *testing.T) {
+	r := paoRound(t, DefaultRules())
+	if got, ok := r.seats[1].pao["Daisangen (Big Three Dragons)"]; !ok || got != 3 {
+		t.Errorf("pao = %v, want seat 3 liable for Daisangen", r.seats[1].pao)
+	}
+
+	t.Run("Suukantsu only when enabled", func(t *testing.T) {
+		for _, enabled := range []bool{false, true} {
+			rules := DefaultRules()
+			rules.PaoSuukantsu = enabled
+			r := scriptedRound(rules, []ActionType{Action_Pass, Action_Pass, Action_Pass, Action_Pass}, nil, []int{9, 9, 9, 18})
+			r.seats[1].melds = []Set{
+				{Type: Kantsu, Tiles: tilesOf(0, 0, 0, 0), Target: 1},
+				{Type: Kantsu, Tiles: tilesOf(1, 1, 1, 1), Target: 1},
+				{Type: Kantsu, Tiles: tilesOf(2, 2, 2, 2), Open: true, Target: 0},
+				{Type: Kantsu, Tiles: tilesOf(9, 9, 9, 9), Open: true, Target: 2},
+			}
+			r.checkPao(1, r.seats[1].melds[3])
+			if _, ok := r.seats[1].pao["Suukantsu (Four Quads)"]; ok != enabled {
+				t.Errorf("PaoSuukantsu %v: pao = %v", enabled, r.seats[1].pao)
+			}
+		}
+	})
+	t.Run("Suukantsu by an added kan", func(t *testing.T) {
+		rules := DefaultRules()
+		rules.PaoSuukantsu = true
+		r := scriptedRound(rules, []ActionType{Action_Pass, Action_Pass, Action_Pass, Action_Pass}, nil, []int{9, 18, 18, 27})
+		r.seats[1].melds = []Set{
+			{Type: Kantsu, Tiles: tilesOf(0, 0, 0, 0), Target: 1},
+			{Type: Kantsu, Tiles: tilesOf(1, 1, 1, 1), Target: 1},
+			{Type: Kantsu, Tiles: tilesOf(2, 2, 2, 2), Open: true, Target: 0},
+			{Type: Koutsu, Tiles: tilesOf(9, 9, 9), Open: true, Target: 3},
+		}
+		if _, robbed := r.declareKan(1, Action{Type: Action_Shouminkan, Tile: ParseTile(9, false)}); robbed {
+			t.Fatalf("declareKan() was robbed")
+		}
+		if got, ok := r.seats[1].pao["Suukantsu (Four Quads)"]; !ok || got != 3 {
+			t.Errorf("pao = %v, want seat 3 liable for Suukantsu", r.seats[1].pao)
+		}
+	})
+}
+
+func TestRound_PaoPayments(t *testing.T) {
+	t.Run("Tsumo is paid by the liable seat", func(t *testing.T) {
+		r := paoRound(t, DefaultRules())
+		r.seats[1].tiles = append(r.seats[1].tiles, ParseTile(9, false))
+		score, ok := r.scoreWin(1, ParseTile(9, false), true)
+		if !ok || score.Yakuman != 1 {
+			t.Fatalf("scoreWin() = %+v, want Daisangen", score)
+		}
+		result := r.settleTsumo(1, ParseTile(9, false), score)
+		if want := []int{0, 32000, 0, -32000}; !reflect.DeepEqual(result.Deltas, want) {
+			t.Errorf("settleTsumo() deltas = %v, want %v", result.Deltas, want)
+		}
+		if result.Wins[0].Liable != 3 {
+			t.Errorf("settleTsumo() liable = %v, want 3", result.Wins[0].Liable)
+		}
+	})
+
+	t.Run("Ron by another seat is split", func(t *testing.T) {
+		r := paoRound(t, DefaultRules())
+		result := r.settleRon(0, ParseTile(9, false), []int{1})
+		if want := []int{-16000, 32000, 0, -16000}; !reflect.DeepEqual(result.Deltas, want) {
+			t.Errorf("settleRon() deltas = %v, want %v", result.Deltas, want)
+		}
+	})
+
+	t.Run("Ron by the liable seat is paid in full", func(t *testing.T) {
+		r := paoRound(t, DefaultRules())
+		result := r.settleRon(3, ParseTile(9, false), []int{1})
+		if want := []int{0, 32000, 0, -32000}; !reflect.DeepEqual(result.Deltas, want) {
+			t.Errorf("settleRon() deltas = %v, want %v", result.Deltas, want)
+		}
+	})
+}
+
+func TestScoreHand_YakumanOnly(t *testing.T) {
+	melds := []Set{
+		{Type: Koutsu, Tiles: tilesOf(31, 31, 31), Open: true, Target: 0},
+		{Type: Koutsu, Tiles: tilesOf(32, 32, 32), Open: true, Target: 2},
+		{Type: Koutsu, Tiles: tilesOf(33, 33, 33), Open: true, Target: 3},
+	}
+	score, ok := ScoreHand(handOf(27, 27, 27, 28, 28), melds, WinContext{WinningTile: ParseTile(28, false), Tsumo: true})
+	if !ok || score.Yakuman != 1 || !reflect.DeepEqual(score.Yaku, []string{"Daisangen (Big Three Dragons)"}) {
+		t.Errorf("ScoreHand() = %v yakuman %v, want Daisangen alone", score.Yaku, score.Yakuman)
+	}
+}
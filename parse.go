@@ -113,3 +113,54 @@ func ValidateHand(hand []int) (bool, []Set) {
 	}
 	return false, nil
 }
+
+// Returns every way to split a concealed hand into a pair followed by sets;
+// unlike ValidateHand, four identical tiles are never treated as a Kantsu since
+// quads only exist once declared
+func AllDecompositions(hand []int) [][]Set {
+	var result [][]Set
+	for id, count := range hand {
+		if count >= 2 {
+			hand[id] -= 2
+			pair := Set{Type: Proto, Tiles: []Tile{ParseTile(id, false), ParseTile(id, false)}}
+			for _, sets := range allSetDecompositions(hand, 0) {
+				result = append(result, append([]Set{pair}, sets...))
+			}
+			hand[id] += 2
+		}
+	}
+	return result
+}
+
+// Recursively collects all decompositions of the remaining tiles into sets, starting at index start
+func allSetDecompositions(hand []int, start int) [][]Set {
+	id := start
+	for id < len(hand) && hand[id] == 0 {
+		id++
+	}
+	if id == len(hand) {
+		return [][]Set{nil}
+	}
+	var result [][]Set
+	if hand[id] >= 3 {
+		hand[id] -= 3
+		set := Set{Type: Koutsu, Tiles: []Tile{ParseTile(id, false), ParseTile(id, false), ParseTile(id, false)}}
+		for _, rest := range allSetDecompositions(hand, id) {
+			result = append(result, append([]Set{set}, rest...))
+		}
+		hand[id] += 3
+	}
+	if id <= 26 && id%9 <= 6 && hand[id+1] > 0 && hand[id+2] > 0 {
+		hand[id]--
+		hand[id+1]--
+		hand[id+2]--
+		set := Set{Type: Shuntsu, Tiles: []Tile{ParseTile(id, false), ParseTile(id+1, false), ParseTile(id+2, false)}}
+		for _, rest := range allSetDecompositions(hand, id) {
+			result = append(result, append([]Set{set}, rest...))
+		}
+		hand[id]++
+		hand[id+1]++
+		hand[id+2]++
+	}
+	return result
+}
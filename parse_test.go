@@ -345,3 +345,37 @@ func TestValidateHand_SetTypes(t *testing.T) {
 		}
 	})
 }
+
+func TestAllDecompositions(t *testing.T) {
+	tests := []struct {
+		name  string
+		hand  Hand
+		want  int
+		pairs []int
+	}{
+		{"Single decomposition", handOf(0, 1, 2, 3, 4, 5, 11, 12, 13, 23, 24, 25, 13, 13), 1, []int{13}},
+		{"Triplets or sequences", handOf(0, 0, 0, 1, 1, 1, 2, 2, 2, 23, 24, 25, 30, 30), 2, []int{30, 30}},
+		{"Two possible pairs", handOf(1, 1, 2, 2, 3, 3, 4, 4, 30, 30, 30, 9, 10, 11), 2, []int{1, 4}},
+		{"Four identical tiles are not a quad", handOf(0, 0, 0, 0, 9, 9, 9, 18, 18, 18, 30, 30, 30, 31), 0, nil},
+		{"Incomplete hand", handOf(0, 1, 3), 0, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := AllDecompositions(tt.hand.counts[:])
+			if len(got) != tt.want {
+				t.Fatalf("AllDecompositions() returned %v decompositions, want %v", len(got), tt.want)
+			}
+			for i, dcmp := range got {
+				if dcmp[0].Type != Proto || dcmp[0].Tiles[0].ID != tt.pairs[i] {
+					t.Errorf("AllDecompositions()[%d] pair = %v, want %v", i, dcmp[0].Tiles[0].ID, tt.pairs[i])
+				}
+				for _, set := range dcmp[1:] {
+					if set.Type == Kantsu {
+						t.Errorf("AllDecompositions()[%d] contains a Kantsu", i)
+					}
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,69 @@
+package main
+
+// Final placement scoring: raw points converted to placement points with uma and oka
+
+type TieBreak int
+
+const (
+	TieBreak_SeatOrder TieBreak = iota // tied seats are ranked by seat order from the first dealer
+	TieBreak_Split                     // tied seats share the uma and oka of the places they occupy
+)
+
+// Uma tables, in thousands of points per place
+var (
+	Uma_5_10    = []float64{10, 5, -5, -10}
+	Uma_10_20   = []float64{20, 10, -10, -20}
+	Uma_10_30   = []float64{30, 10, -10, -30}
+	Uma_15_45   = []float64{45, 15, -15, -45}
+	Uma_MLeague = []float64{30, 10, -10, -30} // M-League 30-10
+	Uma_Sanma15 = []float64{15, 0, -15}
+	Uma_Sanma20 = []float64{20, 0, -20}
+)
+
+// A seat's final result after placement scoring
+type PlacementResult struct {
+	Seat   int
+	Score  int     // raw points
+	Place  int     // 1 for first; tied seats share the higher place when split
+	Points float64 // placement points in thousands: (score - return) / 1000 + uma + oka
+}
+
+// Converts final scores to placement points. Every seat is measured against the return
+// points, the uma of each place is added, and the oka (the difference between the return
+// and starting points of every seat) goes to first place.
+func PlacementScores(scores []int, rules Rules) []PlacementResult {
+	n := len(scores)
+	standings := Standings(scores)
+	bonus := make([]float64, n) // uma and oka per place
+	for place := range bonus {
+		if place < len(rules.Uma) {
+			bonus[place] = rules.Uma[place]
+		}
+	}
+	bonus[0] += float64((rules.ReturnPoints-rules.StartingPoints)*n) / 1000
+
+	results := make([]PlacementResult, n)
+	for i := 0; i < n; {
+		// Group seats tied on score when splitting
+		j := i + 1
+		for rules.TieBreak == TieBreak_Split && j < n && standings[j].Score == standings[i].Score {
+			j++
+		}
+		share := 0.0
+		for k := i; k < j; k++ {
+			share += bonus[k]
+		}
+		share /= float64(j - i)
+		for k := i; k < j; k++ {
+			s := standings[k]
+			results[k] = PlacementResult{
+				Seat:   s.Seat,
+				Score:  s.Score,
+				Place:  i + 1,
+				Points: float64(s.Score-rules.ReturnPoints)/1000 + share,
+			}
+		}
+		i = j
+	}
+	return results
+}
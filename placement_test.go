@@ -0,0 +1,51 @@
+package main
+
+import (
+	"math"
+	"testing"
+)
+
+func TestPlacementScores(t *testing.T) {
+	rules := func(uma []float64, tie TieBreak, start, ret int) Rules {
+		r := DefaultRules()
+		r.Uma, r.TieBreak, r.StartingPoints, r.ReturnPoints = uma, tie, start, ret
+		return r
+	}
+
+	tests := []struct {
+		name       string
+		scores     []int
+		rules      Rules
+		wantSeats  []int
+		wantPlaces []int
+		wantPoints []float64
+	}{
+		{"10-20 with oka", []int{42000, 28000, 18000, 12000}, rules(Uma_10_20, TieBreak_SeatOrder, 25000, 30000),
+			[]int{0, 1, 2, 3}, []int{1, 2, 3, 4}, []float64{52, 8, -22, -38}},
+		{"M-League without oka", []int{20000, 35000, 25000, 20000}, rules(Uma_MLeague, TieBreak_SeatOrder, 25000, 25000),
+			[]int{1, 2, 0, 3}, []int{1, 2, 3, 4}, []float64{40, 10, -15, -35}},
+		{"Tie ranked by seat order", []int{30000, 20000, 30000, 20000}, rules(Uma_10_20, TieBreak_SeatOrder, 25000, 25000),
+			[]int{0, 2, 1, 3}, []int{1, 2, 3, 4}, []float64{25, 15, -15, -25}},
+		{"Tie split", []int{30000, 20000, 30000, 20000}, rules(Uma_10_20, TieBreak_Split, 25000, 25000),
+			[]int{0, 2, 1, 3}, []int{1, 1, 3, 3}, []float64{20, 20, -20, -20}},
+		{"Split oka between tied first places", []int{35000, 35000, 15000, 15000}, rules(Uma_5_10, TieBreak_Split, 25000, 30000),
+			[]int{0, 1, 2, 3}, []int{1, 1, 3, 3}, []float64{22.5, 22.5, -22.5, -22.5}},
+		{"Three players", []int{50000, 35000, 20000}, rules(Uma_Sanma15, TieBreak_SeatOrder, 35000, 40000),
+			[]int{0, 1, 2}, []int{1, 2, 3}, []float64{40, -5, -35}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := PlacementScores(tt.scores, tt.rules)
+			total := 0.0
+			for i, r := range got {
+				if r.Seat != tt.wantSeats[i] || r.Place != tt.wantPlaces[i] || math.Abs(r.Points-tt.wantPoints[i]) > 1e-9 {
+					t.Errorf("PlacementScores()[%d] = %+v, want seat %v place %v points %v", i, r, tt.wantSeats[i], tt.wantPlaces[i], tt.wantPoints[i])
+				}
+				total += r.Points
+			}
+			if math.Abs(total) > 1e-9 {
+				t.Errorf("PlacementScores() points sum to %v, want 0", total)
+			}
+		})
+	}
+}
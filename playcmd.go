@@ -0,0 +1,306 @@
+package main
+
+import (
+	"bufio"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// The play subcommand: a full match in the terminal against three bots
+
+// Names of the winds, East first
+var windNames = [4]string{"East", "South", "West", "North"}
+
+// Writes a tile as its Unicode mahjong glyph; red fives are followed by r
+func tileGlyph(t Tile) string {
+	var r rune
+	switch {
+	case t.ID < 0 || t.ID > 33:
+		return "?"
+	case t.ID <= 8:
+		r = 0x1F007 + rune(t.ID)
+	case t.ID <= 17:
+		r = 0x1F019 + rune(t.ID-9)
+	case t.ID <= 26:
+		r = 0x1F010 + rune(t.ID-18)
+	case t.ID <= 30:
+		r = 0x1F000 + rune(t.ID-27)
+	default:
+		// White, green and red dragons run backwards from U+1F006
+		r = 0x1F006 - rune(t.ID-31)
+	}
+	if t.Red {
+		return string(r) + "r"
+	}
+	return string(r)
+}
+
+// Plays one seat from a terminal: renders the table on each decision and reads the
+// choice from the input. At the end of the input every decision falls back to its default.
+type TerminalAgent struct {
+	in     *bufio.Scanner
+	out    io.Writer
+	glyphs bool // draw tiles with Unicode mahjong glyphs instead of MPSZ text
+}
+
+func NewTerminalAgent(in io.Reader, out io.Writer, glyphs bool) *TerminalAgent {
+	return &TerminalAgent{in: bufio.NewScanner(in), out: out, glyphs: glyphs}
+}
+
+func (a *TerminalAgent) Name() string { return "You" }
+
+// Writes a tile in the agent's tile style
+func (a *TerminalAgent) tile(t Tile) string {
+	if a.glyphs {
+		return tileGlyph(t)
+	}
+	return t.String()
+}
+
+// Writes tiles in the agent's tile style
+func (a *TerminalAgent) tiles(tiles []Tile) string {
+	var s []string
+	for _, t := range tiles {
+		s = append(s, a.tile(t))
+	}
+	return strings.Join(s, " ")
+}
+
+// Names a seat by its wind, marking the agent's own seat
+func seatName(view *PlayerView, seat int) string {
+	name := windNames[view.SeatWind(seat)]
+	if seat == view.Seat {
+		name += " (you)"
+	}
+	return name
+}
+
+func (a *TerminalAgent) OnEvent(view *PlayerView, ev Event) {
+	switch ev.Type {
+	case Event_StartRound:
+		fmt.Fprintf(a.out, "\n=== %s %d, %d honba ===\n", windNames[view.RoundWind], view.Dealer+1, view.Honba)
+		for seat, score := range view.Scores {
+			fmt.Fprintf(a.out, "  %-12s %6d\n", seatName(view, seat), score)
+		}
+	case Event_Call:
+		fmt.Fprintf(a.out, "%s calls %v: %s\n", seatName(view, ev.Seat), ev.Call, a.tiles(ev.Set.Tiles))
+	case Event_Riichi:
+		fmt.Fprintf(a.out, "%s declares riichi\n", seatName(view, ev.Seat))
+	case Event_Kita:
+		fmt.Fprintf(a.out, "%s sets aside a kita\n", seatName(view, ev.Seat))
+	case Event_NewDora:
+		if len(view.DoraIndicators) > 1 {
+			fmt.Fprintf(a.out, "New dora indicator: %s\n", a.tile(ev.Tile))
+		}
+	case Event_Win:
+		how := "tsumo"
+		if ev.From != ev.Seat {
+			how = "ron from " + seatName(view, ev.From)
+		}
+		s := ev.Score
+		fmt.Fprintf(a.out, "%s wins by %s on %s\n", seatName(view, ev.Seat), how, a.tile(ev.Tile))
+		fmt.Fprintf(a.out, "  %s\n", strings.Join(s.Yaku, ", "))
+		switch {
+		case s.Yakuman > 0:
+			fmt.Fprintf(a.out, "  Yakuman x%d: %d points\n", s.Yakuman, s.Total())
+		case s.Limit != "":
+			fmt.Fprintf(a.out, "  %d han %d fu, %s: %d points\n", s.Han, s.Fu, s.Limit, s.Total())
+		default:
+			fmt.Fprintf(a.out, "  %d han %d fu: %d points\n", s.Han, s.Fu, s.Total())
+		}
+	case Event_ExhaustiveDraw:
+		fmt.Fprintln(a.out, "Exhaustive draw")
+	case Event_AbortiveDraw:
+		fmt.Fprintf(a.out, "Abortive draw: %v\n", ev.Abortive)
+	}
+}
+
+// Renders the table: dora, every seat's score, melds and discards, then the agent's hand
+func (a *TerminalAgent) render(view *PlayerView) {
+	fmt.Fprintf(a.out, "\nDora indicators: %s   Tiles left: %d   Riichi sticks: %d\n",
+		a.tiles(view.DoraIndicators), view.WallRemaining, view.RiichiSticks)
+	for seat := range view.Discards {
+		status := ""
+		if view.Riichi[seat] {
+			status = " riichi"
+		}
+		fmt.Fprintf(a.out, "  %-12s %6d%s\n", seatName(view, seat), view.Scores[seat], status)
+		var discards []string
+		for _, d := range view.Discards[seat] {
+			s := a.tile(d.Tile)
+			switch {
+			case d.Riichi:
+				s = "[" + s + "]"
+			case d.Called:
+				s = "(" + s + ")"
+			}
+			discards = append(discards, s)
+		}
+		fmt.Fprintf(a.out, "    discards: %s\n", strings.Join(discards, " "))
+		if len(view.Melds[seat]) > 0 {
+			var melds []string
+			for _, m := range view.Melds[seat] {
+				melds = append(melds, a.tiles(m.Tiles))
+			}
+			fmt.Fprintf(a.out, "    melds: %s\n", strings.Join(melds, " | "))
+		}
+	}
+	tiles := view.Tiles
+	if view.Drawn != nil {
+		tiles = withoutTile(tiles, *view.Drawn)
+	}
+	fmt.Fprintf(a.out, "Your hand: %s", a.tiles(tiles))
+	if view.Drawn != nil {
+		fmt.Fprintf(a.out, "  + %s", a.tile(*view.Drawn))
+	}
+	fmt.Fprintln(a.out)
+}
+
+// Describes an action for the menu
+func (a *TerminalAgent) describe(o Action) string {
+	switch o.Type {
+	case Action_Chi, Action_Pon, Action_Daiminkan:
+		return fmt.Sprintf("%v %s with %s", o.Type, a.tile(o.Tile), a.tiles(o.Tiles))
+	case Action_Riichi, Action_Ankan, Action_Shouminkan, Action_Kita:
+		return fmt.Sprintf("%v %s", o.Type, a.tile(o.Tile))
+	}
+	return o.Type.String()
+}
+
+// Shows the numbered menu of actions and reads a choice until it is valid: a menu number,
+// a tile to discard or an empty line for the default. Returns the default at the end of the input.
+func (a *TerminalAgent) choose(prompt string, menu []Action, options []Action, def Action) Action {
+	for i, o := range menu {
+		fmt.Fprintf(a.out, "  [%d] %s\n", i+1, a.describe(o))
+	}
+	for {
+		fmt.Fprint(a.out, prompt)
+		if !a.in.Scan() {
+			fmt.Fprintln(a.out)
+			return def
+		}
+		line := strings.TrimSpace(a.in.Text())
+		if line == "" {
+			return def
+		}
+		if n, err := strconv.Atoi(line); err == nil && n >= 1 && n <= len(menu) {
+			return menu[n-1]
+		}
+		var t Tile
+		if err := t.UnmarshalText([]byte(line)); err == nil {
+			if _, ok := findAction(options, Action_Discard, t.ID); ok {
+				// Keep a red five unless it was asked for
+				for _, o := range options {
+					if t.Red && o.Type == Action_Discard && o.Tile == t {
+						return o
+					}
+				}
+				return discardOption(options, t.ID)
+			}
+		}
+		fmt.Fprintf(a.out, "Invalid choice %q\n", line)
+	}
+}
+
+func (a *TerminalAgent) ChooseAction(view *PlayerView, options []Action) Action {
+	a.render(view)
+	var menu []Action
+	for _, o := range options {
+		if o.Type != Action_Discard {
+			menu = append(menu, o)
+		}
+	}
+	def := options[0]
+	prompt := fmt.Sprintf("Discard a tile or choose an action (Enter discards %s): ", a.tile(def.Tile))
+	if def.Type != Action_Discard {
+		prompt = "Choose an action: "
+	}
+	return a.choose(prompt, menu, options, def)
+}
+
+func (a *TerminalAgent) ChooseCall(view *PlayerView, options []Action) Action {
+	var menu []Action
+	pass := options[len(options)-1]
+	for _, o := range options {
+		if o.Type != Action_Pass {
+			menu = append(menu, o)
+		}
+	}
+	fmt.Fprintf(a.out, "\nYour hand: %s\n", a.tiles(view.Tiles))
+	fmt.Fprintf(a.out, "You can claim %s:\n", a.tile(menu[0].Tile))
+	return a.choose("Choose a call (Enter passes): ", menu, nil, pass)
+}
+
+// Creates a bot by name
+func newBot(kind string, seed uint64) (Agent, error) {
+	switch kind {
+	case "greedy":
+		return NewGreedyAgent(), nil
+	case "defensive":
+		return NewDefensiveAgent(), nil
+	case "random":
+		return NewRandomAgent(seed), nil
+	}
+	return nil, fmt.Errorf("unknown bot %q, want greedy, defensive or random", kind)
+}
+
+// Runs the play subcommand, reading moves from standard input
+func runPlay(args []string, stdout, stderr io.Writer) error {
+	return playMatch(args, os.Stdin, stdout, stderr)
+}
+
+// Plays a match against bots, reading moves from in
+func playMatch(args []string, in io.Reader, stdout, stderr io.Writer) error {
+	fs := flag.NewFlagSet("play", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	fs.Usage = func() {
+		fmt.Fprintln(stderr, "usage: riichi-mahjong play [flags]")
+		fmt.Fprintln(stderr, "you sit East against three bots; discard by typing a tile such as 5p, or pick an action by number")
+		fs.PrintDefaults()
+	}
+	seed := fs.Uint64("seed", 0, "`seed` of the match (default random)")
+	bot := fs.String("bot", "defensive", "opponent `kind`: greedy, defensive or random")
+	ascii := fs.Bool("ascii", false, "draw tiles as MPSZ text instead of Unicode mahjong glyphs")
+	east := fs.Bool("tonpuusen", false, "play the East round only instead of a hanchan")
+	positional, err := parseFlags(fs, args)
+	if err != nil {
+		return err
+	}
+	if len(positional) != 0 {
+		fs.Usage()
+		return errUsage
+	}
+	if *seed == 0 {
+		*seed = uint64(time.Now().UnixNano())
+	}
+
+	rules := DefaultRules()
+	if *east {
+		rules.Length = Length_Tonpuusen
+	}
+	agents := []Agent{NewTerminalAgent(in, stdout, !*ascii)}
+	for seat := 1; seat < rules.Players; seat++ {
+		agent, err := newBot(*bot, *seed+uint64(seat))
+		if err != nil {
+			return err
+		}
+		agents = append(agents, agent)
+	}
+	fmt.Fprintf(stdout, "Match seed %d\n", *seed)
+	result := NewMatch(rules, *seed, agents).Play()
+
+	fmt.Fprintln(stdout, "\n=== Final standings ===")
+	for _, p := range result.Placement {
+		name := windNames[p.Seat]
+		if p.Seat == 0 {
+			name += " (you)"
+		}
+		fmt.Fprintf(stdout, "%d. %-12s %6d  %+.1f\n", p.Place, name, p.Score, p.Points)
+	}
+	return nil
+}
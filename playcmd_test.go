@@ -0,0 +1,82 @@
+package main
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestTileGlyph(t *testing.T) {
+	tests := []struct {
+		tile Tile
+		want string
+	}{
+		{ParseTile(0, false), "\U0001F007"},
+		{ParseTile(13, true), "\U0001F01Dr"},
+		{ParseTile(26, false), "\U0001F018"},
+		{ParseTile(27, false), "\U0001F000"},
+		{ParseTile(31, false), "\U0001F006"},
+		{ParseTile(33, false), "\U0001F004"},
+	}
+	for _, tt := range tests {
+		if got := tileGlyph(tt.tile); got != tt.want {
+			t.Errorf("tileGlyph(%v) = %q, want %q", tt.tile, got, tt.want)
+		}
+	}
+}
+
+func TestTerminalAgent_Choose(t *testing.T) {
+	options := []Action{
+		{Type: Action_Discard, Tile: ParseTile(4, false)},
+		{Type: Action_Discard, Tile: ParseTile(4, true)},
+		{Type: Action_Discard, Tile: ParseTile(31, false)},
+		{Type: Action_Tsumo, Tile: ParseTile(31, false)},
+	}
+	menu := options[3:]
+	tests := []struct {
+		name  string
+		input string
+		want  Action
+	}{
+		{"default", "\n", options[2]},
+		{"end of input", "", options[2]},
+		{"tile", "5m\n", options[0]},
+		{"red five", "0m\n", options[1]},
+		{"menu", "1\n", options[3]},
+		{"invalid then tile", "9p\n2\nxyz\n5z\n", options[2]},
+		{"hidden tile", "?\n", options[2]},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var out bytes.Buffer
+			a := NewTerminalAgent(strings.NewReader(tt.input), &out, false)
+			if got := a.choose("> ", menu, options, options[2]); got.Type != tt.want.Type || got.Tile != tt.want.Tile {
+				t.Errorf("choose() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPlayMatch(t *testing.T) {
+	var stdout, stderr bytes.Buffer
+	// Without input every decision takes its default: tsumogiri and passing on calls
+	if err := playMatch([]string{"-seed", "7", "-ascii", "-tonpuusen"}, strings.NewReader(""), &stdout, &stderr); err != nil {
+		t.Fatalf("playMatch() error = %v, stderr = %q", err, stderr.String())
+	}
+	out := stdout.String()
+	for _, want := range []string{"=== East 1, 0 honba ===", "Your hand: ", "=== Final standings ===", "(you)"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("playMatch() output missing %q", want)
+		}
+	}
+	if strings.ContainsRune(out, '\U0001F000') {
+		t.Errorf("playMatch() -ascii output has mahjong glyphs")
+	}
+}
+
+func TestPlayMatch_UnknownBot(t *testing.T) {
+	err := playMatch([]string{"-bot", "oracle"}, strings.NewReader(""), &bytes.Buffer{}, &bytes.Buffer{})
+	if err == nil || !strings.Contains(err.Error(), `unknown bot "oracle"`) {
+		t.Errorf("playMatch() error = %v, want unknown bot", err)
+	}
+}
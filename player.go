@@ -0,0 +1,252 @@
+package main
+
+// Player abstraction: events, actions and the view of the table given to agents
+
+type EventType int
+
+const (
+	Event_StartRound     EventType = iota // A round was dealt
+	Event_Draw                            // Seat drew Tile; the tile is hidden from other seats
+	Event_Discard                         // Seat discarded Tile
+	Event_Call                            // Seat called Set, taking the tile from Set.Target
+	Event_Riichi                          // Seat declared riichi with its next discard
+	Event_NewDora                         // Tile was revealed as a dora indicator
+	Event_Win                             // Seat won from From (the same seat on tsumo)
+	Event_ExhaustiveDraw                  // The wall ran out
+	Event_AbortiveDraw                    // The round was aborted
+	Event_Kita                            // Seat set a North aside as kita (sanma)
+)
+
+func (e EventType) String() string {
+	return [...]string{"StartRound", "Draw", "Discard", "Call", "Riichi", "NewDora", "Win", "ExhaustiveDraw", "AbortiveDraw", "Kita"}[e]
+}
+
+// Something that happened at the table
+type Event struct {
+	Type      EventType
+	Seat      int          `json:",omitzero"`
+	Tile      Tile         `json:",omitzero"`
+	Set       Set          `json:",omitzero"` // called set for Event_Call
+	Call      ActionType   `json:",omitzero"` // kind of call for Event_Call: chi, pon or one of the kans
+	Tsumogiri bool         `json:",omitzero"` // the discard was the tile just drawn
+	From      int          `json:",omitzero"` // seat dealt in for Event_Win
+	Score     HandScore    `json:",omitzero"` // score of the winning hand for Event_Win
+	Abortive  AbortiveDraw `json:",omitzero"` // kind of draw for Event_AbortiveDraw
+}
+
+type ActionType int
+
+const (
+	Action_Discard        ActionType = iota // Discard Tile
+	Action_Riichi                           // Declare riichi and discard Tile
+	Action_Tsumo                            // Win on the drawn tile
+	Action_Ankan                            // Declare a closed kan of Tile
+	Action_Shouminkan                       // Add Tile to a called triplet
+	Action_Pass                             // Decline every call
+	Action_Ron                              // Win on the discarded Tile
+	Action_Chi                              // Call Tile as a sequence with Tiles from the hand
+	Action_Pon                              // Call Tile as a triplet with Tiles from the hand
+	Action_Daiminkan                        // Call Tile as a quad with Tiles from the hand
+	Action_KyuushuKyuuhai                   // Abort the round on the first draw with nine different terminals and honors
+	Action_Kita                             // Set a North aside as bonus dora and draw a replacement (sanma)
+)
+
+func (a ActionType) String() string {
+	return [...]string{"Discard", "Riichi", "Tsumo", "Ankan", "Shouminkan", "Pass", "Ron", "Chi", "Pon", "Daiminkan", "Kyuushu Kyuuhai", "Kita"}[a]
+}
+
+// A decision offered to or made by an agent
+type Action struct {
+	Type  ActionType
+	Tile  Tile   // tile discarded, called or declared
+	Tiles []Tile // tiles from the hand used to form a called set
+}
+
+// A discarded tile as seen on the table
+type Discard struct {
+	Tile      Tile
+	Turn      int  // order of the discard across all seats in the round
+	Tsumogiri bool // the tile was discarded straight after drawing it
+	Riichi    bool // the tile declared riichi
+	Called    bool // the tile was claimed by another seat
+}
+
+// Everything a seat is allowed to see
+type PlayerView struct {
+	Seat           int
+	Dealer         int
+	RoundWind      int // 0 East, 1 South, ...
+	Honba          int
+	RiichiSticks   int
+	Scores         []int
+	Tiles          []Tile // the seat's concealed tiles, including a drawn tile
+	Drawn          *Tile  // the tile just drawn, nil when not on a draw
+	Melds          [][]Set
+	Discards       [][]Discard
+	Riichi         []bool
+	DoraIndicators []Tile
+	Kita           []int // Norths set aside by each seat in sanma
+	WallRemaining  int
+}
+
+// Agents decide for one seat. The engine only offers legal actions and always
+// accepts one of the offered actions back.
+type Agent interface {
+	// Name identifies the agent in logs and results
+	Name() string
+	// OnEvent is called for every event visible to the agent's seat, including its own draws
+	OnEvent(view *PlayerView, ev Event)
+	// ChooseAction is called on the agent's turn: discard, riichi, tsumo, a kan or kyuushu kyuuhai
+	ChooseAction(view *PlayerView, options []Action) Action
+	// ChooseCall is called when another seat's tile can be claimed: ron, pon, chi, kan or pass
+	ChooseCall(view *PlayerView, options []Action) Action
+}
+
+// Number of seats at the table
+func (v *PlayerView) Players() int {
+	return len(v.Discards)
+}
+
+// Wind of a seat (0 East) relative to the dealer
+func (v *PlayerView) SeatWind(seat int) int {
+	return (seat - v.Dealer + v.Players()) % v.Players()
+}
+
+// Concealed tiles of the seat as tile counts
+func (v *PlayerView) Hand() Hand {
+	var h Hand
+	for _, t := range v.Tiles {
+		h.counts[t.ID]++
+	}
+	return h
+}
+
+// Number of red fives held by the seat, concealed or called
+func (v *PlayerView) AkaDora() int {
+	count := 0
+	for _, t := range v.Tiles {
+		if t.Red {
+			count++
+		}
+	}
+	for _, m := range v.Melds[v.Seat] {
+		for _, t := range m.Tiles {
+			if t.Red {
+				count++
+			}
+		}
+	}
+	return count
+}
+
+// Builds the knowledge base of tiles unseen by the seat
+func (v *PlayerView) KB() KB {
+	kb := NewKB()
+	if v.Players() == 3 {
+		kb = NewSanmaKB()
+		for _, kita := range v.Kita {
+			for i := 0; i < kita; i++ {
+				kb.Reveal(30)
+			}
+		}
+	}
+	kb.RevealHand(v.Hand())
+	for _, melds := range v.Melds {
+		for _, m := range melds {
+			for _, t := range m.Tiles {
+				kb.Reveal(t.ID)
+			}
+		}
+	}
+	for _, discards := range v.Discards {
+		for _, d := range discards {
+			// Called tiles are already counted in the caller's meld
+			if !d.Called {
+				kb.Reveal(d.Tile.ID)
+			}
+		}
+	}
+	for _, t := range v.DoraIndicators {
+		kb.Reveal(t.ID)
+	}
+	return kb
+}
+
+// Describes every other seat for danger estimation, including tiles passed after their riichi
+func (v *PlayerView) Opponents() []OpponentState {
+	var opponents []OpponentState
+	for seat := range v.Discards {
+		if seat == v.Seat {
+			continue
+		}
+		o := OpponentState{Seat: seat, Riichi: v.Riichi[seat], Melds: v.Melds[seat]}
+		riichiTurn := -1
+		for _, d := range v.Discards[seat] {
+			o.Discards = append(o.Discards, d.Tile)
+			if d.Riichi {
+				riichiTurn = d.Turn
+			}
+		}
+		if riichiTurn >= 0 {
+			for other, discards := range v.Discards {
+				if other == seat {
+					continue
+				}
+				for _, d := range discards {
+					if d.Turn > riichiTurn {
+						o.PassedTiles = append(o.PassedTiles, d.Tile)
+					}
+				}
+			}
+		}
+		opponents = append(opponents, o)
+	}
+	return opponents
+}
+
+// Win context for the seat, without a winning tile
+func (v *PlayerView) WinContext() WinContext {
+	return WinContext{
+		Seat:           v.SeatWind(v.Seat),
+		Round:          v.RoundWind,
+		Riichi:         v.Riichi[v.Seat],
+		DoraIndicators: v.DoraIndicators,
+		AkaDora:        v.AkaDora(),
+		Sanma:          v.Players() == 3,
+		Kita:           v.kita(v.Seat),
+	}
+}
+
+// Number of Norths set aside by a seat
+func (v *PlayerView) kita(seat int) int {
+	if seat < len(v.Kita) {
+		return v.Kita[seat]
+	}
+	return 0
+}
+
+// Decision state for the seat after drawing
+func (v *PlayerView) DecisionState() DecisionState {
+	return DecisionState{
+		Hand:          v.Hand(),
+		Melds:         v.Melds[v.Seat],
+		KB:            v.KB(),
+		WinCtx:        v.WinContext(),
+		Opponents:     v.Opponents(),
+		Seat:          v.Seat,
+		Dealer:        v.Dealer,
+		Scores:        v.Scores,
+		WallRemaining: v.WallRemaining,
+		Riichi:        v.Riichi[v.Seat],
+	}
+}
+
+// Returns the first offered action of a type, optionally matching a tile ID (-1 matches any)
+func findAction(options []Action, actionType ActionType, id int) (Action, bool) {
+	for _, a := range options {
+		if a.Type == actionType && (id < 0 || a.Tile.ID == id) {
+			return a, true
+		}
+	}
+	return Action{}, false
+}
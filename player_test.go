@@ -0,0 +1,96 @@
+package main
+
+import (
+	"testing"
+)
+
+// Builds a four seat view for seat 0 holding the given tiles
+func testView(ids ...int) *PlayerView {
+	return &PlayerView{
+		Seat:          0,
+		Dealer:        0,
+		Scores:        []int{25000, 25000, 25000, 25000},
+		Tiles:         tilesOf(ids...),
+		Melds:         make([][]Set, 4),
+		Discards:      make([][]Discard, 4),
+		Riichi:        make([]bool, 4),
+		WallRemaining: 60,
+	}
+}
+
+func TestPlayerView_SeatWind(t *testing.T) {
+	v := testView()
+	v.Dealer = 2
+	tests := []struct {
+		seat int
+		want int
+	}{
+		{2, 0}, {3, 1}, {0, 2}, {1, 3},
+	}
+	for _, tt := range tests {
+		if got := v.SeatWind(tt.seat); got != tt.want {
+			t.Errorf("PlayerView.SeatWind(%d) = %v, want %v", tt.seat, got, tt.want)
+		}
+	}
+}
+
+func TestPlayerView_KB(t *testing.T) {
+	v := testView(0, 0, 4)
+	v.Melds[1] = []Set{{Type: Koutsu, Tiles: tilesOf(31, 31, 31), Open: true, Target: 2}}
+	v.Discards[2] = []Discard{{Tile: ParseTile(31, false), Called: true}, {Tile: ParseTile(4, false)}}
+	v.DoraIndicators = tilesOf(0)
+
+	kb := v.KB()
+	tests := []struct {
+		id   int
+		want int
+	}{
+		{0, 1},  // two in hand, one indicator
+		{4, 2},  // one in hand, one discarded
+		{31, 1}, // called tile counted once through the meld
+		{33, 4},
+	}
+	for _, tt := range tests {
+		if got := kb.Remaining(tt.id); got != tt.want {
+			t.Errorf("PlayerView.KB() remaining %v = %v, want %v", tt.id, got, tt.want)
+		}
+	}
+}
+
+func TestPlayerView_Opponents(t *testing.T) {
+	v := testView()
+	v.Riichi[1] = true
+	v.Discards[1] = []Discard{{Tile: ParseTile(27, false), Turn: 1}, {Tile: ParseTile(5, false), Turn: 5, Riichi: true}}
+	v.Discards[2] = []Discard{{Tile: ParseTile(9, false), Turn: 2}, {Tile: ParseTile(10, false), Turn: 6}}
+	v.Discards[0] = []Discard{{Tile: ParseTile(20, false), Turn: 0}, {Tile: ParseTile(21, false), Turn: 4}, {Tile: ParseTile(22, false), Turn: 8}}
+
+	opponents := v.Opponents()
+	if len(opponents) != 3 || opponents[0].Seat != 1 {
+		t.Fatalf("PlayerView.Opponents() = %+v", opponents)
+	}
+	riichi := opponents[0]
+	if !riichi.Riichi || len(riichi.Discards) != 2 {
+		t.Errorf("riichi opponent = %+v", riichi)
+	}
+	if len(riichi.PassedTiles) != 2 || riichi.PassedTiles[0].ID != 22 || riichi.PassedTiles[1].ID != 10 {
+		t.Errorf("riichi opponent passed tiles = %v, want [22 10]", riichi.PassedTiles)
+	}
+	if len(opponents[1].PassedTiles) != 0 {
+		t.Errorf("opponent without riichi has passed tiles %v", opponents[1].PassedTiles)
+	}
+}
+
+func TestPlayerView_WinContext(t *testing.T) {
+	v := testView(4, 13)
+	v.Tiles[0].Red = true
+	v.Seat = 3
+	v.Dealer = 1
+	v.RoundWind = 1
+	v.Riichi[3] = true
+	v.Melds[3] = []Set{{Type: Shuntsu, Tiles: []Tile{ParseTile(21, false), ParseTile(22, true), ParseTile(23, false)}, Open: true}}
+
+	ctx := v.WinContext()
+	if ctx.Seat != 2 || ctx.Round != 1 || !ctx.Riichi || ctx.AkaDora != 2 {
+		t.Errorf("PlayerView.WinContext() = %+v", ctx)
+	}
+}
@@ -0,0 +1,123 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestTile_Text(t *testing.T) {
+	tests := []struct {
+		tile Tile
+		want string
+	}{
+		{ParseTile(0, false), "1m"},
+		{ParseTile(13, false), "5p"},
+		{ParseTile(22, true), "0s"},
+		{ParseTile(27, false), "1z"},
+		{ParseTile(33, false), "7z"},
+	}
+	for _, tt := range tests {
+		if got := tt.tile.String(); got != tt.want {
+			t.Errorf("String() = %v, want %v", got, tt.want)
+		}
+		var back Tile
+		if err := back.UnmarshalText([]byte(tt.want)); err != nil || back != tt.tile {
+			t.Errorf("UnmarshalText(%q) = %+v, %v, want %+v", tt.want, back, err, tt.tile)
+		}
+	}
+	if got := (Tile{ID: -1}).String(); got != "?" {
+		t.Errorf("String() = %v, want ?", got)
+	}
+	for _, s := range []string{"", "?", "0z", "8z", "1x", "10m", "m1"} {
+		var tile Tile
+		if err := tile.UnmarshalText([]byte(s)); err == nil {
+			t.Errorf("UnmarshalText(%q) error = nil, want an error", s)
+		}
+	}
+}
+
+func testRecord(t *testing.T) *GameRecord {
+	t.Helper()
+	rules := DefaultRules()
+	rules.Length = Length_Tonpuusen
+	m := NewMatch(rules, 5, greedyAgents())
+	record := m.Record(m.Play())
+	return &record
+}
+
+func TestRecord_RoundTrip(t *testing.T) {
+	record := testRecord(t)
+	tests := []struct {
+		name  string
+		write func(*bytes.Buffer, *GameRecord) error
+	}{
+		{"JSON", func(b *bytes.Buffer, r *GameRecord) error { return WriteRecordJSON(b, r) }},
+		{"Binary", func(b *bytes.Buffer, r *GameRecord) error { return WriteRecordBinary(b, r) }},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			if err := tt.write(&buf, record); err != nil {
+				t.Fatalf("write error = %v", err)
+			}
+			got, err := ReadRecord(&buf)
+			if err != nil {
+				t.Fatalf("ReadRecord() error = %v", err)
+			}
+			if diffs := DiffRecords(record, got); len(diffs) > 0 || got.Seed != 5 || len(got.Players) != 4 {
+				t.Errorf("ReadRecord() differs: %v", diffs)
+			}
+		})
+	}
+
+	var js, bin bytes.Buffer
+	WriteRecordJSON(&js, record)
+	WriteRecordBinary(&bin, record)
+	if bin.Len() >= js.Len()/4 {
+		t.Errorf("binary record = %d bytes, want well under the %d bytes of JSON", bin.Len(), js.Len())
+	}
+}
+
+func TestEvent_JSON(t *testing.T) {
+	for _, ev := range []Event{
+		{Type: Event_Draw, Seat: 1, Tile: Tile{ID: -1}},
+		{Type: Event_Discard, Seat: 2, Tile: ParseTile(22, true), Tsumogiri: true},
+		{Type: Event_Draw},
+	} {
+		data, err := json.Marshal(ev)
+		if err != nil {
+			t.Fatalf("json.Marshal() error = %v", err)
+		}
+		var got Event
+		if err := json.Unmarshal(data, &got); err != nil || !reflect.DeepEqual(got, ev) {
+			t.Errorf("json.Unmarshal(%s) = %+v, %v, want %+v", data, got, err, ev)
+		}
+	}
+	var ev Event
+	if err := json.Unmarshal([]byte(`{"Type":1,"Tile":"8z"}`), &ev); err == nil {
+		t.Errorf("json.Unmarshal() of tile 8z error = nil, want an error")
+	}
+}
+
+func TestReadRecord_Header(t *testing.T) {
+	var buf bytes.Buffer
+	WriteRecordJSON(&buf, &GameRecord{Rules: DefaultRules()})
+	var header map[string]any
+	if err := json.Unmarshal(buf.Bytes(), &header); err != nil || header["Format"] != recordFormat || header["Version"] != float64(RecordVersion) {
+		t.Errorf("WriteRecordJSON() header = %v %v", header["Format"], header["Version"])
+	}
+	for _, input := range []string{
+		`{"Format":"something-else","Version":1}`,
+		`{"Format":"riichi-mahjong-record","Version":99}`,
+		`{"Format":"riichi-mahjong-record","Version":0}`,
+		recordMagic + "not gzip",
+		`{`,
+	} {
+		if _, err := ReadRecord(strings.NewReader(input)); err == nil {
+			t.Errorf("ReadRecord(%q) error = nil, want an error", input)
+		}
+	}
+}
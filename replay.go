@@ -0,0 +1,182 @@
+package main
+
+import (
+	"fmt"
+	"reflect"
+	"slices"
+)
+
+// Re-simulation of recorded games: every seat replays its recorded decisions on a round dealt
+// again from the recorded seed, so the engine must produce the recorded events once more
+
+// Recorded events of a round, shared by the replaying seats
+type replayLog struct {
+	events []Event
+	pos    int   // recorded event the engine emits next
+	err    error // first decision that could not be replayed
+}
+
+// Makes the decisions shown by the recorded events
+type replayAgent struct {
+	seat int
+	log  *replayLog
+}
+
+func (a *replayAgent) Name() string { return "Replay" }
+
+func (a *replayAgent) OnEvent(view *PlayerView, ev Event) {
+	// Every seat is told of every event; the first seat keeps count
+	if a.seat == 0 {
+		a.log.pos++
+	}
+}
+
+func (a *replayAgent) ChooseAction(view *PlayerView, options []Action) Action {
+	l := a.log
+	if l.pos < len(l.events) {
+		ev := l.events[l.pos]
+		var next Event
+		if l.pos+1 < len(l.events) {
+			next = l.events[l.pos+1]
+		}
+		for _, o := range options {
+			if recordedTurn(o, ev, next, a.seat) {
+				return o
+			}
+		}
+	}
+	l.fail(a.seat)
+	return options[0]
+}
+
+func (a *replayAgent) ChooseCall(view *PlayerView, options []Action) Action {
+	want := a.log.recordedCall(a.seat)
+	for _, o := range options {
+		if o.Type != want.Type {
+			continue
+		}
+		if want.Type != Action_Chi && want.Type != Action_Pon && want.Type != Action_Daiminkan {
+			return o
+		}
+		ev := a.log.events[a.log.pos]
+		if slices.Equal(callSet(o, ev.Set.Target).Tiles, ev.Set.Tiles) {
+			return o
+		}
+	}
+	a.log.fail(a.seat)
+	return options[len(options)-1]
+}
+
+// Records the first decision that was not among the offered actions
+func (l *replayLog) fail(seat int) {
+	if l.err == nil {
+		l.err = fmt.Errorf("event %d: the recorded decision of seat %d was not offered", l.pos, seat)
+	}
+}
+
+// Reports whether an offered turn action is the decision shown by the recorded event;
+// a riichi shows its tile in the discard that follows
+func recordedTurn(o Action, ev, next Event, seat int) bool {
+	if ev.Seat != seat && ev.Type != Event_AbortiveDraw {
+		return false
+	}
+	switch ev.Type {
+	case Event_Discard:
+		return o.Type == Action_Discard && o.Tile == ev.Tile
+	case Event_Riichi:
+		return o.Type == Action_Riichi && o.Tile == next.Tile
+	case Event_Win:
+		return o.Type == Action_Tsumo
+	case Event_Call:
+		return o.Type == ev.Call && o.Tile == ev.Tile
+	case Event_Kita:
+		return o.Type == Action_Kita
+	case Event_AbortiveDraw:
+		return o.Type == Action_KyuushuKyuuhai
+	}
+	return false
+}
+
+// Returns the kind of response recorded for a seat offered a discarded or added tile:
+// a ron among the wins that follow, the recorded call, or a pass
+func (l *replayLog) recordedCall(seat int) Action {
+	for i := l.pos; i < len(l.events) && l.events[i].Type == Event_Win; i++ {
+		if l.events[i].Seat == seat {
+			return Action{Type: Action_Ron}
+		}
+	}
+	if l.pos < len(l.events) {
+		if ev := l.events[l.pos]; ev.Type == Event_Call && ev.Seat == seat {
+			return Action{Type: ev.Call}
+		}
+	}
+	return Action{Type: Action_Pass}
+}
+
+// Re-simulates a recorded round and returns the new record of it
+func ReplayRound(rules Rules, record RoundRecord) (RoundRecord, error) {
+	log := &replayLog{events: record.Events}
+	agents := make([]Agent, len(record.Setup.Scores))
+	for seat := range agents {
+		agents[seat] = &replayAgent{seat: seat, log: log}
+	}
+	r := NewRound(rules, record.Setup, agents)
+	return r.Record(r.Play()), log.err
+}
+
+// Re-simulates every round of a recorded game
+func ReplayGame(record *GameRecord) (*GameRecord, error) {
+	replayed := &GameRecord{Players: record.Players, Rules: record.Rules, Seed: record.Seed}
+	for i, round := range record.Rounds {
+		got, err := ReplayRound(record.Rules, round)
+		if err != nil {
+			return nil, fmt.Errorf("round %d: %w", i+1, err)
+		}
+		replayed.Rounds = append(replayed.Rounds, got)
+	}
+	return replayed, nil
+}
+
+// Describes an event for a difference report
+func describeEvent(ev Event) string {
+	switch ev.Type {
+	case Event_StartRound, Event_NewDora, Event_ExhaustiveDraw, Event_AbortiveDraw:
+		return fmt.Sprintf("%v %v", ev.Type, ev.Tile)
+	case Event_Call:
+		return fmt.Sprintf("%v %v by seat %d", ev.Call, ev.Set.Tiles, ev.Seat)
+	}
+	return fmt.Sprintf("%v %v by seat %d", ev.Type, ev.Tile, ev.Seat)
+}
+
+// Lists the differences between two records of the same game, such as a recorded game and
+// its replay on another engine version: per round the setup, the first differing event and the result
+func DiffRecords(want, got *GameRecord) []string {
+	var diffs []string
+	if len(want.Rounds) != len(got.Rounds) {
+		diffs = append(diffs, fmt.Sprintf("%d rounds, want %d", len(got.Rounds), len(want.Rounds)))
+	}
+	for i := range min(len(want.Rounds), len(got.Rounds)) {
+		a, b := want.Rounds[i], got.Rounds[i]
+		if !reflect.DeepEqual(a.Setup, b.Setup) || !reflect.DeepEqual(a.Hands, b.Hands) {
+			diffs = append(diffs, fmt.Sprintf("round %d: setup %+v, want %+v", i+1, b.Setup, a.Setup))
+			continue
+		}
+		for j := range max(len(a.Events), len(b.Events)) {
+			switch {
+			case j >= len(a.Events):
+				diffs = append(diffs, fmt.Sprintf("round %d: event %d is %s, want the end", i+1, j, describeEvent(b.Events[j])))
+			case j >= len(b.Events):
+				diffs = append(diffs, fmt.Sprintf("round %d: event %d is the end, want %s", i+1, j, describeEvent(a.Events[j])))
+			case !reflect.DeepEqual(a.Events[j], b.Events[j]):
+				diffs = append(diffs, fmt.Sprintf("round %d: event %d is %s, want %s", i+1, j, describeEvent(b.Events[j]), describeEvent(a.Events[j])))
+			default:
+				continue
+			}
+			break
+		}
+		if !reflect.DeepEqual(a.Result, b.Result) {
+			diffs = append(diffs, fmt.Sprintf("round %d: result %+v, want %+v", i+1, b.Result, a.Result))
+		}
+	}
+	return diffs
+}
@@ -0,0 +1,88 @@
+package main
+
+import (
+	"flag"
+	"os"
+	"testing"
+)
+
+var updateGolden = flag.Bool("update", false, "rewrite the golden game records in testdata")
+
+func TestReplayGame(t *testing.T) {
+	record := testRecord(t)
+	replayed, err := ReplayGame(record)
+	if err != nil {
+		t.Fatalf("ReplayGame() error = %v", err)
+	}
+	if diffs := DiffRecords(record, replayed); len(diffs) > 0 {
+		t.Errorf("ReplayGame() differs: %v", diffs)
+	}
+}
+
+func TestReplayRound_Divergence(t *testing.T) {
+	record := testRecord(t)
+	round := record.Rounds[0]
+	// Change a discard to a tile the seat does not hold
+	for i, ev := range round.Events {
+		if ev.Type == Event_Discard {
+			events := append([]Event{}, round.Events...)
+			events[i].Tile = ParseTile((ev.Tile.ID+1)%34, false)
+			if events[i].Tile.ID == 4 {
+				events[i].Tile.ID = 5
+			}
+			round.Events = events
+			break
+		}
+	}
+	if _, err := ReplayRound(record.Rules, round); err == nil {
+		t.Errorf("ReplayRound() of an altered record error = nil, want an error")
+	}
+}
+
+func TestDiffRecords(t *testing.T) {
+	record := testRecord(t)
+	changed := *record
+	changed.Rounds = append([]RoundRecord{}, record.Rounds...)
+	changed.Rounds[0].Events = append([]Event{}, record.Rounds[0].Events...)
+	changed.Rounds[0].Events[5].Seat = (changed.Rounds[0].Events[5].Seat + 1) % 4
+	changed.Rounds = changed.Rounds[:len(changed.Rounds)-1]
+	diffs := DiffRecords(record, &changed)
+	if len(diffs) != 2 {
+		t.Errorf("DiffRecords() = %v, want the round count and one event", diffs)
+	}
+	if diffs := DiffRecords(record, record); len(diffs) != 0 {
+		t.Errorf("DiffRecords() of a record with itself = %v", diffs)
+	}
+}
+
+// The golden record pins the engine's behaviour: the same seed and agents must play the same
+// game, and the recorded game must replay event for event. Run with -update after an
+// intended change to the engine.
+func TestGoldenRecord(t *testing.T) {
+	const path = "testdata/golden_tonpuusen.json"
+	record := testRecord(t)
+	if *updateGolden {
+		f, err := os.Create(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer f.Close()
+		if err := WriteRecordJSON(f, record); err != nil {
+			t.Fatal(err)
+		}
+	}
+	golden, err := ReadRecordFile(path)
+	if err != nil {
+		t.Fatalf("ReadRecordFile() error = %v", err)
+	}
+	if diffs := DiffRecords(golden, record); len(diffs) > 0 {
+		t.Errorf("played game differs from %s: %v", path, diffs)
+	}
+	replayed, err := ReplayGame(golden)
+	if err != nil {
+		t.Fatalf("ReplayGame() error = %v", err)
+	}
+	if diffs := DiffRecords(golden, replayed); len(diffs) > 0 {
+		t.Errorf("replayed game differs from %s: %v", path, diffs)
+	}
+}
@@ -0,0 +1,62 @@
+package main
+
+// Riichi declaration, deposits and ippatsu
+
+const (
+	riichiDeposit = 1000 // points put on the table when declaring riichi
+	riichiMinWall = 4    // live wall tiles needed to declare riichi
+)
+
+// Lists the riichi declarations open to a seat: its hand must be closed, it must not
+// already be in riichi, it must have the deposit and riichiMinWall tiles left to draw,
+// and the hand must be tenpai after the discard
+func (r *Round) riichiOptions(seat int, drawn *Tile) []Action {
+	s := r.seats[seat]
+	if drawn == nil || s.riichi || r.scores[seat] < riichiDeposit || r.wall.Remaining() < riichiMinWall {
+		return nil
+	}
+	for _, m := range s.melds {
+		if m.Open {
+			return nil
+		}
+	}
+	var options []Action
+	seen := map[Tile]bool{}
+	for _, t := range append([]Tile{*drawn}, s.tiles...) {
+		if seen[t] {
+			continue
+		}
+		seen[t] = true
+		hand := s.hand()
+		hand.counts[t.ID]--
+		if CalculateDeficiency(hand, len(s.melds)) == 0 {
+			options = append(options, Action{Type: Action_Riichi, Tile: t})
+		}
+	}
+	return options
+}
+
+// Declares riichi for a seat ahead of its discard; a declaration on the seat's first
+// discard with no call made yet is a double riichi
+func (r *Round) declareRiichi(seat int) {
+	s := r.seats[seat]
+	s.riichi = true
+	s.doubleRiichi = len(s.discards) == 0 && !r.interrupted
+	r.emit(Event{Type: Event_Riichi, Seat: seat})
+}
+
+// Takes the riichi deposit once the declaration tile has passed without a ron and
+// starts the seat's ippatsu window
+func (r *Round) payRiichiDeposit(seat int) {
+	r.scores[seat] -= riichiDeposit
+	r.sticks++
+	r.seats[seat].ippatsu = true
+}
+
+// Records a call or kan: it ends every ippatsu window and the uninterrupted first go-around
+func (r *Round) interrupt() {
+	r.interrupted = true
+	for _, s := range r.seats {
+		s.ippatsu = false
+	}
+}
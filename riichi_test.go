@@ -0,0 +1,99 @@
+package main
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestRound_RiichiOptions(t *testing.T) {
+	tenpai := []int{1, 2, 3, 4, 5, 11, 12, 13, 23, 24, 25, 13, 13, 31} // discarding White leaves 1m/4m/7m
+	pass := []ActionType{Action_Pass, Action_Pass, Action_Pass, Action_Pass}
+	drawn := ParseTile(31, false)
+
+	tests := []struct {
+		name  string
+		setup func(r *Round)
+		want  int
+	}{
+		{"Closed tenpai", func(r *Round) {}, 1},
+		{"Open hand", func(r *Round) {
+			r.seats[0].melds = []Set{{Type: Koutsu, Tiles: tilesOf(30, 30, 30), Open: true, Target: 1}}
+		}, 0},
+		{"Closed kan keeps riichi open", func(r *Round) {
+			r.seats[0].tiles = tilesOf(1, 2, 3, 4, 5, 11, 12, 13, 13, 13, 31)
+			r.seats[0].melds = []Set{{Type: Kantsu, Tiles: tilesOf(30, 30, 30, 30), Target: 0}}
+		}, 1},
+		{"Not enough points", func(r *Round) { r.scores[0] = 900 }, 0},
+		{"Already in riichi", func(r *Round) { r.seats[0].riichi = true }, 0},
+		{"Too few tiles left", func(r *Round) {
+			for r.wall.Remaining() >= riichiMinWall {
+				r.wall.Draw()
+			}
+		}, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := scriptedRound(DefaultRules(), pass, tenpai)
+			tt.setup(r)
+			got := r.riichiOptions(0, &drawn)
+			if len(got) != tt.want {
+				t.Fatalf("riichiOptions() = %v, want %d options", got, tt.want)
+			}
+			if tt.want > 0 && got[0].Tile.ID != 31 {
+				t.Errorf("riichiOptions() discard = %v, want White", got[0].Tile)
+			}
+		})
+	}
+}
+
+func TestRound_RiichiForcesTsumogiri(t *testing.T) {
+	r := scriptedRound(DefaultRules(), []ActionType{Action_Pass, Action_Pass, Action_Pass, Action_Pass},
+		[]int{1, 2, 3, 4, 5, 11, 12, 13, 23, 24, 25, 13, 13, 30})
+	r.seats[0].riichi = true
+	drawn := ParseTile(30, false)
+	for _, o := range r.turnOptions(0, &drawn, nil) {
+		if o.Type == Action_Discard && o.Tile != drawn {
+			t.Errorf("turnOptions() in riichi offers discard %v, want only the drawn tile", o.Tile)
+		}
+		if o.Type == Action_Riichi {
+			t.Errorf("turnOptions() in riichi offers another riichi")
+		}
+	}
+}
+
+func TestRound_RiichiDepositAndIppatsu(t *testing.T) {
+	ronHand := []int{1, 2, 3, 4, 5, 11, 12, 13, 23, 24, 25, 13, 13} // waits on 1m/4m/7m
+	r := scriptedRound(DefaultRules(), []ActionType{Action_Pass, Action_Pass, Action_Pass, Action_Pass}, append(ronHand, 31))
+
+	r.declareRiichi(0)
+	if !r.seats[0].doubleRiichi {
+		t.Errorf("declareRiichi() on the first discard should be a double riichi")
+	}
+	r.discard(0, ParseTile(31, false), true, true)
+	r.payRiichiDeposit(0)
+	if r.scores[0] != 24000 || r.sticks != 1 || !r.seats[0].ippatsu {
+		t.Errorf("payRiichiDeposit() score %v sticks %v ippatsu %v, want 24000, 1, true", r.scores[0], r.sticks, r.seats[0].ippatsu)
+	}
+	if d := r.seats[0].discards[0]; !d.Riichi {
+		t.Errorf("riichi discard not marked: %+v", d)
+	}
+
+	score, ok := r.scoreWin(0, ParseTile(3, false), false)
+	for _, yaku := range []string{"Double Riichi", "Ippatsu (One-shot)"} {
+		if !ok || !slices.Contains(score.Yaku, yaku) {
+			t.Errorf("scoreWin() yaku = %v, want %v", score.Yaku, yaku)
+		}
+	}
+	if slices.Contains(score.Yaku, "Riichi") {
+		t.Errorf("scoreWin() yaku = %v, Riichi should not stack with Double Riichi", score.Yaku)
+	}
+
+	r.interrupt()
+	if r.seats[0].ippatsu {
+		t.Errorf("interrupt() should cancel ippatsu")
+	}
+	r.declareRiichi(1)
+	if r.seats[1].doubleRiichi {
+		t.Errorf("declareRiichi() after a call should not be a double riichi")
+	}
+}
@@ -0,0 +1,70 @@
+package main
+
+// Rule set options for the game engine
+
+type Rules struct {
+	Players        int    // 4, or 3 for sanma
+	RedFives       [3]int // red fives in each suit (Manzu, Pinzu, Souzu), each 0-4
+	StartingPoints int    // points each player starts with
+	MultipleRon    bool   // allow double and triple ron; otherwise only the first seat after the discarder wins (atamahane)
+
+	OpenKanDoraAfterDiscard bool // reveal the dora of open kans after the next discard rather than immediately
+	KokushiAnkanChankan     bool // allow Kokushi Musou to rob a closed kan
+	NagashiMangan           bool // pay mangan at an exhaustive draw to a seat that discarded only terminals and honors, none called
+	NotenBappu              int  // points paid in total by noten seats to tenpai seats at an exhaustive draw
+	PaoSuukantsu            bool // the seat feeding the fourth kan is liable for Suukantsu, as for Daisangen and Daisuushii
+	SanmaTsumo              SanmaTsumo
+
+	// Abortive draws
+	KyuushuKyuuhai bool // offer an abortive draw on a first draw with nine different terminals and honors
+	SuufonRenda    bool // abort when every seat discards the same wind on the first go-around
+	SuuchaRiichi   bool // abort when every seat has declared riichi
+	Suukaikan      bool // abort after the fourth kan unless one seat made them all
+	Sanchahou      bool // abort on a triple ron instead of paying all three winners, or the first under atamahane
+
+	// Match progression
+	Length                MatchLength
+	TargetPoints          int  // points needed to end the match; below it the match goes into extension
+	WestExtension         bool // play one more round wind when nobody has reached the target, ending as soon as someone does
+	AgariYame             bool // the dealer may end the last hand by winning while in first place
+	TenpaiYame            bool // the dealer may end the last hand by being tenpai at a draw while in first place
+	Tobi                  bool // end the match when a seat drops below zero
+	LeftoverSticksToFirst bool // riichi sticks left at the end go to first place
+
+	// Placement scoring
+	ReturnPoints int       // points each seat is measured against; the difference from StartingPoints is the oka
+	Uma          []float64 // uma per place in thousands of points
+	TieBreak     TieBreak
+}
+
+// Returns the common online rule set: a hanchan with one red five per suit, 25000 starting
+// points, 30000 to finish, multiple ron and bust at below zero
+func DefaultRules() Rules {
+	return Rules{
+		Players:        4,
+		RedFives:       [3]int{1, 1, 1},
+		StartingPoints: 25000,
+		MultipleRon:    true,
+
+		OpenKanDoraAfterDiscard: true,
+		KokushiAnkanChankan:     true,
+		NagashiMangan:           true,
+		NotenBappu:              3000,
+
+		KyuushuKyuuhai: true,
+		SuufonRenda:    true,
+		SuuchaRiichi:   true,
+		Suukaikan:      true,
+
+		Length:                Length_Hanchan,
+		TargetPoints:          30000,
+		WestExtension:         true,
+		AgariYame:             true,
+		Tobi:                  true,
+		LeftoverSticksToFirst: true,
+
+		ReturnPoints: 30000,
+		Uma:          Uma_10_20,
+		TieBreak:     TieBreak_SeatOrder,
+	}
+}
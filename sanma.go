@@ -0,0 +1,76 @@
+package main
+
+// Three-player (sanma) rules: a wall without 2-8 man, kita (nukidora) and tsumo payments
+
+type SanmaTsumo int
+
+const (
+	SanmaTsumo_Loss           SanmaTsumo = iota // a tsumo is paid by the two other seats only; the missing share is lost
+	SanmaTsumo_NorthBisection                   // the missing non-dealer share is split between the two payers
+)
+
+// Returns the common three-player rule set: no 2-8 man, red 5p and 5s, 35000 starting
+// points, 40000 to finish, kita as bonus dora and tsumo loss
+func SanmaRules() Rules {
+	r := DefaultRules()
+	r.Players = 3
+	r.RedFives = [3]int{0, 1, 1}
+	r.StartingPoints = 35000
+	r.ReturnPoints = 40000
+	r.TargetPoints = 40000
+	r.Uma = Uma_Sanma15
+	r.NotenBappu = 2000
+	r.SanmaTsumo = SanmaTsumo_Loss
+	return r
+}
+
+// Reports whether a tile type is removed from the three-player set (2-8 man)
+func sanmaExcluded(id int) bool {
+	return id >= 1 && id <= 7
+}
+
+// Returns the dora indicated by an indicator; in sanma the man suit wraps 1m to 9m and 9m to 1m
+func doraFor(indicator Tile, sanma bool) Tile {
+	if sanma && indicator.ID == 0 {
+		return ParseTile(8, false)
+	}
+	return DoraFromIndicator(indicator)
+}
+
+// Creates a knowledge base for a three-player game, with no 2-8 man left to draw
+func NewSanmaKB() KB {
+	kb := NewKB()
+	for id := range kb.remainingTiles {
+		if sanmaExcluded(id) {
+			kb.remainingTiles[id] = 0
+		}
+	}
+	return kb
+}
+
+// Reports whether the round is played with three-player rules
+func (r *Round) sanma() bool {
+	return r.Rules.Players == 3
+}
+
+// Offers to set aside a North as kita; in riichi only the drawn North, which leaves the
+// waits unchanged
+func (r *Round) kitaOptions(seat int, drawn *Tile) []Action {
+	s := r.seats[seat]
+	north := 30
+	if !r.sanma() || drawn == nil || s.hand().counts[north] == 0 || r.wall.Remaining() == 0 {
+		return nil
+	}
+	if s.riichi && drawn.ID != north {
+		return nil
+	}
+	return []Action{{Type: Action_Kita, Tile: ParseTile(north, false)}}
+}
+
+// Sets a North aside as kita; the seat goes on to draw a replacement tile
+func (r *Round) declareKita(seat int, action Action) {
+	s := r.seats[seat]
+	s.remove(action.Tile)
+	s.kita = append(s.kita, action.Tile)
+	r.emit(Event{Type: Event_Kita, Seat: seat, Tile: action.Tile})
+}
@@ -0,0 +1,219 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+func sanmaAgents() []Agent {
+	return []Agent{NewGreedyAgent(), NewGreedyAgent(), NewGreedyAgent()}
+}
+
+func TestBuildTiles_Sanma(t *testing.T) {
+	tiles := buildTiles(SanmaRules())
+	if len(tiles) != 108 {
+		t.Fatalf("buildTiles() sanma = %v tiles, want 108", len(tiles))
+	}
+	red := 0
+	for _, tile := range tiles {
+		if sanmaExcluded(tile.ID) {
+			t.Errorf("buildTiles() sanma contains %v", tile)
+		}
+		if tile.Red {
+			red++
+		}
+	}
+	if red != 2 {
+		t.Errorf("buildTiles() sanma red fives = %v, want 2", red)
+	}
+}
+
+func TestDoraFor(t *testing.T) {
+	tests := []struct {
+		name      string
+		indicator int
+		sanma     bool
+		want      int
+	}{
+		{"1m indicates 2m", 0, false, 1},
+		{"1m indicates 9m in sanma", 0, true, 8},
+		{"9m wraps to 1m in sanma", 8, true, 0},
+		{"Pinzu is unchanged in sanma", 9, true, 10},
+		{"North indicates East in sanma", 30, true, 27},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := doraFor(ParseTile(tt.indicator, false), tt.sanma).ID; got != tt.want {
+				t.Errorf("doraFor() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNewSanmaKB(t *testing.T) {
+	kb := NewSanmaKB()
+	if kb.Total() != 108 || kb.Remaining(4) != 0 || kb.Remaining(0) != 4 {
+		t.Errorf("NewSanmaKB() total %v, 5m %v, 1m %v, want 108, 0, 4", kb.Total(), kb.Remaining(4), kb.Remaining(0))
+	}
+}
+
+func TestRound_Kita(t *testing.T) {
+	pass := []ActionType{Action_Pass, Action_Pass, Action_Pass}
+	tiles := []int{0, 8, 9, 10, 11, 18, 19, 20, 23, 24, 25, 13, 30, 13}
+	drawn := ParseTile(13, false)
+
+	t.Run("Offered in sanma only", func(t *testing.T) {
+		r := scriptedRound(SanmaRules(), pass, tiles)
+		if _, ok := findAction(r.turnOptions(0, &drawn, nil), Action_Kita, 30); !ok {
+			t.Errorf("turnOptions() sanma should offer kita")
+		}
+		r = scriptedRound(DefaultRules(), []ActionType{Action_Pass, Action_Pass, Action_Pass, Action_Pass}, tiles)
+		if _, ok := findAction(r.turnOptions(0, &drawn, nil), Action_Kita, 30); ok {
+			t.Errorf("turnOptions() four-player should not offer kita")
+		}
+	})
+
+	t.Run("Only the drawn North in riichi", func(t *testing.T) {
+		r := scriptedRound(SanmaRules(), pass, tiles)
+		r.seats[0].riichi = true
+		if got := r.kitaOptions(0, &drawn); len(got) != 0 {
+			t.Errorf("kitaOptions() in riichi = %v, want none", got)
+		}
+		north := ParseTile(30, false)
+		if got := r.kitaOptions(0, &north); len(got) != 1 {
+			t.Errorf("kitaOptions() in riichi on a drawn North = %v, want kita", got)
+		}
+	})
+
+	t.Run("Kita counts as dora and draws a replacement", func(t *testing.T) {
+		r := scriptedRound(SanmaRules(), pass, tiles)
+		live := r.wall.Remaining()
+		r.declareKita(0, Action{Type: Action_Kita, Tile: ParseTile(30, false)})
+		if tile, ok := r.drawFor(0, drawKita); !ok || tile == nil || r.rinshan {
+			t.Fatalf("drawFor() kita replacement = %v, %v", tile, ok)
+		}
+		if r.wall.Remaining() != live-1 || r.wall.Kans() != 0 {
+			t.Errorf("kita replacement left %v live tiles and %v kans, want %v and 0", r.wall.Remaining(), r.wall.Kans(), live-1)
+		}
+		if ctx := r.winContext(0, drawn, true); ctx.Kita != 1 || !ctx.Sanma {
+			t.Errorf("winContext() kita = %v sanma = %v, want 1 and true", ctx.Kita, ctx.Sanma)
+		}
+	})
+}
+
+func TestWall_ReplacementsPastRinshan(t *testing.T) {
+	r := NewRound(SanmaRules(), testSetup(1), sanmaAgents())
+	live := r.wall.Remaining()
+	for i := 0; i < 6; i++ {
+		if _, ok := r.wall.DrawKita(); !ok {
+			t.Fatalf("DrawKita() %d failed", i)
+		}
+	}
+	if _, ok := r.wall.DrawRinshan(); !ok {
+		t.Errorf("DrawRinshan() after kita should still allow a kan")
+	}
+	if r.wall.Remaining() != live-7 {
+		t.Errorf("wall remaining = %v, want %v", r.wall.Remaining(), live-7)
+	}
+}
+
+func TestRound_SanmaTsumo(t *testing.T) {
+	score := ScoreFromHanFu(5, 30, false, true) // mangan: 4000/2000
+	tests := []struct {
+		name string
+		rule SanmaTsumo
+		want []int
+		pao  []int // double yakuman by seat 1 with seat 2 liable for one
+	}{
+		{"Tsumo loss", SanmaTsumo_Loss, []int{-4000, 6000, -2000}, []int{-16000, 56000, -40000}},
+		{"North bisection", SanmaTsumo_NorthBisection, []int{-5000, 8000, -3000}, []int{-20000, 64000, -44000}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rules := SanmaRules()
+			rules.SanmaTsumo = tt.rule
+			setup := RoundSetup{Scores: []int{35000, 35000, 35000}, Seed: 1}
+			r := NewRound(rules, setup, sanmaAgents())
+			if got := r.settleTsumo(1, ParseTile(9, false), score).Deltas; !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("settleTsumo() deltas = %v, want %v", got, tt.want)
+			}
+
+			result := RoundResult{Deltas: make([]int, 3)}
+			r.settlePaoTsumo(1, 2, 1, HandScore{Yakuman: 2, Tsumo: true}, &result)
+			if !reflect.DeepEqual(result.Deltas, tt.pao) {
+				t.Errorf("settlePaoTsumo() deltas = %v, want %v", result.Deltas, tt.pao)
+			}
+
+			// Nagashi mangan by seat 1 is paid like its mangan tsumo
+			rules.NagashiMangan = true
+			simple := []int{0, 4, 9, 11, 13, 15, 17, 18, 20, 22, 24, 26, 27}
+			r = scriptedRound(rules, []ActionType{Action_Pass, Action_Pass, Action_Pass}, simple, simple, simple)
+			for seat, s := range r.seats {
+				s.discards = []Discard{{Tile: ParseTile(13, false)}}
+				if seat == 1 {
+					s.discards = []Discard{{Tile: ParseTile(8, false)}, {Tile: ParseTile(33, false)}}
+				}
+			}
+			if got := r.exhaustiveDraw().Deltas; !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("exhaustiveDraw() nagashi deltas = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRound_SanmaNoChi(t *testing.T) {
+	r := scriptedRound(SanmaRules(), []ActionType{Action_Chi, Action_Chi, Action_Chi}, nil, []int{10, 11, 30})
+	if options := r.callOptions(1, 0, ParseTile(9, false)); options != nil {
+		t.Errorf("callOptions() sanma = %v, want no chi", options)
+	}
+}
+
+func TestRound_SanmaNotenBappu(t *testing.T) {
+	tenpai := []int{9, 10, 11, 12, 13, 18, 19, 20, 23, 24, 25, 31, 31}
+	noten := []int{0, 8, 9, 11, 13, 15, 17, 18, 20, 22, 24, 26, 27}
+	r := scriptedRound(SanmaRules(), []ActionType{Action_Pass, Action_Pass, Action_Pass}, tenpai, noten, noten)
+	if got := r.exhaustiveDraw().Deltas; !reflect.DeepEqual(got, []int{2000, -1000, -1000}) {
+		t.Errorf("exhaustiveDraw() sanma deltas = %v, want [2000 -1000 -1000]", got)
+	}
+}
+
+func TestMatch_Sanma(t *testing.T) {
+	rules := SanmaRules()
+	rules.Length = Length_Tonpuusen
+	res := NewMatch(rules, 3, sanmaAgents()).Play()
+	total := 0
+	for _, score := range res.Scores {
+		total += score
+	}
+	if len(res.Scores) != 3 || total != 3*rules.StartingPoints {
+		t.Errorf("sanma match scores = %v, want 3 seats summing to %v", res.Scores, 3*rules.StartingPoints)
+	}
+	for _, round := range res.Rounds {
+		for _, ev := range round.Events {
+			if ev.Type == Event_Draw && sanmaExcluded(ev.Tile.ID) {
+				t.Fatalf("sanma round drew %v", ev.Tile)
+			}
+		}
+	}
+}
+
+func TestScoreHand_KitaDora(t *testing.T) {
+	// Riichi ron with one kita; a West indicator makes North the dora in sanma
+	hand := handOf(0, 0, 0, 9, 10, 11, 12, 13, 14, 18, 19, 20, 22, 22)
+	ctx := WinContext{
+		WinningTile: ParseTile(20, false), Riichi: true, Seat: 1, Sanma: true, Kita: 1,
+		DoraIndicators: []Tile{ParseTile(29, false)}, UraDoraIndicators: []Tile{ParseTile(29, false)},
+	}
+	score, ok := ScoreHand(hand, nil, ctx)
+	if !ok {
+		t.Fatalf("ScoreHand() ok = false")
+	}
+	// Riichi, kita, and the kita counted once for the dora and once for the ura-dora
+	want := map[string]bool{"Riichi": true, "Kita (Nukidora)": true, "Dora": true, "Ura Dora": true}
+	for _, name := range score.Yaku {
+		delete(want, name)
+	}
+	if len(want) != 0 || score.Han != 4 {
+		t.Errorf("ScoreHand() = %v han %v, want 4 han with riichi, kita, dora and ura-dora", score.Han, score.Yaku)
+	}
+}
@@ -0,0 +1,350 @@
+package main
+
+import "slices"
+
+// Calculate fu, points and payments for a winning hand.
+
+type WaitType int
+
+const (
+	Wait_Unknown WaitType = iota // Wait not determined (callers that only check yaku)
+	Wait_Ryanmen                 // Two-sided sequence wait
+	Wait_Kanchan                 // Closed (middle) sequence wait
+	Wait_Penchan                 // Edge sequence wait (1-2 waiting on 3, 8-9 waiting on 7)
+	Wait_Shanpon                 // Dual pair wait completing a triplet
+	Wait_Tanki                   // Single tile wait completing the pair
+)
+
+// HandScore is the result of scoring a complete hand
+type HandScore struct {
+	Han         int
+	Fu          int
+	Yaku        []string
+	YakuHan     []int    // han of each yaku in the order of Yaku, 13 per multiple for yakuman
+	Yakuman     int      // number of yakuman multiples, 0 for regular hands
+	Wait        WaitType // wait used for the highest scoring interpretation
+	Dealer      bool
+	Tsumo       bool
+	Limit       string // "Mangan", "Haneman", etc. or empty below mangan
+	Base        int    // basic points before multiplication
+	Ron         int    // points paid by the discarder on ron
+	TsumoDealer int    // points paid by the dealer on a non-dealer tsumo
+	TsumoOther  int    // points paid by each non-dealer on tsumo
+}
+
+// Total returns the points received by the winner, excluding honba and riichi sticks
+func (s HandScore) Total() int {
+	if !s.Tsumo {
+		return s.Ron
+	}
+	if s.Dealer {
+		return s.TsumoOther * 3
+	}
+	return s.TsumoDealer + s.TsumoOther*2
+}
+
+// Returns the dora tile indicated by a dora indicator; suits wrap 9 to 1,
+// winds cycle E-S-W-N and dragons cycle White-Green-Red
+func DoraFromIndicator(indicator Tile) Tile {
+	id := indicator.ID
+	switch {
+	case id <= 26:
+		if id%9 == 8 {
+			return ParseTile(id-8, false)
+		}
+		return ParseTile(id+1, false)
+	case id <= 30:
+		return ParseTile(27+(id-27+1)%4, false)
+	default:
+		return ParseTile(31+(id-31+1)%3, false)
+	}
+}
+
+// Returns the limit name and basic points for a han/fu combination
+func BasePoints(han, fu, yakuman int) (string, int) {
+	switch {
+	case yakuman > 0:
+		return "Yakuman", 8000 * yakuman
+	case han >= 13:
+		return "Kazoe Yakuman", 8000
+	case han >= 11:
+		return "Sanbaiman", 6000
+	case han >= 8:
+		return "Baiman", 4000
+	case han >= 6:
+		return "Haneman", 3000
+	}
+	base := fu << (han + 2)
+	if han >= 5 || base >= 2000 {
+		return "Mangan", 2000
+	}
+	return "", base
+}
+
+// Rounds points up to the next multiple of 100
+func roundUp100(points int) int {
+	return (points + 99) / 100 * 100
+}
+
+// Fills in the limit, basic points and payments of a score from its han, fu and yakuman count
+func (s *HandScore) computePayments() {
+	s.Limit, s.Base = BasePoints(s.Han, s.Fu, s.Yakuman)
+	s.Ron, s.TsumoDealer, s.TsumoOther = 0, 0, 0
+	switch {
+	case !s.Tsumo && s.Dealer:
+		s.Ron = roundUp100(s.Base * 6)
+	case !s.Tsumo:
+		s.Ron = roundUp100(s.Base * 4)
+	case s.Dealer:
+		s.TsumoOther = roundUp100(s.Base * 2)
+	default:
+		s.TsumoDealer = roundUp100(s.Base * 2)
+		s.TsumoOther = roundUp100(s.Base)
+	}
+}
+
+// Calculates the score for a han/fu combination without any hand analysis
+func ScoreFromHanFu(han, fu int, dealer, tsumo bool) HandScore {
+	s := HandScore{Han: han, Fu: fu, Dealer: dealer, Tsumo: tsumo}
+	s.computePayments()
+	return s
+}
+
+// Determines the wait type when the winning tile completes the given set or pair
+func waitFor(set Set, winID int) WaitType {
+	switch set.Type {
+	case Proto:
+		return Wait_Tanki
+	case Koutsu, Kantsu:
+		return Wait_Shanpon
+	}
+	left, right := set.Tiles[0], set.Tiles[len(set.Tiles)-1]
+	switch {
+	case winID == set.Tiles[1].ID:
+		return Wait_Kanchan
+	case left.Rank == 0 && winID == right.ID:
+		return Wait_Penchan
+	case right.Rank == 8 && winID == left.ID:
+		return Wait_Penchan
+	}
+	return Wait_Ryanmen
+}
+
+// Calculates the fu of a regular (four sets and a pair) hand;
+// sets must not include the pair, and a triplet completed by ron must already be marked open
+func CalculateFu(sets []Set, pair int, winCtx WinContext, pinfu bool) int {
+	if pinfu {
+		if winCtx.Tsumo {
+			return 20
+		}
+		return 30
+	}
+	fu := 20
+	if winCtx.Menzen && !winCtx.Tsumo {
+		fu += 10
+	}
+	if winCtx.Tsumo {
+		fu += 2
+	}
+	for _, set := range sets {
+		if set.Type != Koutsu && set.Type != Kantsu {
+			continue
+		}
+		setFu := 2
+		if set.Tiles[0].IsTerminalOrHonor() {
+			setFu *= 2
+		}
+		if !set.Open {
+			setFu *= 2
+		}
+		if set.Type == Kantsu {
+			setFu *= 4
+		}
+		fu += setFu
+	}
+	// Value pair; a double wind pair scores for both seat and round
+	if pair >= 31 {
+		fu += 2
+	}
+	if pair == 27+winCtx.Seat {
+		fu += 2
+	}
+	if pair == 27+winCtx.Round {
+		fu += 2
+	}
+	switch winCtx.Wait {
+	case Wait_Kanchan, Wait_Penchan, Wait_Tanki:
+		fu += 2
+	}
+	// An open hand with no fu is rounded up to 30
+	if fu == 20 && !winCtx.Menzen {
+		return 30
+	}
+	return (fu + 9) / 10 * 10
+}
+
+// Returns the han contributed by bonus yaku (dora) which do not make a hand winnable on their own
+func bonusHan(hand Hand, sets []Set, winCtx WinContext) int {
+	han := 0
+	for _, yaku := range yakuListBonus {
+		if h, ok := yaku.Check(hand, sets, winCtx); ok {
+			han += h
+		}
+	}
+	return han
+}
+
+// Scores a single interpretation of a hand; returns false if the hand has no yaku
+func scoreInterpretation(full Hand, sets []Set, pair int, winCtx WinContext) (HandScore, bool) {
+	han, yakus := CheckAllYaku(full, sets, winCtx)
+	s := HandScore{
+		Yaku:   yakus,
+		Wait:   winCtx.Wait,
+		Dealer: winCtx.Seat == 0,
+		Tsumo:  winCtx.Tsumo,
+	}
+	if yakuman, names := yakumanOf(full, sets, winCtx); yakuman > 0 {
+		// Only yakuman count once a hand has one
+		s.Yakuman = yakuman
+		s.Han = 13 * yakuman
+		s.Yaku = names
+		s.computePayments()
+		return s, true
+	}
+	if han-bonusHan(full, sets, winCtx) <= 0 {
+		return s, false
+	}
+	s.Han = han
+	switch {
+	case sets == nil:
+		s.Fu = 25 // Chiitoitsu
+	default:
+		_, pinfu := Yaku_Pinfu{}.Check(full, sets, winCtx)
+		s.Fu = CalculateFu(sets, pair, winCtx, pinfu)
+	}
+	s.computePayments()
+	return s, true
+}
+
+// Returns the number of yakuman multiples in a hand and their names
+func yakumanOf(hand Hand, sets []Set, winCtx WinContext) (int, []string) {
+	count := 0
+	var names []string
+	for _, yaku := range append(append([]Yaku{}, yakuList...), yakuListSpecial...) {
+		if han, ok := yaku.Check(hand, sets, winCtx); ok && han >= 13 {
+			count += han / 13
+			names = append(names, yaku.Name())
+		}
+	}
+	return count, names
+}
+
+// Returns true if a score is preferred over another (higher payment, then higher han)
+func betterScore(a, b HandScore) bool {
+	if a.Total() != b.Total() {
+		return a.Total() > b.Total()
+	}
+	return a.Han > b.Han
+}
+
+// Scores a complete hand. hand holds the concealed tiles including the winning tile,
+// melds holds called sets (and closed kans). All decompositions and placements of the
+// winning tile are evaluated and the highest scoring one is returned.
+// Returns false if the hand is not complete or has no yaku.
+func ScoreHand(hand Hand, melds []Set, winCtx WinContext) (HandScore, bool) {
+	best, _, found := bestInterpretation(hand, melds, winCtx)
+	return best, found
+}
+
+// One reading of a winning hand: its sets (nil for Chiitoitsu and Kokushi Musou) and
+// the win context with the wait they give
+type interpretation struct {
+	full   Hand
+	sets   []Set
+	winCtx WinContext
+}
+
+// Returns the highest scoring interpretation of a complete hand
+func bestInterpretation(hand Hand, melds []Set, winCtx WinContext) (HandScore, interpretation, bool) {
+	full := hand
+	winCtx.Menzen = true
+	for _, m := range melds {
+		if m.Open {
+			winCtx.Menzen = false
+		}
+		for _, t := range m.Tiles {
+			full.counts[t.ID]++
+		}
+	}
+
+	var best HandScore
+	var reading interpretation
+	found := false
+	consider := func(sets []Set, pair int, ctx WinContext) {
+		s, ok := scoreInterpretation(full, sets, pair, ctx)
+		if ok && (!found || betterScore(s, best)) {
+			best, reading, found = s, interpretation{full, sets, ctx}, true
+		}
+	}
+
+	if len(melds) == 0 {
+		// Kokushi Musou and Chiitoitsu are scored with no sets
+		if _, ok := (Yaku_KokushiMusou{}).Check(full, nil, winCtx); ok {
+			consider(nil, -1, winCtx)
+		}
+		if _, ok := (Yaku_Chiitoitsu{}).Check(full, nil, winCtx); ok {
+			ctx := winCtx
+			ctx.Wait = Wait_Tanki
+			consider(nil, -1, ctx)
+		}
+	}
+
+	for _, dcmp := range AllDecompositions(hand.counts[:]) {
+		pair := dcmp[0].Tiles[0].ID
+		concealed := dcmp[1:]
+		for i, set := range dcmp {
+			if !setContains(set, winCtx.WinningTile.ID) {
+				continue
+			}
+			ctx := winCtx
+			ctx.Wait = waitFor(set, winCtx.WinningTile.ID)
+			sets := make([]Set, 0, len(concealed)+len(melds))
+			for j, s := range concealed {
+				// A triplet completed by ron counts as an open triplet
+				if j+1 == i && !ctx.Tsumo && s.Type == Koutsu {
+					s.Open = true
+				}
+				sets = append(sets, s)
+			}
+			sets = append(sets, melds...)
+			consider(sets, pair, ctx)
+		}
+	}
+	if found {
+		best.YakuHan = yakuHan(reading, best.Yaku)
+	}
+	return best, reading, found
+}
+
+// Returns the han of each named yaku of an interpretation
+func yakuHan(reading interpretation, names []string) []int {
+	hans := make([]int, len(names))
+	for _, list := range [][]Yaku{yakuList, yakuListSpecial, yakuListPairs, yakuListBonus} {
+		for _, yaku := range list {
+			if i := slices.Index(names, yaku.Name()); i >= 0 {
+				hans[i], _ = yaku.Check(reading.full, reading.sets, reading.winCtx)
+			}
+		}
+	}
+	return hans
+}
+
+// Reports whether a set contains a tile with the given ID
+func setContains(set Set, id int) bool {
+	for _, t := range set.Tiles {
+		if t.ID == id {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,236 @@
+package main
+
+import (
+	"testing"
+)
+
+// Builds a hand from tile IDs
+func handOf(ids ...int) Hand {
+	h := Hand{}
+	for _, id := range ids {
+		h.counts[id]++
+	}
+	return h
+}
+
+func TestDoraFromIndicator(t *testing.T) {
+	tests := []struct {
+		name      string
+		indicator int
+		want      int
+	}{
+		{"1-man indicates 2-man", 0, 1},
+		{"9-man wraps to 1-man", 8, 0},
+		{"4-pin indicates 5-pin", 12, 13},
+		{"9-sou wraps to 1-sou", 26, 18},
+		{"East indicates South", 27, 28},
+		{"North wraps to East", 30, 27},
+		{"White indicates Green", 31, 32},
+		{"Red wraps to White", 33, 31},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := DoraFromIndicator(ParseTile(tt.indicator, false)); got.ID != tt.want {
+				t.Errorf("DoraFromIndicator() = %v, want %v", got.ID, tt.want)
+			}
+		})
+	}
+}
+
+func TestBasePoints(t *testing.T) {
+	tests := []struct {
+		name      string
+		han       int
+		fu        int
+		yakuman   int
+		wantLimit string
+		wantBase  int
+	}{
+		{"1 han 30 fu", 1, 30, 0, "", 240},
+		{"3 han 30 fu", 3, 30, 0, "", 960},
+		{"4 han 30 fu is not mangan", 4, 30, 0, "", 1920},
+		{"4 han 40 fu is mangan", 4, 40, 0, "Mangan", 2000},
+		{"3 han 70 fu is mangan", 3, 70, 0, "Mangan", 2000},
+		{"5 han", 5, 30, 0, "Mangan", 2000},
+		{"7 han", 7, 30, 0, "Haneman", 3000},
+		{"10 han", 10, 30, 0, "Baiman", 4000},
+		{"12 han", 12, 30, 0, "Sanbaiman", 6000},
+		{"13 han without yakuman", 13, 30, 0, "Kazoe Yakuman", 8000},
+		{"Double yakuman", 26, 0, 2, "Yakuman", 16000},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			limit, base := BasePoints(tt.han, tt.fu, tt.yakuman)
+			if limit != tt.wantLimit || base != tt.wantBase {
+				t.Errorf("BasePoints() = %v, %v, want %v, %v", limit, base, tt.wantLimit, tt.wantBase)
+			}
+		})
+	}
+}
+
+func TestScoreFromHanFu(t *testing.T) {
+	tests := []struct {
+		name            string
+		han, fu         int
+		dealer, tsumo   bool
+		wantRon         int
+		wantTsumoDealer int
+		wantTsumoOther  int
+		wantTotal       int
+	}{
+		{"Non-dealer ron 1 han 30 fu", 1, 30, false, false, 1000, 0, 0, 1000},
+		{"Dealer ron 4 han 30 fu", 4, 30, true, false, 11600, 0, 0, 11600},
+		{"Non-dealer tsumo 3 han 30 fu", 3, 30, false, true, 0, 2000, 1000, 4000},
+		{"Dealer tsumo 2 han 40 fu", 2, 40, true, true, 0, 0, 1300, 3900},
+		{"Non-dealer tsumo mangan", 5, 30, false, true, 0, 4000, 2000, 8000},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := ScoreFromHanFu(tt.han, tt.fu, tt.dealer, tt.tsumo)
+			if s.Ron != tt.wantRon || s.TsumoDealer != tt.wantTsumoDealer || s.TsumoOther != tt.wantTsumoOther {
+				t.Errorf("ScoreFromHanFu() = %v/%v/%v, want %v/%v/%v", s.Ron, s.TsumoDealer, s.TsumoOther, tt.wantRon, tt.wantTsumoDealer, tt.wantTsumoOther)
+			}
+			if s.Total() != tt.wantTotal {
+				t.Errorf("HandScore.Total() = %v, want %v", s.Total(), tt.wantTotal)
+			}
+		})
+	}
+}
+
+func TestScoreHand(t *testing.T) {
+	greenPon := Set{Type: Koutsu, Tiles: []Tile{ParseTile(32, false), ParseTile(32, false), ParseTile(32, false)}, Open: true, Target: 1}
+
+	tests := []struct {
+		name      string
+		hand      Hand
+		melds     []Set
+		winCtx    WinContext
+		wantOk    bool
+		wantHan   int
+		wantFu    int
+		wantTotal int
+		wantWait  WaitType
+	}{
+		{
+			name: "Riichi tsumo pinfu tanyao",
+			hand: handOf(1, 2, 3, 4, 5, 6, 11, 12, 13, 23, 24, 25, 13, 13),
+			winCtx: WinContext{
+				WinningTile: ParseTile(3, false), Tsumo: true, Riichi: true, Seat: 1,
+			},
+			wantOk: true, wantHan: 4, wantFu: 20, wantTotal: 5200, wantWait: Wait_Ryanmen,
+		},
+		{
+			name:   "Riichi pinfu tanyao ron",
+			hand:   handOf(1, 2, 3, 4, 5, 6, 11, 12, 13, 23, 24, 25, 13, 13),
+			winCtx: WinContext{WinningTile: ParseTile(3, false), Riichi: true, Seat: 1},
+			wantOk: true, wantHan: 3, wantFu: 30, wantTotal: 3900, wantWait: Wait_Ryanmen,
+		},
+		{
+			name:   "Kanchan ron with concealed dragon triplet",
+			hand:   handOf(0, 1, 2, 12, 13, 14, 24, 25, 26, 31, 31, 31, 8, 8),
+			winCtx: WinContext{WinningTile: ParseTile(13, false), Seat: 1},
+			wantOk: true, wantHan: 1, wantFu: 40, wantTotal: 1300, wantWait: Wait_Kanchan,
+		},
+		{
+			name:   "Shanpon ron triplet counts as open",
+			hand:   handOf(0, 1, 2, 3, 4, 5, 6, 7, 8, 9, 9, 31, 31, 31),
+			winCtx: WinContext{WinningTile: ParseTile(31, false), Seat: 1},
+			wantOk: true, wantHan: 1, wantFu: 40, wantTotal: 1300, wantWait: Wait_Shanpon,
+		},
+		{
+			name:   "Open yakuhai hand rounds up to 30 fu",
+			hand:   handOf(1, 2, 3, 13, 14, 15, 24, 25, 26, 12, 12),
+			melds:  []Set{greenPon},
+			winCtx: WinContext{WinningTile: ParseTile(26, false), Seat: 1},
+			wantOk: true, wantHan: 1, wantFu: 30, wantTotal: 1000, wantWait: Wait_Ryanmen,
+		},
+		{
+			name:   "Riichi chiitoitsu ron",
+			hand:   handOf(0, 0, 10, 10, 11, 11, 21, 21, 22, 22, 23, 23, 33, 33),
+			winCtx: WinContext{WinningTile: ParseTile(33, false), Riichi: true, Seat: 1},
+			wantOk: true, wantHan: 3, wantFu: 25, wantTotal: 3200, wantWait: Wait_Tanki,
+		},
+		{
+			name:   "Dealer kokushi ron",
+			hand:   handOf(0, 8, 9, 17, 18, 26, 27, 28, 29, 30, 31, 32, 33, 33),
+			winCtx: WinContext{WinningTile: ParseTile(33, false), Seat: 0},
+			wantOk: true, wantHan: 13, wantFu: 0, wantTotal: 48000,
+		},
+		{
+			name: "Dora count towards han",
+			hand: handOf(1, 2, 3, 4, 5, 6, 11, 12, 13, 23, 24, 25, 13, 13),
+			winCtx: WinContext{
+				WinningTile: ParseTile(3, false), Riichi: true, Seat: 1,
+				DoraIndicators: []Tile{ParseTile(12, false)}, AkaDora: 1,
+			},
+			wantOk: true, wantHan: 7, wantFu: 30, wantTotal: 12000, wantWait: Wait_Ryanmen,
+		},
+		{
+			name:   "No yaku ron is not a win",
+			hand:   handOf(0, 1, 2, 3, 4, 5, 11, 12, 13, 23, 24, 25, 13, 13),
+			winCtx: WinContext{WinningTile: ParseTile(4, false), Seat: 1},
+			wantOk: false,
+		},
+		{
+			name: "Dora alone is not a yaku",
+			hand: handOf(0, 1, 2, 3, 4, 5, 11, 12, 13, 23, 24, 25, 13, 13),
+			winCtx: WinContext{
+				WinningTile: ParseTile(4, false), Seat: 1, DoraIndicators: []Tile{ParseTile(12, false)},
+			},
+			wantOk: false,
+		},
+		{
+			name:   "Incomplete hand",
+			hand:   handOf(0, 1, 2, 3, 4, 5, 11, 12, 13, 23, 24, 25, 13, 15),
+			winCtx: WinContext{WinningTile: ParseTile(15, false), Tsumo: true, Seat: 1},
+			wantOk: false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := ScoreHand(tt.hand, tt.melds, tt.winCtx)
+			if ok != tt.wantOk {
+				t.Fatalf("ScoreHand() ok = %v, want %v (yaku %v)", ok, tt.wantOk, got.Yaku)
+			}
+			if !ok {
+				return
+			}
+			if got.Han != tt.wantHan || got.Fu != tt.wantFu {
+				t.Errorf("ScoreHand() = %v han %v fu, want %v han %v fu (yaku %v)", got.Han, got.Fu, tt.wantHan, tt.wantFu, got.Yaku)
+			}
+			if got.Total() != tt.wantTotal {
+				t.Errorf("ScoreHand() total = %v, want %v", got.Total(), tt.wantTotal)
+			}
+			if tt.wantWait != Wait_Unknown && got.Wait != tt.wantWait {
+				t.Errorf("ScoreHand() wait = %v, want %v", got.Wait, tt.wantWait)
+			}
+		})
+	}
+}
+
+func TestScoreHand_YakuHan(t *testing.T) {
+	hand := handOf(1, 2, 3, 4, 5, 6, 11, 12, 13, 23, 24, 25, 13, 13)
+	winCtx := WinContext{
+		WinningTile: ParseTile(3, false), Tsumo: true, Riichi: true, Seat: 1,
+		DoraIndicators: []Tile{ParseTile(12, false)},
+	}
+	score, ok := ScoreHand(hand, nil, winCtx)
+	if !ok || score.Han != 7 || len(score.YakuHan) != len(score.Yaku) {
+		t.Fatalf("ScoreHand() = %+v, %v", score, ok)
+	}
+	want := map[string]int{"Riichi": 1, "Tsumo (Self-draw)": 1, "Dora": 3}
+	total := 0
+	for i, name := range score.Yaku {
+		total += score.YakuHan[i]
+		if w, ok := want[name]; ok && score.YakuHan[i] != w {
+			t.Errorf("ScoreHand() %s = %d han, want %d", name, score.YakuHan[i], w)
+		}
+	}
+	if total != score.Han {
+		t.Errorf("ScoreHand() han by yaku %v add up to %d, want %d", score.YakuHan, total, score.Han)
+	}
+}
@@ -0,0 +1,247 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// The score subcommand: scores a winning hand written in MPSZ notation
+
+// A called set or closed kan given on the command line as kind:tiles, such as pon:555z
+type meldFlags []Set
+
+func (m *meldFlags) String() string {
+	var melds []string
+	for _, s := range *m {
+		melds = append(melds, FormatTiles(s.Tiles))
+	}
+	return strings.Join(melds, " ")
+}
+
+func (m *meldFlags) Set(value string) error {
+	kind, text, ok := strings.Cut(value, ":")
+	if !ok {
+		return fmt.Errorf("meld %q is not kind:tiles", value)
+	}
+	tiles, err := ParseTiles(text)
+	if err != nil {
+		return err
+	}
+	sortTiles(tiles)
+	set := Set{Tiles: tiles, Open: true}
+	size := 3
+	switch kind {
+	case "chi":
+		set.Type = Shuntsu
+		if len(tiles) == 3 && (tiles[0].Suit == Honor || tiles[0].Suit != tiles[2].Suit ||
+			tiles[1].ID != tiles[0].ID+1 || tiles[2].ID != tiles[0].ID+2) {
+			return fmt.Errorf("chi %q is not a sequence", text)
+		}
+	case "pon":
+		set.Type = Koutsu
+	case "kan", "ankan":
+		set.Type, set.Open, size = Kantsu, kind == "kan", 4
+	default:
+		return fmt.Errorf("unknown meld kind %q, want chi, pon, kan or ankan", kind)
+	}
+	if len(tiles) != size {
+		return fmt.Errorf("%s %q has %d tiles, want %d", kind, text, len(tiles), size)
+	}
+	if set.Type != Shuntsu && tiles[0].ID != tiles[size-1].ID {
+		return fmt.Errorf("%s %q is not of one tile", kind, text)
+	}
+	*m = append(*m, set)
+	return nil
+}
+
+// Parses a wind given as E, S, W, N or 1z-4z
+func parseWind(s string) (int, error) {
+	if i := strings.Index("ESWN", strings.ToUpper(s)); i >= 0 && len(s) == 1 {
+		return i, nil
+	}
+	var t Tile
+	if err := t.UnmarshalText([]byte(s)); err != nil || t.ID < 27 || t.ID > 30 {
+		return 0, fmt.Errorf("invalid wind %q, want E, S, W or N", s)
+	}
+	return t.ID - 27, nil
+}
+
+// Score of a hand as written by score --json
+type scoreJSON struct {
+	Yaku        []yakuJSON `json:"yaku"`
+	Han         int        `json:"han"`
+	Fu          int        `json:"fu"`
+	Yakuman     int        `json:"yakuman,omitempty"`
+	Limit       string     `json:"limit,omitempty"`
+	Dealer      bool       `json:"dealer"`
+	Tsumo       bool       `json:"tsumo"`
+	Ron         int        `json:"ron,omitempty"`
+	TsumoDealer int        `json:"tsumo_dealer,omitempty"`
+	TsumoOther  int        `json:"tsumo_other,omitempty"`
+	Total       int        `json:"total"`
+}
+
+type yakuJSON struct {
+	Name string `json:"name"`
+	Han  int    `json:"han"`
+}
+
+// Runs the score subcommand
+func runScore(args []string, stdout, stderr io.Writer) error {
+	fs := flag.NewFlagSet("score", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	fs.Usage = func() {
+		fmt.Fprintln(stderr, "usage: riichi-mahjong score [flags] hand")
+		fmt.Fprintln(stderr, "hand holds the concealed tiles in MPSZ notation, including the winning tile, e.g. 234m567m345p678s55p")
+		fs.PrintDefaults()
+	}
+	win := fs.String("win", "", "winning `tile` (default the last tile of the hand)")
+	tsumo := fs.Bool("tsumo", false, "won by tsumo instead of ron")
+	seat := fs.String("seat", "E", "seat `wind`: E, S, W or N; East is the dealer")
+	round := fs.String("round", "E", "round `wind`: E, S, W or N")
+	riichi := fs.Bool("riichi", false, "riichi was declared")
+	dora := fs.String("dora", "", "dora indicator `tiles`")
+	ura := fs.String("ura", "", "ura-dora indicator `tiles`, counted with riichi")
+	var melds meldFlags
+	fs.Var(&melds, "meld", "called set or closed kan as `kind:tiles` (chi, pon, kan or ankan), repeatable")
+	asJSON := fs.Bool("json", false, "write the score as JSON")
+	positional, err := parseFlags(fs, args)
+	if err != nil {
+		return err
+	}
+	if len(positional) != 1 {
+		fs.Usage()
+		return errUsage
+	}
+
+	tiles, err := ParseTiles(positional[0])
+	if err != nil {
+		return err
+	}
+	ctx := WinContext{Tsumo: *tsumo, Riichi: *riichi}
+	if ctx.Seat, err = parseWind(*seat); err != nil {
+		return err
+	}
+	if ctx.Round, err = parseWind(*round); err != nil {
+		return err
+	}
+	if ctx.DoraIndicators, err = ParseTiles(*dora); err != nil {
+		return err
+	}
+	if ctx.UraDoraIndicators, err = ParseTiles(*ura); err != nil {
+		return err
+	}
+	if len(tiles) > 0 {
+		ctx.WinningTile = tiles[len(tiles)-1]
+	}
+	if *win != "" {
+		if err := ctx.WinningTile.UnmarshalText([]byte(*win)); err != nil {
+			return err
+		}
+	}
+
+	score, err := scoreTiles(tiles, melds, ctx)
+	if err != nil {
+		return err
+	}
+	if *asJSON {
+		enc := json.NewEncoder(stdout)
+		enc.SetIndent("", "  ")
+		return enc.Encode(newScoreJSON(score))
+	}
+	return writeScore(stdout, score)
+}
+
+// Scores the concealed tiles of a winning hand, including the winning tile, with its melds,
+// counting the red fives of both as aka dora
+func scoreTiles(tiles []Tile, melds []Set, ctx WinContext) (HandScore, error) {
+	if want := 14 - 3*len(melds); len(tiles) != want {
+		return HandScore{}, fmt.Errorf("hand has %d tiles, want %d with %d melds", len(tiles), want, len(melds))
+	}
+	var hand Hand
+	for _, t := range tiles {
+		hand.counts[t.ID]++
+		if t.Red {
+			ctx.AkaDora++
+		}
+	}
+	if hand.counts[ctx.WinningTile.ID] == 0 {
+		return HandScore{}, fmt.Errorf("winning tile %v is not in the hand", ctx.WinningTile)
+	}
+	full := hand
+	for _, m := range melds {
+		for _, t := range m.Tiles {
+			full.counts[t.ID]++
+			if t.Red {
+				ctx.AkaDora++
+			}
+		}
+		if m.Open && ctx.Riichi {
+			return HandScore{}, fmt.Errorf("riichi with an open hand")
+		}
+	}
+	for id, count := range full.counts {
+		if count > 4 {
+			return HandScore{}, fmt.Errorf("%d copies of %v", count, ParseTile(id, false))
+		}
+	}
+
+	score, ok := ScoreHand(hand, melds, ctx)
+	if !ok {
+		if CalculateDeficiency(hand, len(melds)) >= 0 {
+			return HandScore{}, errNotComplete
+		}
+		return HandScore{}, errNoYaku
+	}
+	return score, nil
+}
+
+var (
+	errNotComplete = errors.New("hand is not complete")
+	errNoYaku      = errors.New("hand has no yaku")
+)
+
+// Converts a score to its JSON form
+func newScoreJSON(score HandScore) scoreJSON {
+	out := scoreJSON{
+		Han: score.Han, Fu: score.Fu, Yakuman: score.Yakuman, Limit: score.Limit,
+		Dealer: score.Dealer, Tsumo: score.Tsumo,
+		Ron: score.Ron, TsumoDealer: score.TsumoDealer, TsumoOther: score.TsumoOther, Total: score.Total(),
+	}
+	for i, name := range score.Yaku {
+		out.Yaku = append(out.Yaku, yakuJSON{name, score.YakuHan[i]})
+	}
+	return out
+}
+
+// Writes the yaku of a score with their han, the han and fu and the payments
+func writeScore(w io.Writer, score HandScore) error {
+	var b strings.Builder
+	for i, name := range score.Yaku {
+		fmt.Fprintf(&b, "%-32s %2d han\n", name, score.YakuHan[i])
+	}
+	switch {
+	case score.Yakuman > 0:
+		fmt.Fprintf(&b, "Yakuman x%d", score.Yakuman)
+	case score.Limit != "":
+		fmt.Fprintf(&b, "%d han %d fu, %s", score.Han, score.Fu, score.Limit)
+	default:
+		fmt.Fprintf(&b, "%d han %d fu", score.Han, score.Fu)
+	}
+	b.WriteString("\n")
+	switch {
+	case !score.Tsumo:
+		fmt.Fprintf(&b, "Ron: %d\n", score.Ron)
+	case score.Dealer:
+		fmt.Fprintf(&b, "Tsumo: %d all\n", score.TsumoOther)
+	default:
+		fmt.Fprintf(&b, "Tsumo: %d from the dealer, %d from the others\n", score.TsumoDealer, score.TsumoOther)
+	}
+	fmt.Fprintf(&b, "Total: %d\n", score.Total())
+	_, err := io.WriteString(w, b.String())
+	return err
+}
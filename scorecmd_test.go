@@ -0,0 +1,106 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func TestRunScore(t *testing.T) {
+	tests := []struct {
+		name string
+		args []string
+		want []string
+	}{
+		{
+			"riichi tsumo with dora",
+			[]string{"234m567m345p678s55p", "-win", "4m", "-tsumo", "-riichi", "-seat", "S", "-dora", "4p"},
+			[]string{"Riichi", "Dora                              3 han", "7 han 20 fu, Haneman", "Tsumo: 6000 from the dealer, 3000 from the others", "Total: 12000"},
+		},
+		{
+			"dealer closed kan",
+			[]string{"-meld", "ankan:1111z", "-riichi", "234m567m345p55p"},
+			[]string{"Yakuhai (Value Tiles)             2 han", "3 han 70 fu, Mangan", "Ron: 12000"},
+		},
+		{
+			"dealer tsumo",
+			[]string{"-tsumo", "234m567m345p678s55p"},
+			[]string{"3 han 20 fu\n", "Tsumo: 1300 all", "Total: 3900"},
+		},
+		{
+			"yakuman",
+			[]string{"19m19p19s1234567z1m"},
+			[]string{"Kokushi Musou", "Yakuman x1", "Ron: 48000"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var stdout bytes.Buffer
+			if err := runScore(tt.args, &stdout, &bytes.Buffer{}); err != nil {
+				t.Fatalf("runScore() error = %v", err)
+			}
+			for _, want := range tt.want {
+				if !strings.Contains(stdout.String(), want) {
+					t.Errorf("runScore() = %q, want %q", stdout.String(), want)
+				}
+			}
+		})
+	}
+}
+
+func TestRunScore_JSON(t *testing.T) {
+	var stdout bytes.Buffer
+	args := []string{"--json", "-meld", "pon:777z", "-seat", "W", "234m567m345p5p5p"}
+	if err := runScore(args, &stdout, &bytes.Buffer{}); err != nil {
+		t.Fatalf("runScore() error = %v", err)
+	}
+	var got scoreJSON
+	if err := json.Unmarshal(stdout.Bytes(), &got); err != nil {
+		t.Fatalf("runScore() = %q, error = %v", stdout.String(), err)
+	}
+	want := scoreJSON{Yaku: []yakuJSON{{"Yakuhai (Value Tiles)", 1}}, Han: 1, Fu: 30, Ron: 1000, Total: 1000}
+	if len(got.Yaku) != 1 || got.Yaku[0] != want.Yaku[0] || got.Han != want.Han || got.Fu != want.Fu ||
+		got.Dealer || got.Ron != want.Ron || got.Total != want.Total {
+		t.Errorf("runScore() = %+v, want %+v", got, want)
+	}
+}
+
+func TestRunScore_Invalid(t *testing.T) {
+	tests := []struct {
+		name string
+		args []string
+	}{
+		{"no hand", nil},
+		{"bad tiles", []string{"234x"}},
+		{"wrong tile count", []string{"234m"}},
+		{"winning tile not held", []string{"-win", "9s", "234m567m345p678s55p"}},
+		{"hidden winning tile", []string{"-win", "?", "234m567m345p678s55p"}},
+		{"too many copies", []string{"-meld", "pon:555p", "234m567m345p55p"}},
+		{"bad wind", []string{"-seat", "X", "234m567m345p678s55p"}},
+		{"bad meld", []string{"-meld", "chi:135m", "234m567m345p55p"}},
+		{"open riichi", []string{"-riichi", "-meld", "chi:678s", "234m567m345p55p"}},
+		{"incomplete", []string{"234m567m345p678s19p"}},
+		{"no yaku", []string{"-meld", "chi:123m", "567m345p678s55p"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := runScore(tt.args, &bytes.Buffer{}, &bytes.Buffer{}); err == nil {
+				t.Errorf("runScore() error = nil, want an error")
+			}
+		})
+	}
+}
+
+func TestParseWind(t *testing.T) {
+	for s, want := range map[string]int{"E": 0, "s": 1, "W": 2, "4z": 3} {
+		if got, err := parseWind(s); err != nil || got != want {
+			t.Errorf("parseWind(%q) = %d, %v, want %d", s, got, err, want)
+		}
+	}
+	for _, s := range []string{"", "5z", "1m", "EE"} {
+		if _, err := parseWind(s); err == nil {
+			t.Errorf("parseWind(%q) error = nil, want an error", s)
+		}
+	}
+}
@@ -0,0 +1,126 @@
+package main
+
+import (
+	"encoding/csv"
+	"flag"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+)
+
+// The simulate subcommand: plays a batch of bot-versus-bot matches and compares the bots
+
+// Runs the simulate subcommand
+func runSimulate(args []string, stdout, stderr io.Writer) error {
+	fs := flag.NewFlagSet("simulate", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	fs.Usage = func() {
+		fmt.Fprintln(stderr, "usage: riichi-mahjong simulate [flags]")
+		fmt.Fprintln(stderr, "plays matches between bots, rotating seats, and reports each bot with 95% confidence intervals")
+		fs.PrintDefaults()
+	}
+	games := fs.Int("games", 100, "number of `matches` to play")
+	seed := fs.Uint64("seed", 1, "`seed` of the batch")
+	workers := fs.Int("workers", 0, "concurrent `workers` (default the number of CPUs)")
+	agents := fs.String("agents", "greedy,defensive,greedy,defensive", "comma-separated bot `kinds`, one per seat: greedy, defensive or random")
+	east := fs.Bool("tonpuusen", false, "play the East round only instead of a hanchan")
+	sanma := fs.Bool("sanma", false, "play three-player matches")
+	asCSV := fs.Bool("csv", false, "write the statistics as CSV")
+	positional, err := parseFlags(fs, args)
+	if err != nil {
+		return err
+	}
+	if len(positional) != 0 {
+		fs.Usage()
+		return errUsage
+	}
+	if *games <= 0 {
+		return fmt.Errorf("games must be positive")
+	}
+
+	rules := DefaultRules()
+	if *sanma {
+		rules = SanmaRules()
+	}
+	if *east {
+		rules.Length = Length_Tonpuusen
+	}
+	var entries []BatchEntry
+	for _, kind := range strings.Split(*agents, ",") {
+		kind = strings.TrimSpace(kind)
+		if _, err := newBot(kind, 0); err != nil {
+			return err
+		}
+		entries = append(entries, BatchEntry{kind, func(seed uint64) Agent {
+			agent, _ := newBot(kind, seed)
+			return agent
+		}})
+	}
+	if len(entries) != rules.Players {
+		return fmt.Errorf("%d agents, want %d", len(entries), rules.Players)
+	}
+
+	stats, err := RunBatch(BatchConfig{Rules: rules, Games: *games, Seed: *seed, Workers: *workers}, entries)
+	if err != nil {
+		return err
+	}
+	if *asCSV {
+		return writeStatsCSV(stdout, stats)
+	}
+	return writeStats(stdout, stats)
+}
+
+// Writes a rate estimate as a percentage
+func percent(e Estimate) string {
+	return fmt.Sprintf("%.1f%% ± %.1f", 100*e.Mean, 100*e.Margin)
+}
+
+// Writes a table of agent statistics
+func writeStats(w io.Writer, stats []AgentStats) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "SEAT\tAGENT\tGAMES\tPLACES\tAVG PLACE\tPOINTS\tWIN\tDEAL-IN\tRIICHI\tCALL\tWIN VALUE")
+	for i, s := range stats {
+		var places []string
+		for _, count := range s.Places {
+			places = append(places, strconv.Itoa(count))
+		}
+		place, points, value := s.AvgPlace(), s.AvgPoints(), s.AvgWinValue()
+		fmt.Fprintf(tw, "%d\t%s\t%d\t%s\t%.2f ± %.2f\t%+.1f ± %.1f\t%s\t%s\t%s\t%s\t%.0f ± %.0f\n",
+			i+1, s.Name, s.Games, strings.Join(places, "/"),
+			place.Mean, place.Margin, points.Mean, points.Margin,
+			percent(s.WinRate()), percent(s.DealInRate()), percent(s.RiichiRate()), percent(s.CallRate()),
+			value.Mean, value.Margin)
+	}
+	return tw.Flush()
+}
+
+// Writes agent statistics as CSV with a header row; every estimate has a column for its
+// mean and one for the half-width of its 95% confidence interval
+func writeStatsCSV(w io.Writer, stats []AgentStats) error {
+	cw := csv.NewWriter(w)
+	header := []string{"seat", "agent", "games", "rounds"}
+	if len(stats) > 0 {
+		for place := range stats[0].Places {
+			header = append(header, fmt.Sprintf("place_%d", place+1))
+		}
+	}
+	names := []string{"avg_place", "avg_points", "win_rate", "deal_in_rate", "riichi_rate", "call_rate", "avg_win_value"}
+	for _, name := range names {
+		header = append(header, name, name+"_ci")
+	}
+	cw.Write(header)
+	for i, s := range stats {
+		row := []string{strconv.Itoa(i + 1), s.Name, strconv.Itoa(s.Games), strconv.Itoa(s.Rounds)}
+		for _, count := range s.Places {
+			row = append(row, strconv.Itoa(count))
+		}
+		for _, e := range []Estimate{s.AvgPlace(), s.AvgPoints(), s.WinRate(), s.DealInRate(), s.RiichiRate(), s.CallRate(), s.AvgWinValue()} {
+			row = append(row, strconv.FormatFloat(e.Mean, 'f', 4, 64), strconv.FormatFloat(e.Margin, 'f', 4, 64))
+		}
+		cw.Write(row)
+	}
+	cw.Flush()
+	return cw.Error()
+}
@@ -0,0 +1,60 @@
+package main
+
+import (
+	"bytes"
+	"encoding/csv"
+	"strings"
+	"testing"
+)
+
+func TestRunSimulate(t *testing.T) {
+	var stdout bytes.Buffer
+	if err := runSimulate([]string{"-games", "2", "-tonpuusen", "-agents", "greedy,random,defensive,greedy"}, &stdout, &bytes.Buffer{}); err != nil {
+		t.Fatalf("runSimulate() error = %v", err)
+	}
+	lines := strings.Split(strings.TrimSpace(stdout.String()), "\n")
+	if len(lines) != 5 || !strings.HasPrefix(lines[0], "SEAT") || !strings.Contains(lines[2], "random") {
+		t.Errorf("runSimulate() = %q, want a header and 4 agents", stdout.String())
+	}
+}
+
+func TestRunSimulate_CSV(t *testing.T) {
+	var stdout bytes.Buffer
+	if err := runSimulate([]string{"-games", "3", "-sanma", "-tonpuusen", "-csv", "-agents", "greedy,defensive,random"}, &stdout, &bytes.Buffer{}); err != nil {
+		t.Fatalf("runSimulate() error = %v", err)
+	}
+	rows, err := csv.NewReader(&stdout).ReadAll()
+	if err != nil {
+		t.Fatalf("runSimulate() CSV error = %v", err)
+	}
+	if len(rows) != 4 {
+		t.Fatalf("runSimulate() = %d rows, want a header and 3 agents", len(rows))
+	}
+	// Four counts and seven estimates with their confidence intervals
+	if want := 4 + 3 + 14; len(rows[0]) != want || rows[0][4] != "place_1" || rows[0][len(rows[0])-1] != "avg_win_value_ci" {
+		t.Errorf("runSimulate() header = %v, want %d columns", rows[0], want)
+	}
+	if rows[3][1] != "random" || rows[3][2] != "3" {
+		t.Errorf("runSimulate() row = %v, want random with 3 games", rows[3])
+	}
+}
+
+func TestRunSimulate_Errors(t *testing.T) {
+	tests := []struct {
+		name string
+		args []string
+		want string
+	}{
+		{"unknown bot", []string{"-agents", "greedy,oracle,greedy,greedy"}, `unknown bot "oracle"`},
+		{"agent count", []string{"-agents", "greedy,greedy"}, "2 agents, want 4"},
+		{"games", []string{"-games", "0"}, "games must be positive"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := runSimulate(tt.args, &bytes.Buffer{}, &bytes.Buffer{})
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("runSimulate() error = %v, want %q", err, tt.want)
+			}
+		})
+	}
+}
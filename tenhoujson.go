@@ -0,0 +1,948 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Import and export of tenhou.net/6 JSON logs. Tiles are two-digit codes: 11-19 man, 21-29 pin,
+// 31-39 sou, 41-47 winds and dragons, 51-53 the red fives. Each seat has a list of tiles taken
+// (draws and calls) and a list of tiles given up (discards, closed and added kans, kita).
+// In a call string the letter marks the called tile, and its position tells which seat it came from.
+
+// Discard code for the tile just drawn
+const tenhouTsumogiri = 60
+
+// Tenhou yaku names, in the order of tenhouYaku
+var tenhouYakuJapanese = [...]string{
+	"門前清自摸和", "立直", "一発", "槍槓",
+	"嶺上開花", "海底摸月", "河底撈魚", "平和",
+	"断幺九", "一盃口", "自風 東", "自風 南",
+	"自風 西", "自風 北", "場風 東", "場風 南",
+	"場風 西", "場風 北", "役牌 白", "役牌 發",
+	"役牌 中", "両立直", "七対子", "混全帯幺九",
+	"一気通貫", "三色同順", "三色同刻", "三槓子",
+	"対々和", "三暗刻", "小三元", "混老頭",
+	"二盃口", "純全帯幺九", "混一色", "清一色",
+	"人和", "天和", "地和", "大三元",
+	"四暗刻", "四暗刻単騎", "字一色", "緑一色",
+	"清老頭", "九蓮宝燈", "純正九蓮宝燈", "国士無双",
+	"国士無双１３面", "大四喜", "小四喜", "四槓子",
+	"ドラ", "裏ドラ", "赤ドラ",
+}
+
+// Yaku names outside the Tenhou yaku table, or written differently when exported
+var tenhouYakuExtra = map[string]string{
+	"Yakuhai (Value Tiles)": "役牌",
+	"Kita (Nukidora)":       "抜きドラ",
+}
+
+// Limit names keyed by HandScore.Limit
+var tenhouLimitNames = map[string]string{
+	"Mangan":        "満貫",
+	"Haneman":       "跳満",
+	"Baiman":        "倍満",
+	"Sanbaiman":     "三倍満",
+	"Kazoe Yakuman": "役満",
+	"Yakuman":       "役満",
+}
+
+// Lowest han of each limit, for wins whose han are not written out
+var tenhouLimitHan = map[string]int{"満貫": 5, "跳満": 6, "倍満": 8, "三倍満": 11, "役満": 13}
+
+// Round result names
+const (
+	tenhouAgari     = "和了"
+	tenhouRyuukyoku = "流局"
+	tenhouAllTenpai = "全員聴牌"
+	tenhouAllNoten  = "全員不聴"
+	tenhouNagashi   = "流し満貫"
+	tenhouKyuushu   = "九種九牌"
+	tenhouSuufon    = "四風連打"
+	tenhouSuucha    = "四家立直"
+	tenhouSuukaikan = "四槓散了"
+	tenhouSanchahou = "三家和了"
+)
+
+// Seats written in every log, left empty for the missing seat in sanma
+const tenhouLogPlayers = 4
+
+var tenhouAbortiveNames = map[AbortiveDraw]string{
+	Abortive_KyuushuKyuuhai: tenhouKyuushu,
+	Abortive_SuufonRenda:    tenhouSuufon,
+	Abortive_SuuchaRiichi:   tenhouSuucha,
+	Abortive_Suukaikan:      tenhouSuukaikan,
+	Abortive_Sanchahou:      tenhouSanchahou,
+}
+
+var (
+	tenhouHanFu   = regexp.MustCompile(`(\d+)符(\d+)飜`)
+	tenhouPoints  = regexp.MustCompile(`(\d+)(?:-(\d+))?点(∀)?`)
+	tenhouYakuHan = regexp.MustCompile(`^(.*)\((?:(\d+)飜|(役満))\)$`)
+)
+
+type tenhouLog struct {
+	Title []string            `json:"title"`
+	Name  []string            `json:"name"`
+	Rule  tenhouRule          `json:"rule"`
+	Log   [][]json.RawMessage `json:"log"`
+}
+
+type tenhouRule struct {
+	Disp  string `json:"disp"`
+	Aka   int    `json:"aka"`
+	Aka51 int    `json:"aka51,omitempty"`
+	Aka52 int    `json:"aka52,omitempty"`
+	Aka53 int    `json:"aka53,omitempty"`
+}
+
+// Converts a tile to its Tenhou code
+func tenhouCode(t Tile) int {
+	switch {
+	case t.Red:
+		return 51 + int(t.Suit)
+	case t.Suit == Honor:
+		return 41 + t.Rank
+	}
+	return 10*(int(t.Suit)+1) + t.Rank + 1
+}
+
+// Converts a Tenhou code to a tile
+func tenhouCodeTile(code int) (Tile, bool) {
+	switch {
+	case code >= 51 && code <= 53:
+		return ParseTile((code-51)*9+4, true), true
+	case code >= 41 && code <= 47:
+		return ParseTile(27+code-41, false), true
+	case code >= 11 && code <= 39 && code%10 != 0:
+		return ParseTile((code/10-1)*9+code%10-1, false), true
+	}
+	return Tile{}, false
+}
+
+// Returns the Japanese name of a yaku, or the name itself when Tenhou has none
+func tenhouYakuName(name string) string {
+	if jp, ok := tenhouYakuExtra[name]; ok {
+		return jp
+	}
+	for i, en := range tenhouYaku {
+		if en == name {
+			return tenhouYakuJapanese[i]
+		}
+	}
+	return name
+}
+
+// Returns the yaku name for a Japanese Tenhou name, or the name itself when unknown
+func tenhouYakuFromJapanese(jp string) string {
+	for en, name := range tenhouYakuExtra {
+		if name == jp {
+			return en
+		}
+	}
+	for i, name := range tenhouYakuJapanese {
+		if name == jp {
+			return tenhouYaku[i]
+		}
+	}
+	return jp
+}
+
+// Pads a per-seat list to the four seats of a log
+func tenhouPad(values []int) []int {
+	padded := make([]int, tenhouLogPlayers)
+	copy(padded, values)
+	return padded
+}
+
+// Riichi deposits paid by each seat during a round; a riichi discard that deals in pays none
+func riichiDeposits(round RoundRecord, players int) []int {
+	deposits := make([]int, players)
+	declared := make([]bool, players)
+	last := -1 // seat whose riichi discard is the latest discard
+	for _, ev := range round.Events {
+		switch ev.Type {
+		case Event_Riichi:
+			declared[ev.Seat] = true
+		case Event_Discard:
+			last = -1
+			if declared[ev.Seat] {
+				declared[ev.Seat] = false
+				deposits[ev.Seat]++
+				last = ev.Seat
+			}
+		case Event_Win:
+			if ev.From == last && ev.Seat != ev.From {
+				deposits[last]--
+				last = -1
+			}
+		}
+	}
+	return deposits
+}
+
+// Writes a game record as a tenhou.net/6 JSON log
+func WriteTenhouJSON(w io.Writer, record *GameRecord) error {
+	log := tenhouLog{Title: []string{"", ""}, Rule: tenhouRuleOf(record.Rules)}
+	for seat := 0; seat < tenhouLogPlayers; seat++ {
+		name := ""
+		if seat < len(record.Players) {
+			name = record.Players[seat]
+		}
+		log.Name = append(log.Name, name)
+	}
+	for _, round := range record.Rounds {
+		var entry []json.RawMessage
+		for _, v := range tenhouRound(round) {
+			raw, err := json.Marshal(v)
+			if err != nil {
+				return err
+			}
+			entry = append(entry, raw)
+		}
+		log.Log = append(log.Log, entry)
+	}
+	return json.NewEncoder(w).Encode(log)
+}
+
+// Describes the rules in the log header
+func tenhouRuleOf(rules Rules) tenhouRule {
+	disp := "般南喰"
+	if rules.Length == Length_Tonpuusen {
+		disp = "般東喰"
+	}
+	if rules.Players == 3 {
+		disp = "三" + disp
+	}
+	rule := tenhouRule{Aka51: rules.RedFives[0], Aka52: rules.RedFives[1], Aka53: rules.RedFives[2]}
+	if rule.Aka51+rule.Aka52+rule.Aka53 > 0 {
+		disp += "赤"
+		rule.Aka = 1
+	}
+	rule.Disp = disp
+	return rule
+}
+
+// Builds the log entry of a round
+func tenhouRound(round RoundRecord) []any {
+	n := len(round.Setup.Scores)
+	var dora, ura []int
+	for _, t := range round.UraDora {
+		ura = append(ura, tenhouCode(t))
+	}
+	take := make([][]any, tenhouLogPlayers)
+	given := make([][]any, tenhouLogPlayers)
+	for seat := range take {
+		take[seat], given[seat] = []any{}, []any{}
+	}
+	riichi := make([]bool, n)
+	for _, ev := range round.Events {
+		switch ev.Type {
+		case Event_NewDora:
+			dora = append(dora, tenhouCode(ev.Tile))
+		case Event_Draw:
+			take[ev.Seat] = append(take[ev.Seat], tenhouCode(ev.Tile))
+		case Event_Riichi:
+			riichi[ev.Seat] = true
+		case Event_Discard:
+			var d any = tenhouCode(ev.Tile)
+			if ev.Tsumogiri {
+				d = tenhouTsumogiri
+			}
+			if riichi[ev.Seat] {
+				d = fmt.Sprintf("r%d", d)
+				riichi[ev.Seat] = false
+			}
+			given[ev.Seat] = append(given[ev.Seat], d)
+		case Event_Call:
+			call := tenhouCall(ev, n)
+			switch ev.Call {
+			case Action_Ankan, Action_Shouminkan:
+				given[ev.Seat] = append(given[ev.Seat], call)
+			case Action_Daiminkan:
+				// The kan takes the place of a discard
+				take[ev.Seat] = append(take[ev.Seat], call)
+				given[ev.Seat] = append(given[ev.Seat], 0)
+			default:
+				take[ev.Seat] = append(take[ev.Seat], call)
+			}
+		case Event_Kita:
+			given[ev.Seat] = append(given[ev.Seat], fmt.Sprintf("f%d", tenhouCode(ev.Tile)))
+		}
+	}
+
+	setup := round.Setup
+	entry := []any{
+		[]int{setup.RoundWind*4 + setup.Dealer, setup.Honba, setup.RiichiSticks},
+		tenhouPad(setup.Scores),
+		append([]int{}, dora...),
+		append([]int{}, ura...),
+	}
+	for seat := 0; seat < tenhouLogPlayers; seat++ {
+		hand := []int{}
+		if seat < len(round.Hands) {
+			for _, t := range round.Hands[seat] {
+				hand = append(hand, tenhouCode(t))
+			}
+		}
+		entry = append(entry, hand, take[seat], given[seat])
+	}
+	return append(entry, tenhouResult(round))
+}
+
+// Writes a call as a call string: the other tiles with the letter and marked tile inserted at pos
+func tenhouCallString(letter byte, marked Tile, others []Tile, pos int) string {
+	var b strings.Builder
+	for i := 0; i <= len(others); i++ {
+		if i == pos {
+			b.WriteByte(letter)
+			b.WriteString(strconv.Itoa(tenhouCode(marked)))
+		}
+		if i < len(others) {
+			b.WriteString(strconv.Itoa(tenhouCode(others[i])))
+		}
+	}
+	return b.String()
+}
+
+// Removes one copy of a tile from a list, preferring an exact match of red fives
+func withoutTile(tiles []Tile, t Tile) []Tile {
+	index := -1
+	for i, c := range tiles {
+		if c == t {
+			index = i
+			break
+		}
+		if c.ID == t.ID && index < 0 {
+			index = i
+		}
+	}
+	if index < 0 {
+		return append([]Tile{}, tiles...)
+	}
+	return append(append([]Tile{}, tiles[:index]...), tiles[index+1:]...)
+}
+
+// Position of a seat relative to the caller: 0 the seat before (kamicha), 1 across (toimen),
+// 2 the seat after (shimocha)
+func tenhouRelative(seat, from, players int) int {
+	switch (from - seat + players) % players {
+	case players - 1:
+		return 0
+	case 1:
+		return 2
+	}
+	return 1
+}
+
+// Seat at a relative position from the caller
+func tenhouFrom(seat, relative, players int) int {
+	switch relative {
+	case 0:
+		return (seat + players - 1) % players
+	case 2:
+		return (seat + 1) % players
+	}
+	return (seat + 2) % players
+}
+
+// Writes a call event as a call string
+func tenhouCall(ev Event, players int) string {
+	others := withoutTile(ev.Set.Tiles, ev.Tile)
+	rel := tenhouRelative(ev.Seat, ev.Set.Target, players)
+	switch ev.Call {
+	case Action_Chi:
+		return tenhouCallString('c', ev.Tile, others, 0)
+	case Action_Pon:
+		return tenhouCallString('p', ev.Tile, others, rel)
+	case Action_Daiminkan:
+		return tenhouCallString('m', ev.Tile, others, []int{0, 1, 3}[rel])
+	case Action_Shouminkan:
+		return tenhouCallString('k', ev.Tile, others, rel)
+	}
+	return tenhouCallString('a', ev.Tile, others, 3)
+}
+
+// Builds the result entry of a round. Point changes leave out riichi deposits, which the
+// riichi discards already show.
+func tenhouResult(round RoundRecord) []any {
+	res := round.Result
+	n := len(round.Setup.Scores)
+	deposits := riichiDeposits(round, n)
+	deltas := make([]int, n)
+	for seat := range deltas {
+		if seat < len(res.Deltas) {
+			deltas[seat] = res.Deltas[seat] + riichiDeposit*deposits[seat]
+		}
+	}
+
+	switch {
+	case len(res.Wins) > 0:
+		entry := []any{tenhouAgari}
+		sticks := round.Setup.RiichiSticks
+		for _, d := range deposits {
+			sticks += d
+		}
+		for i, win := range res.Wins {
+			// Each win but the last shows its own payment; the last takes the remainder
+			winDeltas := deltas
+			if i < len(res.Wins)-1 {
+				winDeltas = make([]int, n)
+				pay := win.Score.Ron
+				if i == 0 {
+					pay += 300 * round.Setup.Honba
+					winDeltas[win.Seat] += riichiDeposit * sticks
+				}
+				winDeltas[win.Seat] += pay
+				winDeltas[win.From] -= pay
+				for seat := range deltas {
+					deltas[seat] -= winDeltas[seat]
+				}
+			}
+			entry = append(entry, tenhouPad(winDeltas), tenhouWin(win))
+		}
+		return entry
+	case res.Abortive != Abortive_None:
+		return []any{tenhouAbortiveNames[res.Abortive]}
+	case len(res.Nagashi) > 0:
+		return []any{tenhouNagashi, tenhouPad(deltas)}
+	}
+	tenpai := 0
+	for _, t := range res.Tenpai {
+		if t {
+			tenpai++
+		}
+	}
+	switch tenpai {
+	case 0:
+		return []any{tenhouAllNoten}
+	case n:
+		return []any{tenhouAllTenpai}
+	}
+	return []any{tenhouRyuukyoku, tenhouPad(deltas)}
+}
+
+// Describes a win: winner, discarder, liable seat, the score summary and the yaku
+func tenhouWin(win Win) []any {
+	s := win.Score
+	liable := win.Seat
+	if win.Liable >= 0 {
+		liable = win.Liable
+	}
+	var points string
+	switch {
+	case !s.Tsumo:
+		points = fmt.Sprintf("%d点", s.Ron)
+	case s.Dealer:
+		points = fmt.Sprintf("%d点∀", s.TsumoOther)
+	default:
+		points = fmt.Sprintf("%d-%d点", s.TsumoOther, s.TsumoDealer)
+	}
+	summary := fmt.Sprintf("%d符%d飜%s", s.Fu, s.Han, points)
+	if name, ok := tenhouLimitNames[s.Limit]; ok {
+		summary = name + points
+	}
+	info := []any{win.Seat, win.From, liable, summary}
+	for i, name := range s.Yaku {
+		// Yakuman are written as such, other yaku with their han when the score records them
+		switch {
+		case s.Yakuman > 0:
+			info = append(info, tenhouYakuName(name)+"(役満)")
+		case i < len(s.YakuHan):
+			info = append(info, fmt.Sprintf("%s(%d飜)", tenhouYakuName(name), s.YakuHan[i]))
+		default:
+			info = append(info, tenhouYakuName(name))
+		}
+	}
+	return info
+}
+
+// Reads a tenhou.net/6 JSON log
+func ParseTenhouJSON(r io.Reader) (*GameRecord, error) {
+	var log tenhouLog
+	if err := json.NewDecoder(r).Decode(&log); err != nil {
+		return nil, fmt.Errorf("tenhou json: %w", err)
+	}
+	rules, players := DefaultRules(), 4
+	if strings.Contains(log.Rule.Disp, "三") || len(log.Name) == 3 {
+		rules, players = SanmaRules(), 3
+	}
+	if strings.Contains(log.Rule.Disp, "東") {
+		rules.Length = Length_Tonpuusen
+	}
+	switch {
+	case log.Rule.Aka51+log.Rule.Aka52+log.Rule.Aka53 > 0:
+		rules.RedFives = [3]int{log.Rule.Aka51, log.Rule.Aka52, log.Rule.Aka53}
+	case log.Rule.Aka == 0:
+		rules.RedFives = [3]int{}
+	}
+
+	record := &GameRecord{Players: log.Name[:min(players, len(log.Name))], Rules: rules}
+	for i, entry := range log.Log {
+		round, err := parseTenhouRound(entry, players)
+		if err != nil {
+			return nil, fmt.Errorf("tenhou json round %d: %w", i+1, err)
+		}
+		record.Rounds = append(record.Rounds, *round)
+	}
+	return record, nil
+}
+
+// Decodes a list of tile codes
+func tenhouTiles(raw json.RawMessage) ([]Tile, error) {
+	var codes []int
+	if err := json.Unmarshal(raw, &codes); err != nil {
+		return nil, fmt.Errorf("invalid tiles %s", raw)
+	}
+	var tiles []Tile
+	for _, code := range codes {
+		t, ok := tenhouCodeTile(code)
+		if !ok {
+			return nil, fmt.Errorf("invalid tile %d", code)
+		}
+		tiles = append(tiles, t)
+	}
+	return tiles, nil
+}
+
+// State of a round log being replayed into events
+type tenhouReplay struct {
+	players int
+	round   *RoundRecord
+	take    [][]any // tile codes (float64) and call strings per seat
+	given   [][]any
+	next    []int // next take per seat
+	nextOut []int // next given entry per seat
+	dora    []Tile
+	shown   int  // dora indicators emitted so far
+	last    Tile // latest tile drawn, discarded or added to a kan
+}
+
+// Decodes the log entry of a round
+func parseTenhouRound(entry []json.RawMessage, players int) (*RoundRecord, error) {
+	if len(entry) < 4+3*tenhouLogPlayers+1 && len(entry) != 4+3*players+1 {
+		return nil, fmt.Errorf("round has %d fields", len(entry))
+	}
+	var header, scores []int
+	if err := json.Unmarshal(entry[0], &header); err != nil || len(header) < 3 || header[0] < 0 || header[0]%4 >= players {
+		return nil, fmt.Errorf("invalid round header %s", entry[0])
+	}
+	if err := json.Unmarshal(entry[1], &scores); err != nil || len(scores) < players {
+		return nil, fmt.Errorf("invalid scores %s", entry[1])
+	}
+	dora, err := tenhouTiles(entry[2])
+	if err != nil {
+		return nil, err
+	}
+	ura, err := tenhouTiles(entry[3])
+	if err != nil {
+		return nil, err
+	}
+	setup := RoundSetup{
+		RoundWind:    header[0] / 4,
+		Dealer:       header[0] % 4,
+		Honba:        header[1],
+		RiichiSticks: header[2],
+		Scores:       scores[:players],
+	}
+	p := &tenhouReplay{
+		players: players,
+		round:   &RoundRecord{Setup: setup, UraDora: ura},
+		dora:    dora,
+		next:    make([]int, players),
+		nextOut: make([]int, players),
+	}
+	for seat := 0; seat < players; seat++ {
+		hand, err := tenhouTiles(entry[4+3*seat])
+		if err != nil {
+			return nil, err
+		}
+		sortTiles(hand)
+		p.round.Hands = append(p.round.Hands, hand)
+		var take, given []any
+		if json.Unmarshal(entry[5+3*seat], &take) != nil || json.Unmarshal(entry[6+3*seat], &given) != nil {
+			return nil, fmt.Errorf("invalid moves for seat %d", seat)
+		}
+		p.take = append(p.take, take)
+		p.given = append(p.given, given)
+	}
+
+	p.emit(Event{Type: Event_StartRound, Seat: setup.Dealer})
+	p.revealDora()
+	if err := p.play(); err != nil {
+		return nil, err
+	}
+	if err := p.result(entry[len(entry)-1]); err != nil {
+		return nil, err
+	}
+	return p.round, nil
+}
+
+func (p *tenhouReplay) emit(ev Event) {
+	p.round.Events = append(p.round.Events, ev)
+}
+
+// Emits the next dora indicator, if any
+func (p *tenhouReplay) revealDora() {
+	if p.shown < len(p.dora) {
+		p.emit(Event{Type: Event_NewDora, Tile: p.dora[p.shown]})
+		p.shown++
+	}
+}
+
+// Decodes a tile code read from a move list
+func tenhouMoveTile(v float64) (Tile, error) {
+	t, ok := tenhouCodeTile(int(v))
+	if !ok {
+		return Tile{}, fmt.Errorf("invalid tile %v", v)
+	}
+	return t, nil
+}
+
+// Decodes a call string made by a seat into an event
+func (p *tenhouReplay) parseCall(seat int, s string) (Event, error) {
+	pos := strings.IndexAny(s, "cpmkaf")
+	if pos < 0 || pos%2 != 0 {
+		return Event{}, fmt.Errorf("invalid call %q", s)
+	}
+	digits := s[:pos] + s[pos+1:]
+	if len(digits)%2 != 0 {
+		return Event{}, fmt.Errorf("invalid call %q", s)
+	}
+	var tiles []Tile
+	for i := 0; i < len(digits); i += 2 {
+		code, err := strconv.Atoi(digits[i : i+2])
+		t, ok := tenhouCodeTile(code)
+		if err != nil || !ok {
+			return Event{}, fmt.Errorf("invalid call %q", s)
+		}
+		tiles = append(tiles, t)
+	}
+	if len(tiles) <= pos/2 {
+		return Event{}, fmt.Errorf("invalid call %q", s)
+	}
+	marked := tiles[pos/2]
+	set := Set{Tiles: append([]Tile{}, tiles...), Open: true}
+	sortTiles(set.Tiles)
+	ev := Event{Type: Event_Call, Seat: seat, Tile: marked}
+	n := p.players
+	switch s[pos] {
+	case 'c':
+		ev.Call, set.Type, set.Target = Action_Chi, Shuntsu, tenhouFrom(seat, 0, n)
+	case 'p':
+		ev.Call, set.Type, set.Target = Action_Pon, Koutsu, tenhouFrom(seat, pos/2, n)
+	case 'k':
+		ev.Call, set.Type, set.Target = Action_Shouminkan, Kantsu, tenhouFrom(seat, pos/2, n)
+	case 'm':
+		ev.Call, set.Type, set.Target = Action_Daiminkan, Kantsu, tenhouFrom(seat, min(pos/2, 2), n)
+	case 'a':
+		ev.Call, set.Type, set.Target, set.Open = Action_Ankan, Kantsu, seat, false
+	case 'f':
+		return Event{Type: Event_Kita, Seat: seat, Tile: marked}, nil
+	}
+	if len(set.Tiles) != map[SetType]int{Shuntsu: 3, Koutsu: 3, Kantsu: 4}[set.Type] {
+		return Event{}, fmt.Errorf("invalid call %q", s)
+	}
+	ev.Set = set
+	return ev, nil
+}
+
+// Emits a call, revealing a dora indicator for kans
+func (p *tenhouReplay) call(ev Event) {
+	p.emit(ev)
+	if ev.Type == Event_Call && ev.Set.Type == Kantsu {
+		p.revealDora()
+	}
+	p.last = ev.Tile
+}
+
+// Returns the next given entry of a seat
+func (p *tenhouReplay) nextGiven(seat int) (any, error) {
+	if p.nextOut[seat] >= len(p.given[seat]) {
+		return nil, fmt.Errorf("seat %d has no discard left", seat)
+	}
+	v := p.given[seat][p.nextOut[seat]]
+	p.nextOut[seat]++
+	return v, nil
+}
+
+// Replays the moves of every seat in turn order
+func (p *tenhouReplay) play() error {
+	seat := p.round.Setup.Dealer
+	for p.next[seat] < len(p.take[seat]) {
+		kan := false
+		switch v := p.take[seat][p.next[seat]].(type) {
+		case float64:
+			t, err := tenhouMoveTile(v)
+			if err != nil {
+				return err
+			}
+			p.last = t
+			p.emit(Event{Type: Event_Draw, Seat: seat, Tile: t})
+		case string:
+			ev, err := p.parseCall(seat, v)
+			if err != nil {
+				return err
+			}
+			p.call(ev)
+			kan = ev.Call == Action_Daiminkan
+		default:
+			return fmt.Errorf("invalid move %v", v)
+		}
+		p.next[seat]++
+		if kan {
+			p.nextGiven(seat) // the placeholder in place of a discard
+			continue
+		}
+
+		out, err := p.nextGiven(seat)
+		if err != nil {
+			// The round ended on the seat's draw: a tsumo or kyuushu kyuuhai
+			return nil
+		}
+		riichi := false
+		if s, ok := out.(string); ok {
+			if !strings.HasPrefix(s, "r") {
+				ev, err := p.parseCall(seat, s)
+				if err != nil {
+					return err
+				}
+				p.call(ev)
+				continue
+			}
+			riichi = true
+			code, err := strconv.Atoi(s[1:])
+			if err != nil {
+				return fmt.Errorf("invalid riichi %q", s)
+			}
+			out = float64(code)
+		}
+		code, ok := out.(float64)
+		if !ok {
+			return fmt.Errorf("invalid discard %v", out)
+		}
+		if riichi {
+			p.emit(Event{Type: Event_Riichi, Seat: seat})
+		}
+		tile := p.last
+		tsumogiri := code == tenhouTsumogiri
+		if !tsumogiri {
+			if tile, err = tenhouMoveTile(code); err != nil {
+				return err
+			}
+		}
+		p.last = tile
+		p.emit(Event{Type: Event_Discard, Seat: seat, Tile: tile, Tsumogiri: tsumogiri})
+		seat = p.nextSeat(seat, tile)
+	}
+	return nil
+}
+
+// Finds the seat that takes the next turn after a discard: a seat whose next move calls the
+// tile from the discarder, pon and kan before chi, otherwise the next seat
+func (p *tenhouReplay) nextSeat(from int, tile Tile) int {
+	caller := -1
+	for i := 1; i < p.players; i++ {
+		seat := (from + i) % p.players
+		if p.next[seat] >= len(p.take[seat]) {
+			continue
+		}
+		s, ok := p.take[seat][p.next[seat]].(string)
+		if !ok {
+			continue
+		}
+		ev, err := p.parseCall(seat, s)
+		if err != nil || ev.Set.Target != from || ev.Tile.ID != tile.ID {
+			continue
+		}
+		if ev.Call != Action_Chi {
+			return seat
+		}
+		caller = seat
+	}
+	if caller >= 0 {
+		return caller
+	}
+	return (from + 1) % p.players
+}
+
+// Decodes the result entry of a round
+func (p *tenhouReplay) result(raw json.RawMessage) error {
+	var entry []json.RawMessage
+	var name string
+	if json.Unmarshal(raw, &entry) != nil || len(entry) == 0 || json.Unmarshal(entry[0], &name) != nil {
+		return fmt.Errorf("invalid result %s", raw)
+	}
+	n := p.players
+	res := &p.round.Result
+	deltas := make([]int, n)
+	addDeltas := func(raw json.RawMessage) error {
+		var values []int
+		if err := json.Unmarshal(raw, &values); err != nil || len(values) < n {
+			return fmt.Errorf("invalid point changes %s", raw)
+		}
+		for seat := range deltas {
+			deltas[seat] += values[seat]
+		}
+		return nil
+	}
+
+	switch name {
+	case tenhouAgari:
+		for i := 1; i+1 < len(entry); i += 2 {
+			if err := addDeltas(entry[i]); err != nil {
+				return err
+			}
+			var info []any
+			if err := json.Unmarshal(entry[i+1], &info); err != nil {
+				return fmt.Errorf("invalid win %s", entry[i+1])
+			}
+			win, err := tenhouParseWin(info, p.round.Setup.Dealer, p.last)
+			if err != nil {
+				return err
+			}
+			res.Wins = append(res.Wins, win)
+			if win.Seat == p.round.Setup.Dealer {
+				res.DealerRepeat = true
+			}
+			p.emit(Event{Type: Event_Win, Seat: win.Seat, From: win.From, Tile: win.Tile, Score: win.Score})
+		}
+		if len(res.Wins) == 0 {
+			return fmt.Errorf("win without winners")
+		}
+	case tenhouRyuukyoku, tenhouAllTenpai, tenhouAllNoten:
+		res.Exhaustive = true
+		res.Tenpai = make([]bool, n)
+		if len(entry) > 1 {
+			if err := addDeltas(entry[1]); err != nil {
+				return err
+			}
+		}
+		for seat := range res.Tenpai {
+			res.Tenpai[seat] = name == tenhouAllTenpai || deltas[seat] > 0
+		}
+		res.DealerRepeat = res.Tenpai[p.round.Setup.Dealer]
+		p.emit(Event{Type: Event_ExhaustiveDraw})
+	case tenhouNagashi:
+		res.Exhaustive = true
+		if len(entry) > 1 {
+			if err := addDeltas(entry[1]); err != nil {
+				return err
+			}
+		}
+		for seat, d := range deltas {
+			if d > 0 {
+				res.Nagashi = append(res.Nagashi, seat)
+			}
+		}
+		res.DealerRepeat = containsInt(res.Nagashi, p.round.Setup.Dealer)
+		p.emit(Event{Type: Event_ExhaustiveDraw})
+	default:
+		for kind, abortive := range tenhouAbortiveNames {
+			if abortive == name {
+				res.Abortive = kind
+			}
+		}
+		if res.Abortive == Abortive_None {
+			return fmt.Errorf("unknown result %q", name)
+		}
+		res.DealerRepeat = true
+		p.emit(Event{Type: Event_AbortiveDraw, Abortive: res.Abortive})
+	}
+
+	// Recorded point changes leave out riichi deposits
+	deposits := riichiDeposits(*p.round, n)
+	res.Deltas = make([]int, n)
+	res.Scores = make([]int, n)
+	res.RiichiSticks = p.round.Setup.RiichiSticks
+	for seat := range deltas {
+		res.Deltas[seat] = deltas[seat] - riichiDeposit*deposits[seat]
+		res.Scores[seat] = p.round.Setup.Scores[seat] + res.Deltas[seat]
+		res.RiichiSticks += deposits[seat]
+	}
+	if len(res.Wins) > 0 {
+		res.RiichiSticks = 0
+	}
+	return nil
+}
+
+// Decodes a win: winner, discarder, liable seat, the score summary and the yaku
+func tenhouParseWin(info []any, dealer int, tile Tile) (Win, error) {
+	if len(info) < 4 {
+		return Win{}, fmt.Errorf("invalid win %v", info)
+	}
+	var seats [3]int
+	for i := range seats {
+		v, ok := info[i].(float64)
+		if !ok {
+			return Win{}, fmt.Errorf("invalid win %v", info)
+		}
+		seats[i] = int(v)
+	}
+	summary, ok := info[3].(string)
+	if !ok {
+		return Win{}, fmt.Errorf("invalid win %v", info)
+	}
+	win := Win{Seat: seats[0], From: seats[1], Tile: tile, Liable: -1}
+	if seats[2] != seats[0] {
+		win.Liable = seats[2]
+	}
+	score := HandScore{Dealer: win.Seat == dealer, Tsumo: win.Seat == win.From}
+
+	han := 0
+	for _, v := range info[4:] {
+		s, _ := v.(string)
+		m := tenhouYakuHan.FindStringSubmatch(s)
+		if m == nil {
+			score.Yaku = append(score.Yaku, tenhouYakuFromJapanese(s))
+			continue
+		}
+		score.Yaku = append(score.Yaku, tenhouYakuFromJapanese(m[1]))
+		if m[3] != "" {
+			score.Yakuman++
+			score.YakuHan = append(score.YakuHan, 13)
+			continue
+		}
+		h, _ := strconv.Atoi(m[2])
+		han += h
+		score.YakuHan = append(score.YakuHan, h)
+	}
+	switch m := tenhouHanFu.FindStringSubmatch(summary); {
+	case m != nil:
+		score.Fu, _ = strconv.Atoi(m[1])
+		score.Han, _ = strconv.Atoi(m[2])
+	case score.Yakuman > 0:
+		score.Han = 13 * score.Yakuman
+	case han > 0:
+		score.Han = han
+	default:
+		for name, minimum := range tenhouLimitHan {
+			if strings.HasPrefix(summary, name) {
+				score.Han = minimum
+			}
+		}
+	}
+	score.computePayments()
+
+	// The written payments are authoritative
+	m := tenhouPoints.FindStringSubmatch(summary)
+	if m == nil {
+		return Win{}, fmt.Errorf("invalid score %q", summary)
+	}
+	first, _ := strconv.Atoi(m[1])
+	second, _ := strconv.Atoi(m[2])
+	switch {
+	case !score.Tsumo:
+		score.Ron = first
+	case score.Dealer:
+		score.TsumoOther = first
+	default:
+		score.TsumoOther, score.TsumoDealer = first, second
+	}
+	win.Score = score
+	return win, nil
+}
@@ -0,0 +1,243 @@
+package main
+
+import (
+	"bytes"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+const sampleTenhouJSON = `{"title":["",""],"name":["Alice","Bob","Carol","Dave"],"rule":{"disp":"般南喰赤","aka":1},"log":[
+[[0,0,0],[25000,25000,25000,25000],[12],[13],
+ [11,11,19,21,29,31,39,41,42,43,44,45,46],[11],["r60"],
+ [11,11,22,24,25,26,27,28,29,33,34,35,36],["p111111"],[23],
+ [22,24,51,52,53,15,16,17,18,19,45,46,47],["c232224"],[45],
+ [14,15,16,17,18,19,21,24,25,26,27,28,29],[33],[33],
+ ["和了",[3900,0,0,-2900],[0,3,0,"30符2飜2900点","立直(1飜)","ドラ(1飜)"]]],
+[[0,1,0],[27900,25000,25000,22100],[21],[],
+ [11,19,21,29,31,39,41,42,43,44,45,46,12],[47],[],
+ [],[],[],[],[],[],[],[],[],
+ ["九種九牌"]],
+[[1,1,0],[27900,25000,25000,22100],[21],[],
+ [],[],[],[],[],[],[],[],[],[],[],[],
+ ["流局",[1500,-1500,1500,-1500]]]
+]}`
+
+func TestTenhouCode(t *testing.T) {
+	for id := 0; id < 34; id++ {
+		for _, red := range []bool{false, true} {
+			tile := ParseTile(id, red)
+			if red && (tile.Suit == Honor || tile.Rank != 4) {
+				continue
+			}
+			got, ok := tenhouCodeTile(tenhouCode(tile))
+			if !ok || got != tile {
+				t.Errorf("tenhouCodeTile(tenhouCode(%+v)) = %+v, want the same tile", tile, got)
+			}
+		}
+	}
+	for _, code := range []int{0, 10, 20, 48, 50, 54, 60} {
+		if _, ok := tenhouCodeTile(code); ok {
+			t.Errorf("tenhouCodeTile(%d) ok, want invalid", code)
+		}
+	}
+}
+
+func TestParseTenhouJSON(t *testing.T) {
+	record, err := ParseTenhouJSON(strings.NewReader(sampleTenhouJSON))
+	if err != nil {
+		t.Fatalf("ParseTenhouJSON() error = %v", err)
+	}
+	if len(record.Rounds) != 3 || len(record.Players) != 4 || record.Rules.RedFives != [3]int{1, 1, 1} {
+		t.Fatalf("ParseTenhouJSON() = %v rounds, players %v, rules %+v", len(record.Rounds), record.Players, record.Rules)
+	}
+
+	first := record.Rounds[0]
+	var types []EventType
+	for _, ev := range first.Events {
+		types = append(types, ev.Type)
+	}
+	want := []EventType{Event_StartRound, Event_NewDora, Event_Draw, Event_Riichi, Event_Discard, Event_Call,
+		Event_Discard, Event_Call, Event_Discard, Event_Draw, Event_Discard, Event_Win}
+	if !reflect.DeepEqual(types, want) {
+		t.Fatalf("ParseTenhouJSON() events = %v, want %v", types, want)
+	}
+	if ev := first.Events[4]; !ev.Tsumogiri || ev.Tile.ID != 0 {
+		t.Errorf("ParseTenhouJSON() riichi discard = %+v, want a tsumogiri 1m", ev)
+	}
+	if ev := first.Events[5]; ev.Call != Action_Pon || ev.Seat != 1 || ev.Set.Target != 0 {
+		t.Errorf("ParseTenhouJSON() pon = %+v, want seat 1 calling from seat 0", ev)
+	}
+	if ev := first.Events[7]; ev.Call != Action_Chi || ev.Seat != 2 || ev.Set.Target != 1 || ev.Tile.ID != 11 {
+		t.Errorf("ParseTenhouJSON() chi = %+v, want seat 2 calling 3p from seat 1", ev)
+	}
+	if ev := first.Events[10]; ev.Tsumogiri {
+		t.Errorf("ParseTenhouJSON() hand discard = %+v, want no tsumogiri", ev)
+	}
+	win := first.Result.Wins[0]
+	if win.Seat != 0 || win.From != 3 || win.Tile.ID != 20 || win.Score.Han != 2 || win.Score.Ron != 2900 || win.Liable != -1 {
+		t.Errorf("ParseTenhouJSON() win = %+v", win)
+	}
+	if !reflect.DeepEqual(win.Score.Yaku, []string{"Riichi", "Dora"}) || !reflect.DeepEqual(win.Score.YakuHan, []int{1, 1}) {
+		t.Errorf("ParseTenhouJSON() yaku = %v han %v", win.Score.Yaku, win.Score.YakuHan)
+	}
+	if !reflect.DeepEqual(first.Result.Deltas, []int{2900, 0, 0, -2900}) || first.Result.RiichiSticks != 0 || !first.Result.DealerRepeat {
+		t.Errorf("ParseTenhouJSON() result = %+v", first.Result)
+	}
+	if len(first.UraDora) != 1 || first.UraDora[0].ID != 2 {
+		t.Errorf("ParseTenhouJSON() ura-dora = %v", first.UraDora)
+	}
+
+	if res := record.Rounds[1].Result; res.Abortive != Abortive_KyuushuKyuuhai || !res.DealerRepeat {
+		t.Errorf("ParseTenhouJSON() abortive draw = %+v", res)
+	}
+	draw := record.Rounds[2].Result
+	if !reflect.DeepEqual(draw.Tenpai, []bool{true, false, true, false}) || draw.DealerRepeat || record.Rounds[2].Setup.Dealer != 1 {
+		t.Errorf("ParseTenhouJSON() exhaustive draw = %+v", draw)
+	}
+}
+
+func TestParseTenhouJSON_Invalid(t *testing.T) {
+	for _, input := range []string{
+		`{"log":[[[0,0,0]]]}`,
+		`{"log":[[[0,0,0],[25000,25000,25000,25000],[99],[],[],[],[],[],[],[],[],[],[],[],[],[],["流局",[0,0,0,0]]]]}`,
+		`{"log":[[[0,0,0],[25000,25000,25000,25000],[11],[],[],["x11"],[],[],[],[],[],[],[],[],[],[],["流局",[0,0,0,0]]]]}`,
+		`{"log":[[[0,0,0],[25000,25000,25000,25000],[11],[],[],[],[],[],[],[],[],[],[],[],[],[],["unknown"]]]}`,
+		`{"log":`,
+		`{"name":["A","B","C"],"log":[[[3,0,0],[35000,35000,35000],[11],[],[],[],[],[],[],[],[],[],[],["流局",[0,0,0]]]]}`,
+		`{"log":[[[-1,0,0],[25000,25000,25000,25000],[11],[],[],[],[],[],[],[],[],[],[],[],[],[],["流局",[0,0,0,0]]]]}`,
+	} {
+		if _, err := ParseTenhouJSON(strings.NewReader(input)); err == nil {
+			t.Errorf("ParseTenhouJSON(%q) error = nil, want an error", input)
+		}
+	}
+}
+
+// Moves of a round in comparable form: no dora or win events, sets sorted
+func tenhouMoves(round RoundRecord) []Event {
+	var moves []Event
+	for _, ev := range round.Events {
+		if ev.Type == Event_NewDora || ev.Type == Event_Win {
+			continue
+		}
+		ev.Set.Tiles = append([]Tile{}, ev.Set.Tiles...)
+		sortTiles(ev.Set.Tiles)
+		moves = append(moves, ev)
+	}
+	return moves
+}
+
+func TestTenhouJSON_RoundTrip(t *testing.T) {
+	tests := []struct {
+		name   string
+		rules  Rules
+		agents []Agent
+	}{
+		{"Four players", DefaultRules(), greedyAgents()},
+		{"Sanma", SanmaRules(), sanmaAgents()},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.rules.Length = Length_Tonpuusen
+			m := NewMatch(tt.rules, 3, tt.agents)
+			record := m.Record(m.Play())
+			var buf bytes.Buffer
+			if err := WriteTenhouJSON(&buf, &record); err != nil {
+				t.Fatalf("WriteTenhouJSON() error = %v", err)
+			}
+			got, err := ParseTenhouJSON(&buf)
+			if err != nil {
+				t.Fatalf("ParseTenhouJSON() error = %v", err)
+			}
+			if got.Rules.Players != tt.rules.Players || got.Rules.Length != Length_Tonpuusen || len(got.Rounds) != len(record.Rounds) {
+				t.Fatalf("ParseTenhouJSON() = %v rounds, rules %+v", len(got.Rounds), got.Rules)
+			}
+			for i, want := range record.Rounds {
+				round := got.Rounds[i]
+				setup := want.Setup
+				setup.Seed = 0
+				if !reflect.DeepEqual(round.Setup, setup) || !reflect.DeepEqual(round.Hands, want.Hands) {
+					t.Errorf("round %d setup = %+v, want %+v", i, round.Setup, setup)
+				}
+				if !reflect.DeepEqual(tenhouMoves(round), tenhouMoves(want)) {
+					t.Errorf("round %d moves = %+v, want %+v", i, tenhouMoves(round), tenhouMoves(want))
+				}
+				res, wantRes := round.Result, want.Result
+				if !reflect.DeepEqual(res.Deltas, wantRes.Deltas) || !reflect.DeepEqual(res.Scores, wantRes.Scores) ||
+					res.RiichiSticks != wantRes.RiichiSticks || res.DealerRepeat != wantRes.DealerRepeat ||
+					res.Abortive != wantRes.Abortive || len(res.Wins) != len(wantRes.Wins) {
+					t.Errorf("round %d result = %+v, want %+v", i, res, wantRes)
+					continue
+				}
+				for j, win := range res.Wins {
+					w := wantRes.Wins[j]
+					if win.Seat != w.Seat || win.From != w.From || win.Tile != w.Tile || win.Liable != w.Liable ||
+						win.Score.Ron != w.Score.Ron || win.Score.TsumoOther != w.Score.TsumoOther ||
+						win.Score.TsumoDealer != w.Score.TsumoDealer || win.Score.Limit != w.Score.Limit ||
+						!reflect.DeepEqual(win.Score.Yaku, w.Score.Yaku) || !reflect.DeepEqual(win.Score.YakuHan, w.Score.YakuHan) {
+						t.Errorf("round %d win = %+v, want %+v", i, win, w)
+					}
+				}
+			}
+		})
+	}
+}
+
+func TestTenhouWin(t *testing.T) {
+	score := HandScore{Han: 4, Fu: 30, Yaku: []string{"Riichi", "Dora", "Aka Dora"}, YakuHan: []int{1, 2, 1}, Ron: 7700}
+	got := tenhouWin(Win{Seat: 0, From: 2, Liable: -1, Score: score})
+	want := []any{0, 2, 0, "30符4飜7700点", "立直(1飜)", "ドラ(2飜)", "赤ドラ(1飜)"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("tenhouWin() = %v, want %v", got, want)
+	}
+
+	yakuman := HandScore{Han: 13, Yakuman: 1, Yaku: []string{"Kokushi Musou (Thirteen Orphans)"}, YakuHan: []int{13}, Ron: 32000}
+	if got := tenhouWin(Win{Seat: 1, From: 3, Liable: -1, Score: yakuman}); got[4] != "国士無双(役満)" {
+		t.Errorf("tenhouWin() yakuman = %v, want 国士無双(役満)", got[4])
+	}
+}
+
+func TestTenhouCall(t *testing.T) {
+	five := ParseTile(13, false)
+	red := ParseTile(13, true)
+	tests := []struct {
+		name string
+		ev   Event
+		want string
+	}{
+		{"Chi", Event{Seat: 1, Call: Action_Chi, Tile: ParseTile(2, false),
+			Set: Set{Type: Shuntsu, Tiles: tilesOf(1, 2, 3), Open: true, Target: 0}}, "c131214"},
+		{"Pon from the seat before", Event{Seat: 1, Call: Action_Pon, Tile: red,
+			Set: Set{Type: Koutsu, Tiles: []Tile{five, five, red}, Open: true, Target: 0}}, "p522525"},
+		{"Pon from across", Event{Seat: 1, Call: Action_Pon, Tile: five,
+			Set: Set{Type: Koutsu, Tiles: []Tile{five, five, red}, Open: true, Target: 3}}, "25p2552"},
+		{"Pon from the seat after", Event{Seat: 1, Call: Action_Pon, Tile: five,
+			Set: Set{Type: Koutsu, Tiles: []Tile{five, five, five}, Open: true, Target: 2}}, "2525p25"},
+		{"Called kan from the seat after", Event{Seat: 0, Call: Action_Daiminkan, Tile: ParseTile(27, false),
+			Set: Set{Type: Kantsu, Tiles: tilesOf(27, 27, 27, 27), Open: true, Target: 1}}, "414141m41"},
+		{"Added kan", Event{Seat: 0, Call: Action_Shouminkan, Tile: ParseTile(31, false),
+			Set: Set{Type: Kantsu, Tiles: tilesOf(31, 31, 31, 31), Open: true, Target: 2}}, "45k454545"},
+		{"Closed kan", Event{Seat: 2, Call: Action_Ankan, Tile: ParseTile(8, false),
+			Set: Set{Type: Kantsu, Tiles: tilesOf(8, 8, 8, 8), Target: 2}}, "191919a19"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := tenhouCall(tt.ev, 4)
+			if got != tt.want {
+				t.Errorf("tenhouCall() = %v, want %v", got, tt.want)
+			}
+			p := &tenhouReplay{players: 4}
+			ev, err := p.parseCall(tt.ev.Seat, got)
+			tt.ev.Type = Event_Call
+			sortTiles(tt.ev.Set.Tiles)
+			if err != nil || !reflect.DeepEqual(ev, tt.ev) {
+				t.Errorf("parseCall(%v) = %+v, %v, want %+v", got, ev, err, tt.ev)
+			}
+		})
+	}
+	for _, s := range []string{"c", "12c", "p1111", "12a"} {
+		if _, err := (&tenhouReplay{players: 4}).parseCall(0, s); err == nil {
+			t.Errorf("parseCall(%q) error = nil, want an error", s)
+		}
+	}
+}
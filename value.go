@@ -0,0 +1,221 @@
+package main
+
+// Expected hand value estimation for tenpai and near-tenpai hands
+
+// Value of winning on a single tile of a tenpai hand
+type WaitValue struct {
+	Tile        Tile
+	Remaining   int     // unseen copies according to the KB
+	RonDama     int     // points for ron without riichi, 0 if the hand has no yaku
+	TsumoDama   int     // points for tsumo without riichi
+	RonRiichi   float64 // expected points for ron after riichi, including ura-dora
+	TsumoRiichi float64 // expected points for tsumo after riichi, including ura-dora
+}
+
+// Improvement of a 1-shanten hand: drawing Draw and discarding Discard reaches the tenpai Value
+type Improvement struct {
+	Draw    Tile
+	Discard Tile
+	Count   int // unseen copies of Draw
+	Value   HandValue
+}
+
+// Expected value of a hand, averaged over winning tiles (or improvements) weighted by unseen copies
+type HandValue struct {
+	Shanten      Deficiency
+	Waits        []WaitValue   // winning tiles of a tenpai hand
+	Improvements []Improvement // best improvement per draw of a 1-shanten hand
+	Remaining    int           // unseen winning tiles, or unseen improving tiles for 1-shanten
+	RonDama      float64
+	TsumoDama    float64
+	RonRiichi    float64
+	TsumoRiichi  float64
+}
+
+// Combines the ron and tsumo values of the hand given the share of wins expected to come from tsumo
+func (v HandValue) Expected(riichi bool, tsumoRate float64) float64 {
+	if riichi {
+		return v.TsumoRiichi*tsumoRate + v.RonRiichi*(1-tsumoRate)
+	}
+	return v.TsumoDama*tsumoRate + v.RonDama*(1-tsumoRate)
+}
+
+// Share of wins assumed to come from tsumo when ranking improvements
+const defaultTsumoRate = 0.4
+
+// Probability distribution of the number of ura-dora hits for a winning hand,
+// treating each ura indicator as an independent draw from the unseen tiles
+func uraDistribution(full Hand, indicators int, kb KB, sanma bool) []float64 {
+	dist := []float64{1}
+	total := kb.Total()
+	if total == 0 {
+		return dist
+	}
+	single := map[int]float64{}
+	for id := range kb.remainingTiles {
+		if kb.Remaining(id) == 0 {
+			continue
+		}
+		hits := full.counts[doraFor(ParseTile(id, false), sanma).ID]
+		single[hits] += float64(kb.Remaining(id)) / float64(total)
+	}
+	for i := 0; i < indicators; i++ {
+		next := make([]float64, len(dist)+4)
+		for k, p := range dist {
+			for hits, q := range single {
+				next[k+hits] += p * q
+			}
+		}
+		dist = next
+	}
+	return dist
+}
+
+// Expected points of a riichi win given the base score and ura-dora distribution
+func expectedWithUra(s HandScore, dist []float64) float64 {
+	if s.Yakuman > 0 {
+		return float64(s.Total())
+	}
+	expected := 0.0
+	for k, p := range dist {
+		if p == 0 {
+			continue
+		}
+		expected += p * float64(ScoreFromHanFu(s.Han+k, s.Fu, s.Dealer, s.Tsumo).Total())
+	}
+	return expected
+}
+
+// Values every winning tile of a tenpai hand
+func tenpaiValue(hand Hand, melds []Set, kb KB, winCtx WinContext) HandValue {
+	v := HandValue{Shanten: 0}
+	closed := true
+	for _, m := range melds {
+		if m.Open {
+			closed = false
+		}
+	}
+	for _, id := range Waits(hand, len(melds)) {
+		w := WaitValue{Tile: ParseTile(id, false), Remaining: kb.Remaining(id)}
+		hand.counts[id]++
+		ctx := winCtx
+		ctx.WinningTile = w.Tile
+		ctx.Riichi = false
+		for _, tsumo := range []bool{false, true} {
+			ctx.Tsumo = tsumo
+			if s, ok := ScoreHand(hand, melds, ctx); ok {
+				if tsumo {
+					w.TsumoDama = s.Total()
+				} else {
+					w.RonDama = s.Total()
+				}
+			}
+		}
+		if closed {
+			full := hand
+			for _, m := range melds {
+				for _, t := range m.Tiles {
+					full.counts[t.ID]++
+				}
+			}
+			dist := uraDistribution(full, max(1, len(winCtx.DoraIndicators)), kb, winCtx.Sanma)
+			rctx := ctx
+			rctx.Riichi = true
+			rctx.UraDoraIndicators = nil
+			for _, tsumo := range []bool{false, true} {
+				rctx.Tsumo = tsumo
+				if s, ok := ScoreHand(hand, melds, rctx); ok {
+					if tsumo {
+						w.TsumoRiichi = expectedWithUra(s, dist)
+					} else {
+						w.RonRiichi = expectedWithUra(s, dist)
+					}
+				}
+			}
+		}
+		hand.counts[id]--
+		v.Waits = append(v.Waits, w)
+		v.Remaining += w.Remaining
+	}
+	if v.Remaining == 0 {
+		return v
+	}
+	for _, w := range v.Waits {
+		weight := float64(w.Remaining) / float64(v.Remaining)
+		v.RonDama += weight * float64(w.RonDama)
+		v.TsumoDama += weight * float64(w.TsumoDama)
+		v.RonRiichi += weight * w.RonRiichi
+		v.TsumoRiichi += weight * w.TsumoRiichi
+	}
+	return v
+}
+
+// Strength of a tenpai used to pick the best discard: expected value times winning tiles
+func tenpaiStrength(v HandValue) float64 {
+	best := max(v.Expected(false, defaultTsumoRate), v.Expected(true, defaultTsumoRate))
+	return best * float64(v.Remaining)
+}
+
+// Approximates the value of a 1-shanten hand by the best tenpai reachable from each improving draw
+func improvementValue(hand Hand, melds []Set, kb KB, winCtx WinContext) HandValue {
+	v := HandValue{Shanten: 1}
+	for draw := range hand.counts {
+		if kb.Remaining(draw) == 0 || hand.counts[draw] >= 4 {
+			continue
+		}
+		hand.counts[draw]++
+		var best *Improvement
+		for discard := range hand.counts {
+			if hand.counts[discard] == 0 {
+				continue
+			}
+			hand.counts[discard]--
+			if CalculateDeficiency(hand, len(melds)) == 0 {
+				next := kb
+				next.Reveal(draw)
+				tv := tenpaiValue(hand, melds, next, winCtx)
+				if best == nil || tenpaiStrength(tv) > tenpaiStrength(best.Value) {
+					best = &Improvement{
+						Draw:    ParseTile(draw, false),
+						Discard: ParseTile(discard, false),
+						Count:   kb.Remaining(draw),
+						Value:   tv,
+					}
+				}
+			}
+			hand.counts[discard]++
+		}
+		hand.counts[draw]--
+		if best != nil {
+			v.Improvements = append(v.Improvements, *best)
+			v.Remaining += best.Count
+		}
+	}
+	if v.Remaining == 0 {
+		return v
+	}
+	for _, imp := range v.Improvements {
+		weight := float64(imp.Count) / float64(v.Remaining)
+		v.RonDama += weight * imp.Value.RonDama
+		v.TsumoDama += weight * imp.Value.TsumoDama
+		v.RonRiichi += weight * imp.Value.RonRiichi
+		v.TsumoRiichi += weight * imp.Value.TsumoRiichi
+	}
+	return v
+}
+
+// Estimates the expected value of a hand awaiting a draw (13 tiles counting melds).
+// Tenpai hands are valued over all winning tiles weighted by unseen copies in the KB,
+// for ron and tsumo with and without riichi (riichi values include expected ura-dora).
+// 1-shanten hands are approximated by the best tenpai after each improving draw.
+// Hands further from tenpai return only their shanten.
+func EstimateHandValue(hand Hand, melds []Set, kb KB, winCtx WinContext) HandValue {
+	switch shanten := CalculateDeficiency(hand, len(melds)); shanten {
+	case 0:
+		return tenpaiValue(hand, melds, kb, winCtx)
+	case 1:
+		return improvementValue(hand, melds, kb, winCtx)
+	default:
+		return HandValue{Shanten: shanten}
+	}
+}
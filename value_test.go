@@ -0,0 +1,97 @@
+package main
+
+import (
+	"math"
+	"testing"
+)
+
+func TestUraDistribution(t *testing.T) {
+	kb := NewKB()
+	full := handOf(1, 2, 3, 4, 5, 6, 11, 12, 13, 23, 24, 25, 13, 13)
+	kb.RevealHand(full)
+	dist := uraDistribution(full, 2, kb, false)
+	sum := 0.0
+	for _, p := range dist {
+		sum += p
+	}
+	if math.Abs(sum-1) > 1e-9 {
+		t.Errorf("uraDistribution() sums to %v, want 1", sum)
+	}
+	single := uraDistribution(full, 1, kb, false)
+	if single[0] <= dist[0] || single[0] >= 1 {
+		t.Errorf("uraDistribution() P(0) = %v with one indicator, want between %v and 1", single[0], dist[0])
+	}
+}
+
+func TestEstimateHandValue_Tenpai(t *testing.T) {
+	// 23m ryanmen with 234m 345p 678s and 5p pair: pinfu tanyao on 4m, tanyao only on 1m
+	hand := handOf(1, 2, 1, 2, 3, 11, 12, 13, 23, 24, 25, 13, 13)
+	kb := NewKB()
+	kb.RevealHand(hand)
+	v := EstimateHandValue(hand, nil, kb, WinContext{Seat: 1})
+
+	if v.Shanten != 0 {
+		t.Fatalf("EstimateHandValue() shanten = %v, want 0", v.Shanten)
+	}
+	if len(v.Waits) != 2 || v.Remaining != 7 {
+		t.Fatalf("EstimateHandValue() waits = %v remaining = %v, want 2 waits and 7 tiles", len(v.Waits), v.Remaining)
+	}
+	for _, w := range v.Waits {
+		if w.RonDama <= 0 || w.TsumoDama <= 0 {
+			t.Errorf("wait %v dama values = %v/%v, want positive", w.Tile.ID, w.RonDama, w.TsumoDama)
+		}
+		if w.RonRiichi <= float64(w.RonDama) || w.TsumoRiichi <= float64(w.TsumoDama) {
+			t.Errorf("wait %v riichi values should exceed dama", w.Tile.ID)
+		}
+	}
+	if v.Expected(true, 0.5) <= v.Expected(false, 0.5) {
+		t.Errorf("HandValue.Expected() riichi %v should exceed dama %v", v.Expected(true, 0.5), v.Expected(false, 0.5))
+	}
+}
+
+func TestEstimateHandValue_NoYakuDama(t *testing.T) {
+	// Kanchan wait on 5m with no yaku: dama ron is worthless, tsumo and riichi are not
+	hand := handOf(0, 1, 2, 3, 5, 11, 12, 13, 23, 24, 25, 13, 13)
+	kb := NewKB()
+	kb.RevealHand(hand)
+	v := EstimateHandValue(hand, nil, kb, WinContext{Seat: 1})
+	if len(v.Waits) != 1 {
+		t.Fatalf("EstimateHandValue() waits = %v, want 1", len(v.Waits))
+	}
+	w := v.Waits[0]
+	if w.RonDama != 0 {
+		t.Errorf("RonDama = %v, want 0", w.RonDama)
+	}
+	if w.TsumoDama == 0 || w.RonRiichi == 0 {
+		t.Errorf("TsumoDama = %v RonRiichi = %v, want positive", w.TsumoDama, w.RonRiichi)
+	}
+}
+
+func TestEstimateHandValue_OneShanten(t *testing.T) {
+	hand := handOf(1, 2, 4, 5, 11, 12, 13, 23, 24, 25, 13, 13, 30)
+	kb := NewKB()
+	kb.RevealHand(hand)
+	v := EstimateHandValue(hand, nil, kb, WinContext{Seat: 1})
+	if v.Shanten != 1 {
+		t.Fatalf("EstimateHandValue() shanten = %v, want 1", v.Shanten)
+	}
+	if len(v.Improvements) == 0 || v.Remaining == 0 {
+		t.Fatalf("EstimateHandValue() found no improvements")
+	}
+	for _, imp := range v.Improvements {
+		if imp.Value.Shanten != 0 || imp.Value.Remaining == 0 {
+			t.Errorf("improvement %v/%v does not reach a live tenpai", imp.Draw.ID, imp.Discard.ID)
+		}
+	}
+	if v.Expected(true, 0.4) <= 0 {
+		t.Errorf("HandValue.Expected() = %v, want positive", v.Expected(true, 0.4))
+	}
+}
+
+func TestEstimateHandValue_FarFromTenpai(t *testing.T) {
+	hand := handOf(0, 4, 8, 9, 13, 17, 19, 23, 27, 28, 29, 31, 32)
+	v := EstimateHandValue(hand, nil, NewKB(), WinContext{})
+	if v.Shanten < 2 || len(v.Waits) != 0 || len(v.Improvements) != 0 {
+		t.Errorf("EstimateHandValue() = %+v, want only shanten", v)
+	}
+}
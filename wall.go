@@ -0,0 +1,145 @@
+package main
+
+import "math/rand/v2"
+
+// The wall of tiles and the dead wall with dora indicators and rinshan tiles
+
+// Number of tiles set aside as the dead wall
+const deadWallSize = 14
+
+// Layout of the dead wall: four rinshan tiles, then five dora and five ura-dora indicators
+const (
+	rinshanStart = 0
+	doraStart    = 4
+	uraDoraStart = 9
+	maxDora      = 5
+)
+
+type Wall struct {
+	live         []Tile // drawn from the front
+	dead         []Tile
+	doraRevealed int
+	rinshanDrawn int
+	kitaDrawn    int
+}
+
+// Builds every tile of the set, four copies of each type, marking the first copies
+// of each five as red according to the rules; sanma leaves out 2-8 man
+func buildTiles(rules Rules) []Tile {
+	var tiles []Tile
+	for id := 0; id < 34; id++ {
+		if rules.Players == 3 && sanmaExcluded(id) {
+			continue
+		}
+		for copy := 0; copy < 4; copy++ {
+			t := ParseTile(id, false)
+			if t.Suit != Honor && t.Rank == 4 && copy < rules.RedFives[t.Suit] {
+				t.Red = true
+			}
+			tiles = append(tiles, t)
+		}
+	}
+	return tiles
+}
+
+// Shuffles a full set of tiles and splits off the dead wall, revealing the first dora indicator
+func NewWall(rules Rules, rng *rand.Rand) *Wall {
+	tiles := buildTiles(rules)
+	rng.Shuffle(len(tiles), func(i, j int) {
+		tiles[i], tiles[j] = tiles[j], tiles[i]
+	})
+	split := len(tiles) - deadWallSize
+	return &Wall{live: tiles[:split], dead: tiles[split:], doraRevealed: 1}
+}
+
+// Builds a wall of unseen tiles, sized as it is after the deal, for a round rebuilt from
+// observed events; dora indicators are placed as they are revealed
+func unseenWall(rules Rules) *Wall {
+	live := len(buildTiles(rules)) - deadWallSize - 13*rules.Players
+	return &Wall{live: make([]Tile, live), dead: make([]Tile, deadWallSize)}
+}
+
+// Places the next dora indicator on a wall of unseen tiles and reveals it
+func (w *Wall) showIndicator(t Tile) bool {
+	if w.doraRevealed >= maxDora {
+		return false
+	}
+	w.dead[doraStart+w.doraRevealed] = t
+	w.doraRevealed++
+	return true
+}
+
+// Number of tiles left to draw from the live wall
+func (w *Wall) Remaining() int {
+	return len(w.live)
+}
+
+// Draws the next tile from the live wall
+func (w *Wall) Draw() (Tile, bool) {
+	if len(w.live) == 0 {
+		return Tile{}, false
+	}
+	t := w.live[0]
+	w.live = w.live[1:]
+	return t, true
+}
+
+// Returns the revealed dora indicators
+func (w *Wall) DoraIndicators() []Tile {
+	return append([]Tile{}, w.dead[doraStart:doraStart+w.doraRevealed]...)
+}
+
+// Returns the ura-dora indicators under the revealed dora indicators
+func (w *Wall) UraDoraIndicators() []Tile {
+	return append([]Tile{}, w.dead[uraDoraStart:uraDoraStart+w.doraRevealed]...)
+}
+
+// Reveals the next dora indicator, after a kan
+func (w *Wall) RevealDora() (Tile, bool) {
+	if w.doraRevealed >= maxDora {
+		return Tile{}, false
+	}
+	w.doraRevealed++
+	return w.dead[doraStart+w.doraRevealed-1], true
+}
+
+// Draws a replacement tile from the dead wall; the dead wall is replenished from the end
+// of the live wall, so the live wall shrinks by one. Once the four rinshan tiles are used,
+// replacements come from the replenished tiles.
+func (w *Wall) replacement() (Tile, bool) {
+	if len(w.live) == 0 {
+		return Tile{}, false
+	}
+	last := w.live[len(w.live)-1]
+	w.live = w.live[:len(w.live)-1]
+	if drawn := w.rinshanDrawn + w.kitaDrawn; drawn < doraStart-rinshanStart {
+		return w.dead[rinshanStart+drawn], true
+	}
+	return last, true
+}
+
+// Draws a replacement tile after a kan, at most four per round
+func (w *Wall) DrawRinshan() (Tile, bool) {
+	if w.rinshanDrawn >= doraStart-rinshanStart {
+		return Tile{}, false
+	}
+	t, ok := w.replacement()
+	if ok {
+		w.rinshanDrawn++
+	}
+	return t, ok
+}
+
+// Draws a replacement tile after setting aside a kita in sanma
+func (w *Wall) DrawKita() (Tile, bool) {
+	t, ok := w.replacement()
+	if ok {
+		w.kitaDrawn++
+	}
+	return t, ok
+}
+
+// Number of rinshan tiles drawn, one per kan
+func (w *Wall) Kans() int {
+	return w.rinshanDrawn
+}
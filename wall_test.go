@@ -0,0 +1,75 @@
+package main
+
+import (
+	"math/rand/v2"
+	"reflect"
+	"testing"
+)
+
+func TestBuildTiles(t *testing.T) {
+	tests := []struct {
+		name     string
+		redFives [3]int
+		wantRed  int
+	}{
+		{"No red fives", [3]int{0, 0, 0}, 0},
+		{"One per suit", [3]int{1, 1, 1}, 3},
+		{"Two red pinzu", [3]int{1, 2, 1}, 4},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tiles := buildTiles(Rules{RedFives: tt.redFives})
+			if len(tiles) != 136 {
+				t.Fatalf("buildTiles() = %v tiles, want 136", len(tiles))
+			}
+			red := 0
+			counts := map[int]int{}
+			for _, tile := range tiles {
+				counts[tile.ID]++
+				if tile.Red {
+					red++
+					if tile.Rank != 4 || tile.Suit == Honor {
+						t.Errorf("buildTiles() marked %v as red", tile.ID)
+					}
+				}
+			}
+			if red != tt.wantRed {
+				t.Errorf("buildTiles() red = %v, want %v", red, tt.wantRed)
+			}
+			for id := 0; id < 34; id++ {
+				if counts[id] != 4 {
+					t.Errorf("buildTiles() has %v copies of %v", counts[id], id)
+				}
+			}
+		})
+	}
+}
+
+func TestNewWall(t *testing.T) {
+	a := NewWall(DefaultRules(), rand.New(rand.NewPCG(9, 0)))
+	b := NewWall(DefaultRules(), rand.New(rand.NewPCG(9, 0)))
+	c := NewWall(DefaultRules(), rand.New(rand.NewPCG(10, 0)))
+	if !reflect.DeepEqual(a, b) {
+		t.Errorf("NewWall() with the same seed differs")
+	}
+	if reflect.DeepEqual(a.live, c.live) {
+		t.Errorf("NewWall() with different seeds is identical")
+	}
+	if a.Remaining() != 122 {
+		t.Errorf("Wall.Remaining() = %v, want 122", a.Remaining())
+	}
+	if len(a.DoraIndicators()) != 1 || len(a.UraDoraIndicators()) != 1 {
+		t.Errorf("NewWall() should reveal one dora indicator")
+	}
+	first := a.live[0]
+	if tile, ok := a.Draw(); !ok || tile != first || a.Remaining() != 121 {
+		t.Errorf("Wall.Draw() = %v, %v", tile, ok)
+	}
+	for a.Remaining() > 0 {
+		a.Draw()
+	}
+	if _, ok := a.Draw(); ok {
+		t.Errorf("Wall.Draw() on an empty wall should fail")
+	}
+}
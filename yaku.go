@@ -4,13 +4,24 @@ package main
 
 // Yaku and Scoring Definition
 type WinContext struct {
-	WinningTile Tile
-	Tsumo       bool // self-drawn win
-	Seat        int  // wind of the player
-	Round       int  // wind of the round
-	Menzen      bool // whether the hand is closed
-	Riichi      bool // whether the player declared riichi
-	TurnCount   int  // number of turns taken in the hand
+	WinningTile  Tile
+	Tsumo        bool // self-drawn win
+	Seat         int  // wind of the player
+	Round        int  // wind of the round
+	Menzen       bool // whether the hand is closed
+	Riichi       bool // whether the player declared riichi
+	DoubleRiichi bool // riichi declared on the first uninterrupted discard
+	Ippatsu      bool // won within one uninterrupted go-around of declaring riichi
+	TurnCount    int  // number of turns taken in the hand
+
+	Wait              WaitType // wait completed by the winning tile, Wait_Unknown if not determined
+	DoraIndicators    []Tile   // revealed dora indicators
+	UraDoraIndicators []Tile   // ura-dora indicators, only counted with riichi
+	AkaDora           int      // number of red fives in the hand
+	Rinshan           bool     // won on the replacement tile after a kan
+	Chankan           bool     // won by robbing another player's kan
+	Sanma             bool     // three-player game, where dora wrap from 1m to 9m
+	Kita              int      // Norths set aside as bonus dora in sanma
 }
 
 type Yaku interface {
@@ -22,6 +33,8 @@ type Yaku interface {
 
 var yakuList = []Yaku{
 	Yaku_Riichi{},
+	Yaku_DoubleRiichi{},
+	Yaku_Ippatsu{},
 	Yaku_Tsumo{},
 	Yaku_Tanyao{},
 	Yaku_Yakuhai{},
@@ -30,14 +43,34 @@ var yakuList = []Yaku{
 	Yaku_Chinitsu{},
 	Yaku_Honitsu{},
 	Yaku_Suuankou{},
+	Yaku_Daisangen{},
+	Yaku_Daisuushii{},
+	Yaku_Suukantsu{},
+	Yaku_Rinshan{},
+	Yaku_Chankan{},
 }
 
 var yakuListSpecial = []Yaku{
 	Yaku_Chiitoitsu{},
+	Yaku_KokushiMusou{},
+}
+
+// Yaku that do not depend on the set structure and may combine with Chiitoitsu
+var yakuListPairs = []Yaku{
+	Yaku_Riichi{},
+	Yaku_DoubleRiichi{},
+	Yaku_Ippatsu{},
+	Yaku_Tsumo{},
+	Yaku_Tanyao{},
+	Yaku_Chinitsu{},
+	Yaku_Honitsu{},
 }
 
 var yakuListBonus = []Yaku{
-	// Placeholder for bonus yaku like Dora, Ura-Dora, etc.
+	Yaku_Dora{},
+	Yaku_UraDora{},
+	Yaku_Kita{},
+	Yaku_AkaDora{},
 }
 
 func CheckAllYaku(hand Hand, sets []Set, winCtx WinContext) (int, []string) {
@@ -69,12 +102,22 @@ func CheckAllYaku(hand Hand, sets []Set, winCtx WinContext) (int, []string) {
 		}
 	}
 	if len(sets) == 0 { // Special hands like Chiitoitsu or Kokushi Musou
+		special := false
 		for _, yaku := range yakuListSpecial {
 			if han, ok := yaku.Check(hand, sets, winCtx); ok {
+				special = true
 				totalHan += han
 				yakus = append(yakus, yaku.Name())
 			}
 		}
+		if special {
+			for _, yaku := range yakuListPairs {
+				if han, ok := yaku.Check(hand, sets, winCtx); ok {
+					totalHan += han
+					yakus = append(yakus, yaku.Name())
+				}
+			}
+		}
 		return totalHan, yakus
 	}
 	for _, yaku := range yakuList {
@@ -90,7 +133,27 @@ type Yaku_Riichi struct{}
 
 func (y Yaku_Riichi) Name() string { return "Riichi" }
 func (y Yaku_Riichi) Check(hand Hand, sets []Set, winCtx WinContext) (int, bool) {
-	if winCtx.Riichi && winCtx.Menzen {
+	if winCtx.Riichi && winCtx.Menzen && !winCtx.DoubleRiichi {
+		return 1, true
+	}
+	return 0, false
+}
+
+type Yaku_DoubleRiichi struct{}
+
+func (y Yaku_DoubleRiichi) Name() string { return "Double Riichi" }
+func (y Yaku_DoubleRiichi) Check(hand Hand, sets []Set, winCtx WinContext) (int, bool) {
+	if winCtx.Riichi && winCtx.DoubleRiichi && winCtx.Menzen {
+		return 2, true
+	}
+	return 0, false
+}
+
+type Yaku_Ippatsu struct{}
+
+func (y Yaku_Ippatsu) Name() string { return "Ippatsu (One-shot)" }
+func (y Yaku_Ippatsu) Check(hand Hand, sets []Set, winCtx WinContext) (int, bool) {
+	if winCtx.Riichi && winCtx.Ippatsu && winCtx.Menzen {
 		return 1, true
 	}
 	return 0, false
@@ -168,6 +231,10 @@ func (y Yaku_Pinfu) Check(hand Hand, sets []Set, winCtx WinContext) (int, bool)
 	if winCtx.WinningTile.Suit == Honor {
 		return 0, false
 	}
+	// When the wait is known only a two-sided wait qualifies
+	if winCtx.Wait != Wait_Unknown && winCtx.Wait != Wait_Ryanmen {
+		return 0, false
+	}
 	for _, set := range sets {
 		if set.Type != Shuntsu {
 			return 0, false
@@ -362,3 +429,153 @@ func (y Yaku_Suuankou) Check(hand Hand, sets []Set, winCtx WinContext) (int, boo
 	// Yakuman: 13 han
 	return 13, true
 }
+
+// Counts the triplets and quads among the sets whose tile is in [lo, hi]
+func countTriplets(sets []Set, lo, hi int) int {
+	count := 0
+	for _, set := range sets {
+		if (set.Type == Koutsu || set.Type == Kantsu) && set.Tiles[0].ID >= lo && set.Tiles[0].ID <= hi {
+			count++
+		}
+	}
+	return count
+}
+
+type Yaku_Daisangen struct{}
+
+func (y Yaku_Daisangen) Name() string { return "Daisangen (Big Three Dragons)" }
+func (y Yaku_Daisangen) Check(hand Hand, sets []Set, winCtx WinContext) (int, bool) {
+	if countTriplets(sets, 31, 33) == 3 {
+		return 13, true
+	}
+	return 0, false
+}
+
+type Yaku_Daisuushii struct{}
+
+func (y Yaku_Daisuushii) Name() string { return "Daisuushii (Big Four Winds)" }
+func (y Yaku_Daisuushii) Check(hand Hand, sets []Set, winCtx WinContext) (int, bool) {
+	if countTriplets(sets, 27, 30) == 4 {
+		return 13, true
+	}
+	return 0, false
+}
+
+type Yaku_Suukantsu struct{}
+
+func (y Yaku_Suukantsu) Name() string { return "Suukantsu (Four Quads)" }
+func (y Yaku_Suukantsu) Check(hand Hand, sets []Set, winCtx WinContext) (int, bool) {
+	quads := 0
+	for _, set := range sets {
+		if set.Type == Kantsu {
+			quads++
+		}
+	}
+	if quads == 4 {
+		return 13, true
+	}
+	return 0, false
+}
+
+type Yaku_KokushiMusou struct{}
+
+func (y Yaku_KokushiMusou) Name() string { return "Kokushi Musou (Thirteen Orphans)" }
+func (y Yaku_KokushiMusou) Check(hand Hand, sets []Set, winCtx WinContext) (int, bool) {
+	if len(sets) != 0 {
+		return 0, false
+	}
+	hasPair := false
+	total := 0
+	for i, count := range hand.counts {
+		total += count
+		if !ParseTile(i, false).IsTerminalOrHonor() {
+			if count > 0 {
+				return 0, false
+			}
+			continue
+		}
+		switch count {
+		case 0:
+			return 0, false
+		case 1:
+		case 2:
+			if hasPair {
+				return 0, false
+			}
+			hasPair = true
+		default:
+			return 0, false
+		}
+	}
+	if !hasPair || total != 14 {
+		return 0, false
+	}
+	return 13, true
+}
+
+// Counts how many tiles in the hand are dora for the given indicators; the Norths set aside
+// as kita count too when North is the dora
+func countDora(hand Hand, indicators []Tile, sanma bool, kita int) int {
+	count := 0
+	for _, ind := range indicators {
+		dora := doraFor(ind, sanma).ID
+		count += hand.counts[dora]
+		if dora == 30 {
+			count += kita
+		}
+	}
+	return count
+}
+
+type Yaku_Dora struct{}
+
+func (y Yaku_Dora) Name() string { return "Dora" }
+func (y Yaku_Dora) Check(hand Hand, sets []Set, winCtx WinContext) (int, bool) {
+	han := countDora(hand, winCtx.DoraIndicators, winCtx.Sanma, winCtx.Kita)
+	return han, han > 0
+}
+
+type Yaku_UraDora struct{}
+
+func (y Yaku_UraDora) Name() string { return "Ura Dora" }
+func (y Yaku_UraDora) Check(hand Hand, sets []Set, winCtx WinContext) (int, bool) {
+	if !winCtx.Riichi {
+		return 0, false
+	}
+	han := countDora(hand, winCtx.UraDoraIndicators, winCtx.Sanma, winCtx.Kita)
+	return han, han > 0
+}
+
+type Yaku_AkaDora struct{}
+
+func (y Yaku_AkaDora) Name() string { return "Aka Dora" }
+func (y Yaku_AkaDora) Check(hand Hand, sets []Set, winCtx WinContext) (int, bool) {
+	return winCtx.AkaDora, winCtx.AkaDora > 0
+}
+
+type Yaku_Rinshan struct{}
+
+func (y Yaku_Rinshan) Name() string { return "Rinshan Kaihou (After a Kan)" }
+func (y Yaku_Rinshan) Check(hand Hand, sets []Set, winCtx WinContext) (int, bool) {
+	if winCtx.Rinshan && winCtx.Tsumo {
+		return 1, true
+	}
+	return 0, false
+}
+
+type Yaku_Chankan struct{}
+
+func (y Yaku_Chankan) Name() string { return "Chankan (Robbing a Kan)" }
+func (y Yaku_Chankan) Check(hand Hand, sets []Set, winCtx WinContext) (int, bool) {
+	if winCtx.Chankan && !winCtx.Tsumo {
+		return 1, true
+	}
+	return 0, false
+}
+
+type Yaku_Kita struct{}
+
+func (y Yaku_Kita) Name() string { return "Kita (Nukidora)" }
+func (y Yaku_Kita) Check(hand Hand, sets []Set, winCtx WinContext) (int, bool) {
+	return winCtx.Kita, winCtx.Kita > 0
+}
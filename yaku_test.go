@@ -798,6 +798,18 @@ func TestYakuNames(t *testing.T) {
 		{Yaku_Honitsu{}, "Honitsu (Half Flush)"},
 		{Yaku_Chiitoitsu{}, "Chiitoitsu (Seven Pairs)"},
 		{Yaku_Suuankou{}, "Suuankou (Four Concealed Triplets)"},
+		{Yaku_KokushiMusou{}, "Kokushi Musou (Thirteen Orphans)"},
+		{Yaku_Dora{}, "Dora"},
+		{Yaku_UraDora{}, "Ura Dora"},
+		{Yaku_AkaDora{}, "Aka Dora"},
+		{Yaku_Rinshan{}, "Rinshan Kaihou (After a Kan)"},
+		{Yaku_DoubleRiichi{}, "Double Riichi"},
+		{Yaku_Ippatsu{}, "Ippatsu (One-shot)"},
+		{Yaku_Kita{}, "Kita (Nukidora)"},
+		{Yaku_Daisangen{}, "Daisangen (Big Three Dragons)"},
+		{Yaku_Daisuushii{}, "Daisuushii (Big Four Winds)"},
+		{Yaku_Suukantsu{}, "Suukantsu (Four Quads)"},
+		{Yaku_Chankan{}, "Chankan (Robbing a Kan)"},
 	}
 
 	for _, tt := range tests {
@@ -808,3 +820,93 @@ func TestYakuNames(t *testing.T) {
 		})
 	}
 }
+
+func TestYaku_PinfuWait(t *testing.T) {
+	yaku := Yaku_Pinfu{}
+	sets := []Set{
+		{Type: Shuntsu, Tiles: []Tile{ParseTile(1, false), ParseTile(2, false), ParseTile(3, false)}},
+	}
+
+	tests := []struct {
+		name   string
+		wait   WaitType
+		wantOk bool
+	}{
+		{"Unknown wait falls back to set shape", Wait_Unknown, true},
+		{"Ryanmen", Wait_Ryanmen, true},
+		{"Tanki", Wait_Tanki, false},
+		{"Kanchan", Wait_Kanchan, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			winCtx := WinContext{Menzen: true, WinningTile: ParseTile(3, false), Wait: tt.wait}
+			if _, ok := yaku.Check(Hand{}, sets, winCtx); ok != tt.wantOk {
+				t.Errorf("Yaku_Pinfu.Check() ok = %v, want %v", ok, tt.wantOk)
+			}
+		})
+	}
+}
+
+func TestYaku_KokushiMusou(t *testing.T) {
+	yaku := Yaku_KokushiMusou{}
+
+	tests := []struct {
+		name    string
+		hand    Hand
+		wantHan int
+		wantOk  bool
+	}{
+		{"Thirteen orphans", handOf(0, 8, 9, 17, 18, 26, 27, 28, 29, 30, 31, 32, 33, 0), 13, true},
+		{"Missing an orphan", handOf(0, 8, 9, 17, 18, 26, 27, 28, 29, 30, 31, 32, 32, 0), 0, false},
+		{"Contains a simple", handOf(0, 8, 9, 17, 18, 26, 27, 28, 29, 30, 31, 32, 33, 1), 0, false},
+		{"Thirteen tiles only", handOf(0, 8, 9, 17, 18, 26, 27, 28, 29, 30, 31, 32, 33), 0, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gotHan, gotOk := yaku.Check(tt.hand, nil, WinContext{})
+			if gotHan != tt.wantHan || gotOk != tt.wantOk {
+				t.Errorf("Yaku_KokushiMusou.Check() = %v, %v, want %v, %v", gotHan, gotOk, tt.wantHan, tt.wantOk)
+			}
+		})
+	}
+}
+
+func TestYaku_Dora(t *testing.T) {
+	hand := handOf(1, 2, 3, 4, 5, 6, 11, 12, 13, 23, 24, 25, 13, 13)
+
+	tests := []struct {
+		name    string
+		yaku    Yaku
+		winCtx  WinContext
+		wantHan int
+	}{
+		{"No indicators", Yaku_Dora{}, WinContext{}, 0},
+		{"Dora pair", Yaku_Dora{}, WinContext{DoraIndicators: []Tile{ParseTile(12, false)}}, 3},
+		{"Two indicators", Yaku_Dora{}, WinContext{DoraIndicators: []Tile{ParseTile(0, false), ParseTile(27, false)}}, 1},
+		{"Ura without riichi", Yaku_UraDora{}, WinContext{UraDoraIndicators: []Tile{ParseTile(12, false)}}, 0},
+		{"Ura with riichi", Yaku_UraDora{}, WinContext{Riichi: true, UraDoraIndicators: []Tile{ParseTile(12, false)}}, 3},
+		{"Red fives", Yaku_AkaDora{}, WinContext{AkaDora: 2}, 2},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gotHan, gotOk := tt.yaku.Check(hand, nil, tt.winCtx)
+			if gotHan != tt.wantHan || gotOk != (tt.wantHan > 0) {
+				t.Errorf("%v.Check() = %v, %v, want %v", tt.yaku.Name(), gotHan, gotOk, tt.wantHan)
+			}
+		})
+	}
+}
+
+func TestCheckAllYaku_ChiitoitsuCombinations(t *testing.T) {
+	// Seven pairs of simples in one suit plus riichi and tsumo
+	hand := handOf(1, 1, 2, 2, 3, 3, 4, 4, 5, 5, 6, 6, 7, 7)
+	winCtx := WinContext{Riichi: true, Tsumo: true, Menzen: true}
+	han, yakus := CheckAllYaku(hand, nil, winCtx)
+	// Chiitoitsu(2) + Riichi(1) + Tsumo(1) + Tanyao(1) + Chinitsu(6)
+	if han != 11 {
+		t.Errorf("CheckAllYaku() han = %v, want 11 (%v)", han, yakus)
+	}
+}